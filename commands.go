@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/schemacheck"
+	"github.com/you/pawtrack/internal/service"
+)
+
+const usage = `usage: pawtrack [command]
+
+Without a command the API server is started.
+
+Commands:
+  migrate up         apply all pending migrations
+  migrate down N     roll back the last N migrations
+  migrate goto V     migrate up or down to version V
+  migrate status     print the applied and the latest version
+  migrate force V    record version V as applied and clear the dirty flag
+                     without running migrations (-1: none applied)
+  schema check       apply the migrations to a scratch database and compare
+                     the result with the models; exits with 1 on drift
+  storage gc [-dry-run]
+                     reconcile the storage backend with the database: fix
+                     reference counts, delete unreferenced files and files
+                     without a record, and list missing files; -dry-run only
+                     reports
+`
+
+// runCommand runs a command-line subcommand and returns the exit code
+func runCommand(args []string) int {
+	var err error
+	switch args[0] {
+	case "migrate":
+		err = migrateCommand(args[1:])
+	case "schema":
+		err = schemaCommand(args[1:])
+	case "storage":
+		err = storageCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		err = errUsage
+	}
+
+	if err == errUsage {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// errUsage reports invalid command-line arguments
+var errUsage = errors.New("invalid arguments")
+
+// migrateCommand runs "pawtrack migrate ..."
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	action := args[0]
+	var number int
+	switch action {
+	case "up", "status":
+		if len(args) != 1 {
+			return errUsage
+		}
+	case "down", "goto", "force":
+		if len(args) != 2 {
+			return errUsage
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errUsage
+		}
+		number = n
+	default:
+		return errUsage
+	}
+
+	mg, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	switch action {
+	case "up":
+		err = mg.Up()
+	case "down":
+		err = mg.Down(number)
+	case "goto":
+		if number < 0 {
+			return errUsage
+		}
+		err = mg.Goto(uint(number))
+	case "force":
+		err = mg.Force(number)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d\ndirty: %t\nlatest: %d\n", status.Version, status.Dirty, status.Latest)
+	return nil
+}
+
+// errDrift reports that the schema check found differences
+var errDrift = errors.New("the schema created by the migrations does not match the models")
+
+// schemaCommand runs "pawtrack schema check". SQLite is checked in a temporary
+// file; Postgres in a temporary schema of the configured database.
+func schemaCommand(args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errUsage
+	}
+
+	drifts, err := schemacheck.Run(dbType(), postgresURL())
+	if err != nil {
+		return err
+	}
+	for _, drift := range drifts {
+		fmt.Println(drift)
+	}
+	if len(drifts) > 0 {
+		return errDrift
+	}
+	fmt.Printf("%s schema matches the models\n", dbType())
+	return nil
+}
+
+// storageCommand runs "pawtrack storage gc [-dry-run]" on the backend selected
+// by STORAGE_TYPE. Files younger than STORAGE_GC_GRACE_MINUTES are left alone,
+// so it is safe to run while the server accepts uploads.
+func storageCommand(args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return errUsage
+	}
+	dryRun := false
+	switch {
+	case len(args) == 1:
+	case len(args) == 2 && (args[1] == "-dry-run" || args[1] == "--dry-run"):
+		dryRun = true
+	default:
+		return errUsage
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	backend, err := newFileStorage()
+	if err != nil {
+		return err
+	}
+
+	store := service.NewObjectStore(repository.NewStoredObjectRepository(db), backend, objectStoreOptions())
+	report, err := store.Reconcile(context.Background(), dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "deleted"
+	if dryRun {
+		verb = "to delete"
+	}
+	fmt.Printf("backend: %s\n", backend.Name())
+	fmt.Printf("files: %d (%d bytes)\n", report.Files, report.Bytes)
+	fmt.Printf("untracked keys: %d\n", report.Untracked)
+	fmt.Printf("wrong reference counts: %d\n", report.Recounted)
+	fmt.Printf("unreferenced files %s: %d\n", verb, report.Released)
+	fmt.Printf("orphaned files %s: %d (%d bytes)\n", verb, report.Orphans, report.OrphanBytes)
+	fmt.Printf("missing files: %d\n", len(report.Missing))
+	for _, key := range report.Missing {
+		fmt.Printf("  %s\n", key)
+	}
+	if dryRun {
+		fmt.Println("dry run: nothing was changed")
+	}
+	return nil
+}
@@ -15,116 +15,38 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Authenticate with email and password, returns JWT token",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "User login",
-                "parameters": [
-                    {
-                        "description": "Login Credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.LoginRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handler.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/consultant-notes": {
+        "/admin/jobs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List notes with filtering and sorting",
+                "description": "Jobs of all types, newest first (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "consultant-notes"
+                    "admin"
                 ],
-                "summary": "List consultant notes",
+                "summary": "List background jobs",
                 "parameters": [
                     {
+                        "enum": [
+                            "pending",
+                            "running",
+                            "succeeded",
+                            "dead"
+                        ],
                         "type": "string",
-                        "description": "Search in title and content",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Filter by dog ID",
-                        "name": "dog_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Filter by owner ID",
-                        "name": "owner_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter from date (RFC3339)",
-                        "name": "from_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter to date (RFC3339)",
-                        "name": "to_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "created_at",
-                        "description": "Sort by: created_at, updated_at, dog_name, owner_name",
-                        "name": "sort_by",
+                        "description": "Filter by status",
+                        "name": "status",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "default": "desc",
-                        "description": "Order: asc, desc",
-                        "name": "order",
+                        "description": "Filter by job type",
+                        "name": "type",
                         "in": "query"
                     },
                     {
@@ -146,7 +68,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.NoteListResponse"
+                            "$ref": "#/definitions/dto.JobListResponse"
                         }
                     },
                     "400": {
@@ -167,6 +89,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -177,44 +108,41 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/jobs/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new note about a dog (Consultant only, requires access to dog)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Job details with payload and last error (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "consultant-notes"
+                    "admin"
                 ],
-                "summary": "Create consultant note",
+                "summary": "Get background job",
                 "parameters": [
                     {
-                        "description": "Note Data",
-                        "name": "note",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateNoteRequest"
-                        }
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ConsultantNote"
+                            "$ref": "#/definitions/dto.JobResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -222,8 +150,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -231,8 +159,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -252,35 +180,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/consultant-notes/{id}": {
-            "get": {
+        "/admin/jobs/{id}/retry": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get note by ID",
+                "description": "Puts a dead-lettered job back in the queue with fresh attempts (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "consultant-notes"
+                    "admin"
                 ],
-                "summary": "Get consultant note",
+                "summary": "Retry dead job",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "Job ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/dto.NoteResponse"
+                            "$ref": "#/definitions/dto.JobResponse"
                         }
                     },
                     "401": {
@@ -292,6 +220,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -301,6 +238,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -311,60 +257,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/attachments/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update note by ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Remove a file from its event, comment or note. Event files are removed by those who may delete the event, comment and note files by their author.",
                 "tags": [
-                    "consultant-notes"
+                    "attachments"
                 ],
-                "summary": "Update consultant note",
+                "summary": "Remove an attachment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "Attachment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Note Data",
-                        "name": "note",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateNoteRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.ConsultantNote"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -391,36 +312,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/attachments/{id}/content": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete note by ID",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Redirects to a short-lived signed URL of the file if the user can see the event the attachment belongs to, directly or through a comment.\nFor links and \u003cimg\u003e tags, where the Authorization header cannot be set, get the signed URL from /attachments/{id}/link.\nImages and videos open inline; documents and other files are served with Content-Disposition: attachment.\nFiles are downloadable once the malware scan finds them clean: 423 while quarantined, 403 when infected.",
                 "tags": [
-                    "consultant-notes"
+                    "attachments"
                 ],
-                "summary": "Delete consultant note",
+                "summary": "Download an attachment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "Attachment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "302": {
+                        "description": "Found"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -437,6 +357,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -449,71 +378,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/consultants": {
+        "/attachments/{id}/link": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Search consultants by name, services, breeds, location",
+                "description": "Returns the short-lived signed URL GET /attachments/{id}/content redirects to, with the same access rules.\nThe URL needs no token, so it can be used in links and \u003cimg\u003e tags.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "consultants"
+                    "attachments"
                 ],
-                "summary": "Search consultants",
+                "summary": "Get a signed URL of an attachment",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Search query",
-                        "name": "query",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by services",
-                        "name": "services",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by breeds",
-                        "name": "breeds",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by location",
-                        "name": "location",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "default": 20,
-                        "description": "Page size",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AttachmentLinkResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -533,44 +448,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/consultants/profile": {
-            "put": {
+        "/attachments/{id}/thumbnails/{size}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update profile details (Consultant only)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Redirects to a short-lived signed URL of a thumbnail of a JPEG, PNG or WebP attachment, with the same access rules as the file.\nSizes by the longer side: small 160px, medium 480px, large 1280px; smaller images are not scaled up.",
                 "tags": [
-                    "consultants"
+                    "attachments"
                 ],
-                "summary": "Update consultant profile",
+                "summary": "Download a thumbnail of an image",
                 "parameters": [
                     {
-                        "description": "Profile Data",
-                        "name": "profile",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateProfileRequest"
-                        }
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "small",
+                            "medium",
+                            "large"
+                        ],
+                        "type": "string",
+                        "description": "Thumbnail size",
+                        "name": "size",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "302": {
+                        "description": "Found"
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/models.ConsultantProfile"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -578,8 +503,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "423": {
+                        "description": "Locked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -599,35 +524,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/consultants/{id}": {
+        "/attachments/{id}/thumbnails/{size}/link": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get profile by ID",
+                "description": "Returns the short-lived signed URL GET /attachments/{id}/thumbnails/{size} redirects to, for use in \u003cimg\u003e tags.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "consultants"
+                    "attachments"
                 ],
-                "summary": "Get consultant profile",
+                "summary": "Get a signed URL of a thumbnail",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Consultant ID",
+                        "description": "Attachment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "small",
+                            "medium",
+                            "large"
+                        ],
+                        "type": "string",
+                        "description": "Thumbnail size",
+                        "name": "size",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.ConsultantProfileResponse"
+                            "$ref": "#/definitions/dto.AttachmentLinkResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
@@ -639,6 +585,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -651,14 +606,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/consultants/{id}/invite": {
+        "/auth/login": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Invite a consultant to manage a dog (Owner only)",
+                "description": "Authenticate with email and password, returns JWT token",
                 "consumes": [
                     "application/json"
                 ],
@@ -666,32 +616,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "consultants"
+                    "auth"
                 ],
-                "summary": "Invite consultant",
+                "summary": "User login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Consultant ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Invite Data",
-                        "name": "request",
+                        "description": "Login Credentials",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateInviteRequest"
+                            "$ref": "#/definitions/handler.LoginRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.InviteResponse"
+                            "$ref": "#/definitions/handler.LoginResponse"
                         }
                     },
                     "400": {
@@ -711,41 +654,107 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
                     }
                 }
             }
         },
-        "/dogs": {
+        "/consultant-notes": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of dogs",
+                "description": "List notes with filtering and sorting",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dogs"
+                    "consultant-notes"
+                ],
+                "summary": "List consultant notes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search in title and content",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by dog ID",
+                        "name": "dog_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by owner ID",
+                        "name": "owner_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter from date (RFC3339)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter to date (RFC3339)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort by: created_at, updated_at, dog_name, owner_name",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order: asc, desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List dogs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Dog"
+                            "$ref": "#/definitions/dto.NoteListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -766,7 +775,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new dog (requires authentication and owner role)",
+                "description": "Create a new note about a dog (Consultant only, requires access to dog)",
                 "consumes": [
                     "application/json"
                 ],
@@ -774,17 +783,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "dogs"
+                    "consultant-notes"
                 ],
-                "summary": "Create a new dog",
+                "summary": "Create consultant note",
                 "parameters": [
                     {
-                        "description": "Dog Data",
-                        "name": "dog",
+                        "description": "Note Data",
+                        "name": "note",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateDogRequest"
+                            "$ref": "#/definitions/dto.CreateNoteRequest"
                         }
                     }
                 ],
@@ -792,7 +801,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Dog"
+                            "$ref": "#/definitions/models.ConsultantNote"
                         }
                     },
                     "400": {
@@ -834,25 +843,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/dogs/{id}": {
+        "/consultant-notes/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get details of a specific dog",
+                "description": "Get note by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dogs"
+                    "consultant-notes"
                 ],
-                "summary": "Get dog by ID",
+                "summary": "Get consultant note",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Dog ID",
+                        "description": "Note ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -862,7 +871,16 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Dog"
+                            "$ref": "#/definitions/dto.NoteResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
@@ -891,7 +909,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a dog by ID",
+                "description": "Update note by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -899,24 +917,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "dogs"
+                    "consultant-notes"
                 ],
-                "summary": "Update dog",
+                "summary": "Update consultant note",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Dog ID",
+                        "description": "Note ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Dog Data",
-                        "name": "dog",
+                        "description": "Note Data",
+                        "name": "note",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateDogRequest"
+                            "$ref": "#/definitions/dto.UpdateNoteRequest"
                         }
                     }
                 ],
@@ -924,7 +942,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Dog"
+                            "$ref": "#/definitions/models.ConsultantNote"
                         }
                     },
                     "400": {
@@ -936,6 +954,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -962,18 +989,18 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a dog by ID",
+                "description": "Delete note by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dogs"
+                    "consultant-notes"
                 ],
-                "summary": "Delete dog",
+                "summary": "Delete consultant note",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Dog ID",
+                        "description": "Note ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -983,6 +1010,24 @@ const docTemplate = `{
                     "204": {
                         "description": "No Content"
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -995,39 +1040,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/event-comments/{id}": {
-            "get": {
+        "/consultant-notes/{id}/attachments": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get comment by ID",
+                "description": "Upload one or more files (repeated \"file\" parts, up to 10) to a note of the consultant\nAlternatively send JSON {\"upload_ids\": [...]} to attach completed resumable uploads (see /uploads).",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "event-comments"
+                    "attachments"
                 ],
-                "summary": "Get event comment",
+                "summary": "Attach files to a consultant note",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Comment ID",
+                        "description": "Note ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File, may be repeated",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dto.CommentResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1035,8 +1094,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1044,8 +1103,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1063,47 +1122,69 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/consultants": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update comment by ID (only author or admin)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Search consultants by name, services, breeds, location",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "event-comments"
+                    "consultants"
                 ],
-                "summary": "Update event comment",
+                "summary": "Search consultants",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by services",
+                        "name": "services",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by breeds",
+                        "name": "breeds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by location",
+                        "name": "location",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Comment ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "Comment Data",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateCommentRequest"
-                        }
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.EventComment"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1115,33 +1196,6 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1152,45 +1206,46 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/consultants/profile": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete comment by ID (only author or admin)",
+                "description": "Update profile details (Consultant only)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "event-comments"
+                    "consultants"
                 ],
-                "summary": "Delete event comment",
+                "summary": "Update consultant profile",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Profile Data",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateProfileRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.ConsultantProfile"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1198,8 +1253,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1219,96 +1274,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/events": {
+        "/consultants/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get paginated events with filters and sorting",
+                "description": "Get profile by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "consultants"
                 ],
-                "summary": "List events with filtering",
+                "summary": "Get consultant profile",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "From date (YYYY-MM-DD)",
-                        "name": "from_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "To date (YYYY-MM-DD)",
-                        "name": "to_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Event types (comma-separated)",
-                        "name": "types",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search in notes and dog names",
-                        "name": "search",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by dog name",
-                        "name": "dog_name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "default": 20,
-                        "description": "Page size",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "created_at",
-                            "type"
-                        ],
-                        "type": "string",
-                        "description": "Sort by field",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
-                        "type": "string",
-                        "description": "Sort order",
-                        "name": "sort_order",
-                        "in": "query"
+                        "description": "Consultant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.EventListResponse"
+                            "$ref": "#/definitions/dto.ConsultantProfileResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1326,38 +1324,49 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/consultants/{id}/invite": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new pet event with optional file attachment",
+                "description": "Invite a consultant to manage a dog (Owner only)",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "consultants"
                 ],
-                "summary": "Create a new event",
+                "summary": "Invite consultant",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Event Data (JSON)",
-                        "name": "data",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "Consultant ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Invite Data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateInviteRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "$ref": "#/definitions/dto.InviteResponse"
                         }
                     },
                     "400": {
@@ -1369,6 +1378,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1381,43 +1399,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/events/{id}": {
+        "/dogs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get details of a specific event",
+                "description": "Get a list of dogs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
-                ],
-                "summary": "Get event by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Event ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "dogs"
                 ],
+                "summary": "List dogs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Dog"
                             }
                         }
                     },
@@ -1432,32 +1435,67 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete an event by ID",
+                "description": "Create a new dog (requires authentication and owner role)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "dogs"
                 ],
-                "summary": "Delete event",
+                "summary": "Create a new dog",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Event ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Dog Data",
+                        "name": "dog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateDogRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Dog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -1471,25 +1509,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/events/{id}/comments": {
+        "/dogs/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all comments for an event",
+                "description": "Get details of a specific dog",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "event-comments"
+                    "dogs"
                 ],
-                "summary": "List event comments",
+                "summary": "Get dog by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Event ID",
+                        "description": "Dog ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1499,20 +1537,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.CommentListResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Dog"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1531,13 +1560,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new comment on an event (Owner or Consultant with access)",
+                "description": "Update a dog by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1545,32 +1574,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "event-comments"
+                    "dogs"
                 ],
-                "summary": "Create event comment",
+                "summary": "Update dog",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Event ID",
+                        "description": "Dog ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Comment Data",
-                        "name": "comment",
+                        "description": "Dog Data",
+                        "name": "dog",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateCommentRequest"
+                            "$ref": "#/definitions/dto.UpdateDogRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.EventComment"
+                            "$ref": "#/definitions/models.Dog"
                         }
                     },
                     "400": {
@@ -1582,17 +1611,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1610,60 +1630,105 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Check service health and DB connection",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a dog by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "system"
+                    "dogs"
+                ],
+                "summary": "Delete dog",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Dog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.healthResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/invites/accept": {
-            "post": {
+        "/dogs/{id}/stats": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Accept an invitation to manage a dog (Consultant only)",
+                "description": "Event counts by type grouped by day, week or month, totals of structured fields and longest daily streaks",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "invites"
+                    "dogs"
                 ],
-                "summary": "Accept invite",
+                "summary": "Get dog statistics",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Invite Token",
-                        "name": "token",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Dog ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Grouping period",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From datetime (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To datetime (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event types (comma-separated)",
+                        "name": "types",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.DogStatsResponse"
                         }
                     },
                     "400": {
@@ -1675,8 +1740,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1696,33 +1761,66 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/dogs/{id}/stream": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of all users",
+                "description": "Server-Sent Events with created/updated/deleted events, comments and notes of a dog.\nReconnect with the Last-Event-ID header (or last_event_id query) to receive missed messages; a \"reset\" event means some were lost.\nEventSource clients may pass the JWT as access_token query parameter.\nAccess is rechecked while the stream is open; the stream ends once it is lost and reconnecting returns 404.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "users"
+                    "dogs"
+                ],
+                "summary": "Stream dog changes (SSE)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Dog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last received message",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Same as Last-Event-ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT if the Authorization header cannot be set",
+                        "name": "access_token",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/realtime.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1731,35 +1829,50 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Register a new user with email and password",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/dogs/{id}/timeline": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Events, event comments and visible consultant notes of a dog merged into one feed, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "dogs"
                 ],
-                "summary": "Create a new user",
+                "summary": "Get dog timeline",
                 "parameters": [
                     {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateUserRequest"
-                        }
+                        "type": "integer",
+                        "description": "Dog ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page (next_cursor)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/dto.TimelineResponse"
                         }
                     },
                     "400": {
@@ -1771,6 +1884,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1783,39 +1905,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/dogs/{id}/ws": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get details of a specific user",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Same messages as /dogs/{id}/stream as JSON text frames. A {\"type\":\"reset\"} frame means some messages were lost.",
                 "tags": [
-                    "users"
+                    "dogs"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Stream dog changes (WebSocket)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Dog ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last received message",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT if the Authorization header cannot be set",
+                        "name": "access_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "101": {
+                        "description": "Switching Protocols",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/realtime.Message"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1823,8 +1954,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1833,51 +1964,50 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/event-comments/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a user by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get comment by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "event-comments"
                 ],
-                "summary": "Update user",
+                "summary": "Get event comment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateUserRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/dto.CommentResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1905,33 +2035,149 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a user by ID",
+                "description": "Update comment by ID (only author or admin)",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "event-comments"
                 ],
-                "summary": "Delete user",
+                "summary": "Update event comment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
+                    },
+                    {
+                        "description": "Comment Data",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EventComment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete comment by ID (only author or admin)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "event-comments"
+                ],
+                "summary": "Delete event comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "204": {
                         "description": "No Content"
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1943,137 +2189,2652 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.CommentListResponse": {
-            "type": "object",
-            "properties": {
-                "comments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.CommentResponse"
+        },
+        "/event-comments/{id}/attachments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload one or more files (repeated \"file\" parts, up to 10) to a comment. Only the author or an admin can attach files.\nAlternatively send JSON {\"upload_ids\": [...]} to attach completed resumable uploads (see /uploads).",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Attach files to a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File, may be repeated",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
-                },
-                "count": {
-                    "type": "integer"
                 }
             }
         },
-        "dto.CommentResponse": {
-            "type": "object",
-            "properties": {
-                "content": {
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get paginated events with filters and sorting",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "List events with filtering",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "From date (YYYY-MM-DD)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date (YYYY-MM-DD)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From datetime (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To datetime (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by dog ID",
+                        "name": "dog_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Event types (comma-separated)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search in notes and dog names",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by dog name",
+                        "name": "dog_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "at",
+                            "created_at",
+                            "type"
+                        ],
+                        "type": "string",
+                        "description": "Sort by field",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.EventListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new pet event with optional file attachments (repeated \"file\" parts, up to 10)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Create a new event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event Data (JSON)",
+                        "name": "data",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Attachment, may be repeated",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/events/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create up to 100 events in one call. Every item is validated like POST /events; items for dogs the user cannot access fail with 404.\nmode=atomic (default) creates all items or none; mode=partial creates the valid items and reports failures per item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Create events in bulk",
+                "parameters": [
+                    {
+                        "description": "Events",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchCreateEventsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "All items created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchCreateEventsResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Partial mode, some items failed",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchCreateEventsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Atomic mode, nothing created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchCreateEventsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/events/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get details of a specific event",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Get event by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an event by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Delete event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/events/{id}/attachments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload one or more files (repeated \"file\" parts, up to 10) to an event of a dog the user can access\nAlternatively send JSON {\"upload_ids\": [...]} to attach completed resumable uploads (see /uploads).",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Attach files to an event",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File, may be repeated",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/events/{id}/comments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all comments for an event",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "event-comments"
+                ],
+                "summary": "List event comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CommentListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new comment on an event (Owner or Consultant with access)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "event-comments"
+                ],
+                "summary": "Create event comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment Data",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.EventComment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check service health, DB connection and schema version",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.healthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/invites/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accept an invitation to manage a dog (Consultant only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Accept invite",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invite Token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dogs, events, comments and notes visible to the caller that were created or updated since the token, plus tombstones for deletions.\nWithout since a full snapshot is returned. Records may repeat across pulls and should be upserted by id.\nResults are paged: while has_more is true, pull again with next_since to get the rest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Pull changes since a checkpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the previous pull",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, records of all kinds together (default 500, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SyncPullResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates events, comments and notes made offline, identified by client-generated UUIDs.\nEach record is authorized like the regular create endpoints and gets its own result: created, exists (already pushed), conflict or rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Push records created offline",
+                "parameters": [
+                    {
+                        "description": "Offline records",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SyncPushRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SyncPushResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "tus 1.0 creation. The file name and type are sent base64-encoded in Upload-Metadata (\"filename\" and \"filetype\").\nThe first chunk may be sent in the body with Content-Type application/offset+octet-stream.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Start a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "File size in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "filename \u003cbase64\u003e,filetype \u003cbase64\u003e",
+                        "name": "Upload-Metadata",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the upload; its last segment is the upload ID"
+                            },
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "When an unfinished or unattached upload is removed"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "options": {
+                "description": "tus 1.0 discovery: supported versions, extensions and the largest accepted upload",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Discover resumable upload support",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Tus-Extension": {
+                                "type": "string",
+                                "description": "creation,creation-with-upload,termination,expiration"
+                            },
+                            "Tus-Max-Size": {
+                                "type": "integer",
+                                "description": "Largest accepted upload in bytes"
+                            },
+                            "Tus-Version": {
+                                "type": "string",
+                                "description": "1.0.0"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "tus 1.0 termination: removes the upload and the bytes received",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Cancel a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "tus 1.0 HEAD: the number of bytes received, from which the client resumes",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get the offset of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "Upload-Length": {
+                                "type": "integer",
+                                "description": "File size in bytes"
+                            },
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "Bytes received"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "tus 1.0 PATCH: appends the body at Upload-Offset, which must equal the upload's offset.\nWhen the last byte arrives the file is checked by its content; an upload of a type that is not allowed is removed.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Send a chunk of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the chunk",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "Bytes received"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of all users",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a new user with email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get details of a specific user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a user by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a user by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Own webhook endpoints; admins see all of them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook endpoints",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Subscribe a URL to event types. Deliveries are signed with the returned secret, which is shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register webhook endpoint",
+                "parameters": [
+                    {
+                        "description": "Webhook data",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change URL, description, event types or pause the endpoint with active=false. Omitted fields are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes the endpoint together with its delivery log",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook endpoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delivery log of an endpoint with attempts, last response and the sent payload, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (pending, succeeded, failed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveryListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queues a new delivery with the same payload; the original stays in the log",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AttachmentLinkResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-11-22T10:05:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/files/events/2025/11/3f2a9c.jpg?expires=1763805900\u0026signature=9b1c..."
+                }
+            }
+        },
+        "dto.BatchCreateEventsRequest": {
+            "type": "object",
+            "required": [
+                "events"
+            ],
+            "properties": {
+                "events": {
+                    "description": "at most MaxBatchEvents, items are validated one by one",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.CreateEventRequest"
+                    }
+                },
+                "mode": {
+                    "description": "default: atomic",
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "partial"
+                    ],
+                    "example": "atomic"
+                }
+            }
+        },
+        "dto.BatchCreateEventsResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchEventResult"
+                    }
+                }
+            }
+        },
+        "dto.BatchEventResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/models.Event"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "description": "201, 400 for validation errors, 404 if the dog is missing or not accessible",
+                    "type": "integer",
+                    "example": 201
+                }
+            }
+        },
+        "dto.CommentListResponse": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommentResponse"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommentResponse": {
+            "type": "object",
+            "properties": {
+                "attachment_url": {
+                    "description": "Deprecated: download path of the first attachment, see Attachments",
+                    "type": "string"
+                },
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
+                },
+                "user_role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ConsultantProfileResponse": {
+            "type": "object",
+            "properties": {
+                "breeds": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "services": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateCommentRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "event_id"
+            ],
+            "properties": {
+                "client_id": {
+                    "description": "Optional client-generated UUID",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreateDogRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "birth_date": {
+                    "type": "string",
+                    "example": "2020-01-01T00:00:00Z"
+                },
+                "breed": {
+                    "type": "string",
+                    "example": "German Shepherd"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2,
+                    "example": "Rex"
+                }
+            }
+        },
+        "dto.CreateEventRequest": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "amount_grams": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 150
+                },
+                "at": {
+                    "type": "string",
+                    "example": "2025-11-22T10:00:00Z"
+                },
+                "client_id": {
+                    "description": "Optional client-generated UUID",
+                    "type": "string",
+                    "example": "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
+                },
+                "dog_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "duration_minutes": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 30
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "morning walk"
+                },
+                "type": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "walk"
+                }
+            }
+        },
+        "dto.CreateInviteRequest": {
+            "type": "object",
+            "required": [
+                "dog_id"
+            ],
+            "properties": {
+                "dog_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreateNoteRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "dog_id",
+                "title"
+            ],
+            "properties": {
+                "client_id": {
+                    "description": "Optional client-generated UUID",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "dog_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "john@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2,
+                    "example": "John Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 6,
+                    "example": "securepassword123"
+                },
+                "role": {
+                    "enum": [
+                        "owner",
+                        "consultant"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserRole"
+                        }
+                    ],
+                    "example": "owner"
+                }
+            }
+        },
+        "dto.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "CRM sync"
+                },
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "event.created",
+                        "comment.created"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "https://example.com/hooks/pawtrack"
+                }
+            }
+        },
+        "dto.DogStatsResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StatsBucket"
+                    }
+                },
+                "dog_id": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "streaks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StatsStreak"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "totals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StatsTypeTotals"
+                    }
+                }
+            }
+        },
+        "dto.EventListResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Event"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.InviteResponse": {
+            "type": "object",
+            "properties": {
+                "consultant_id": {
+                    "type": "integer"
+                },
+                "dog_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Only returned to owner",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.JobListResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.JobResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.JobResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "locked_by": {
+                    "description": "Worker running the job",
+                    "type": "string"
+                },
+                "locked_until": {
+                    "description": "Running jobs past this time are reclaimed",
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "run_at": {
+                    "description": "Not started before this time",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.JobStatus"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.NoteListResponse": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NoteResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.NoteResponse": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "consultant_id": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dog_id": {
+                    "type": "integer"
+                },
+                "dog_name": {
                     "type": "string"
                 },
-                "created_at": {
+                "highlight": {
+                    "description": "Search results only: content with matches in \u003cmark\u003e, HTML-escaped",
                     "type": "string"
                 },
-                "event_id": {
+                "id": {
                     "type": "integer"
                 },
-                "id": {
+                "owner_id": {
                     "type": "integer"
                 },
-                "updated_at": {
+                "owner_name": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
-                },
-                "user_name": {
+                "title": {
                     "type": "string"
                 },
-                "user_role": {
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "dto.ConsultantProfileResponse": {
+        "dto.StatsBucket": {
             "type": "object",
             "properties": {
-                "breeds": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
+                "counts": {
+                    "description": "Event count by type",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 },
-                "email": {
-                    "type": "string"
+                "period": {
+                    "description": "First day of the period (YYYY-MM-DD, UTC)",
+                    "type": "string",
+                    "example": "2025-11-01"
                 },
-                "id": {
+                "total": {
                     "type": "integer"
+                }
+            }
+        },
+        "dto.StatsStreak": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 12
                 },
-                "location": {
-                    "type": "string"
+                "end": {
+                    "type": "string",
+                    "example": "2025-11-14"
                 },
-                "name": {
-                    "type": "string"
+                "start": {
+                    "type": "string",
+                    "example": "2025-11-03"
                 },
-                "services": {
-                    "type": "string"
+                "type": {
+                    "type": "string",
+                    "example": "walk"
+                }
+            }
+        },
+        "dto.StatsTypeTotals": {
+            "type": "object",
+            "properties": {
+                "amount_grams": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "surname": {
-                    "type": "string"
+                "count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "duration_minutes": {
+                    "type": "integer",
+                    "example": 1260
+                },
+                "type": {
+                    "type": "string",
+                    "example": "walk"
                 }
             }
         },
-        "dto.CreateCommentRequest": {
+        "dto.SyncCommentPush": {
             "type": "object",
             "required": [
-                "content",
-                "event_id"
+                "client_id",
+                "content"
             ],
             "properties": {
+                "client_id": {
+                    "type": "string"
+                },
                 "content": {
                     "type": "string"
                 },
+                "event_client_id": {
+                    "type": "string"
+                },
                 "event_id": {
                     "type": "integer"
                 }
             }
         },
-        "dto.CreateDogRequest": {
+        "dto.SyncEventPush": {
             "type": "object",
             "required": [
-                "name"
+                "client_id",
+                "type"
             ],
             "properties": {
-                "birth_date": {
+                "amount_grams": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 150
+                },
+                "at": {
                     "type": "string",
-                    "example": "2020-01-01T00:00:00Z"
+                    "example": "2025-11-22T10:00:00Z"
                 },
-                "breed": {
+                "client_id": {
                     "type": "string",
-                    "example": "German Shepherd"
+                    "example": "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
                 },
-                "name": {
+                "dog_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "duration_minutes": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 30
+                },
+                "note": {
                     "type": "string",
                     "maxLength": 255,
-                    "minLength": 2,
-                    "example": "Rex"
-                }
-            }
-        },
-        "dto.CreateInviteRequest": {
-            "type": "object",
-            "required": [
-                "dog_id"
-            ],
-            "properties": {
-                "dog_id": {
-                    "type": "integer"
+                    "example": "morning walk"
+                },
+                "type": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "walk"
                 }
             }
         },
-        "dto.CreateNoteRequest": {
+        "dto.SyncNotePush": {
             "type": "object",
             "required": [
+                "client_id",
                 "content",
                 "dog_id",
                 "title"
             ],
             "properties": {
+                "client_id": {
+                    "type": "string"
+                },
                 "content": {
                     "type": "string"
                 },
@@ -2086,146 +4847,170 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.CreateUserRequest": {
+        "dto.SyncPullResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "name",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "example": "john@example.com"
-                },
-                "name": {
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 2,
-                    "example": "John Doe"
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommentResponse"
+                    }
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 6,
-                    "example": "securepassword123"
+                "dogs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Dog"
+                    }
                 },
-                "role": {
-                    "enum": [
-                        "owner",
-                        "consultant"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.UserRole"
-                        }
-                    ],
-                    "example": "owner"
-                }
-            }
-        },
-        "dto.EventListResponse": {
-            "type": "object",
-            "properties": {
                 "events": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.Event"
                     }
                 },
-                "page": {
-                    "type": "integer"
-                },
-                "page_size": {
-                    "type": "integer"
-                },
-                "total_count": {
-                    "type": "integer"
-                },
-                "total_pages": {
-                    "type": "integer"
-                }
-            }
-        },
-        "dto.InviteResponse": {
-            "type": "object",
-            "properties": {
-                "consultant_id": {
-                    "type": "integer"
+                "full": {
+                    "description": "True on every page of a full snapshot",
+                    "type": "boolean"
                 },
-                "dog_id": {
-                    "type": "integer"
+                "has_more": {
+                    "description": "More pages follow; pull again with next_since right away",
+                    "type": "boolean"
                 },
-                "expires_at": {
+                "next_since": {
+                    "description": "Pass as since on the next pull",
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NoteResponse"
+                    }
                 },
-                "status": {
+                "server_time": {
                     "type": "string"
                 },
                 "token": {
-                    "description": "Only returned to owner",
+                    "description": "Same as next_since, for clients predating pages",
                     "type": "string"
+                },
+                "tombstones": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tombstone"
+                    }
                 }
             }
         },
-        "dto.NoteListResponse": {
+        "dto.SyncPushRequest": {
             "type": "object",
             "properties": {
-                "notes": {
+                "comments": {
                     "type": "array",
+                    "maxItems": 500,
                     "items": {
-                        "$ref": "#/definitions/dto.NoteResponse"
+                        "$ref": "#/definitions/dto.SyncCommentPush"
                     }
                 },
-                "page": {
-                    "type": "integer"
+                "events": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncEventPush"
+                    }
                 },
-                "page_size": {
-                    "type": "integer"
+                "notes": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncNotePush"
+                    }
+                }
+            }
+        },
+        "dto.SyncPushResponse": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncPushResult"
+                    }
                 },
-                "total_count": {
-                    "type": "integer"
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncPushResult"
+                    }
                 },
-                "total_pages": {
-                    "type": "integer"
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncPushResult"
+                    }
                 }
             }
         },
-        "dto.NoteResponse": {
+        "dto.SyncPushResult": {
             "type": "object",
             "properties": {
-                "consultant_id": {
-                    "type": "integer"
+                "client_id": {
+                    "type": "string",
+                    "example": "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
                 },
-                "content": {
-                    "type": "string"
+                "id": {
+                    "description": "Server ID for created and exists",
+                    "type": "integer",
+                    "example": 15
                 },
-                "created_at": {
+                "reason": {
                     "type": "string"
                 },
-                "dog_id": {
-                    "type": "integer"
+                "status": {
+                    "type": "string",
+                    "example": "created"
+                }
+            }
+        },
+        "dto.TimelineItem": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "Event time for events, creation time otherwise",
+                    "type": "string",
+                    "example": "2025-11-23T08:00:00Z"
                 },
-                "dog_name": {
-                    "type": "string"
+                "comment": {
+                    "$ref": "#/definitions/dto.CommentResponse"
                 },
-                "id": {
-                    "type": "integer"
+                "event": {
+                    "$ref": "#/definitions/models.Event"
                 },
-                "owner_id": {
-                    "type": "integer"
+                "id": {
+                    "type": "integer",
+                    "example": 15
                 },
-                "owner_name": {
-                    "type": "string"
+                "note": {
+                    "$ref": "#/definitions/dto.NoteResponse"
                 },
-                "title": {
-                    "type": "string"
+                "type": {
+                    "type": "string",
+                    "example": "event"
+                }
+            }
+        },
+        "dto.TimelineResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
                 },
-                "updated_at": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TimelineItem"
+                    }
+                },
+                "next_cursor": {
                     "type": "string"
                 }
             }
@@ -2322,6 +5107,146 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.UpdateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "event_types"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "dto.WebhookDeliveryListResponse": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "endpoint_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "description": "Pending deliveries are picked up once this time has passed",
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "redelivery_of": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.WebhookDeliveryStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "CRM sync"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "event.created",
+                        "comment.created"
+                    ]
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "secret": {
+                    "description": "Only returned on creation",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/pawtrack"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
         "handler.LoginRequest": {
             "type": "object",
             "required": [
@@ -2357,12 +5282,107 @@ const docTemplate = `{
                 },
                 "ok": {
                     "type": "boolean"
+                },
+                "schema": {
+                    "description": "Omitted if the database is down",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/migrator.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "migrator.Status": {
+            "type": "object",
+            "properties": {
+                "dirty": {
+                    "description": "The last migration failed halfway; fix the schema, then force a version",
+                    "type": "boolean"
+                },
+                "latest": {
+                    "description": "Newest embedded migration",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Last applied migration, 0 if none",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-11-22T10:00:00Z"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "vaccination.pdf"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "scan_status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ScanStatus"
+                        }
+                    ],
+                    "example": "clean"
+                },
+                "scan_threat": {
+                    "description": "Signature found in an infected file",
+                    "type": "string",
+                    "example": "Eicar-Test-Signature"
+                },
+                "scanned_at": {
+                    "type": "string",
+                    "example": "2025-11-22T10:00:05Z"
+                },
+                "sha256": {
+                    "description": "Hex SHA-256 of the content",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "size": {
+                    "description": "Bytes",
+                    "type": "integer",
+                    "example": 48213
+                },
+                "thumbnails": {
+                    "description": "Downscaled copies of JPEG, PNG and WebP images",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Thumbnail"
+                    }
+                },
+                "url": {
+                    "description": "Download path, resolved on load",
+                    "type": "string",
+                    "example": "/api/v1/attachments/7/content"
                 }
             }
         },
         "models.ConsultantNote": {
             "type": "object",
             "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "client_id": {
+                    "description": "UUID generated by offline clients",
+                    "type": "string"
+                },
                 "consultant": {
                     "$ref": "#/definitions/models.User"
                 },
@@ -2460,13 +5480,30 @@ const docTemplate = `{
         "models.Event": {
             "type": "object",
             "properties": {
+                "amount_grams": {
+                    "description": "e.g. food portion",
+                    "type": "integer",
+                    "example": 150
+                },
                 "at": {
                     "type": "string",
                     "example": "2025-11-22T10:00:00Z"
                 },
                 "attachment_url": {
+                    "description": "Deprecated: download path of the first attachment, see Attachments",
+                    "type": "string",
+                    "example": "/api/v1/attachments/7/content"
+                },
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "client_id": {
+                    "description": "UUID generated by offline clients",
                     "type": "string",
-                    "example": "https://example.com/file.jpg"
+                    "example": "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
                 },
                 "created_at": {
                     "type": "string",
@@ -2479,6 +5516,16 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1
                 },
+                "duration_minutes": {
+                    "description": "e.g. walk or training length",
+                    "type": "integer",
+                    "example": 30
+                },
+                "highlight": {
+                    "description": "Set in search results: the note with matches marked, HTML-escaped",
+                    "type": "string",
+                    "example": "morning \u003cmark\u003ewalk\u003c/mark\u003e"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
@@ -2501,6 +5548,17 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "attachment_url": {
+                    "description": "Deprecated: download path of the first attachment, see Attachments",
+                    "type": "string"
+                },
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
+                "client_id": {
+                    "description": "UUID generated by offline clients",
                     "type": "string"
                 },
                 "content": {
@@ -2530,6 +5588,93 @@ const docTemplate = `{
                 }
             }
         },
+        "models.JobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "succeeded",
+                "dead"
+            ],
+            "x-enum-comments": {
+                "JobDead": "Out of attempts or failed permanently, retried only manually"
+            },
+            "x-enum-varnames": [
+                "JobPending",
+                "JobRunning",
+                "JobSucceeded",
+                "JobDead"
+            ]
+        },
+        "models.ScanStatus": {
+            "type": "string",
+            "enum": [
+                "quarantine",
+                "clean",
+                "infected"
+            ],
+            "x-enum-comments": {
+                "ScanInfected": "Malware found, downloads stay blocked",
+                "ScanQuarantine": "Not scanned yet, downloads are blocked"
+            },
+            "x-enum-varnames": [
+                "ScanQuarantine",
+                "ScanClean",
+                "ScanInfected"
+            ]
+        },
+        "models.Thumbnail": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "size": {
+                    "description": "\"small\", \"medium\" or \"large\", see imaging.Sizes",
+                    "type": "string",
+                    "example": "small"
+                },
+                "url": {
+                    "description": "Download path, resolved on load",
+                    "type": "string",
+                    "example": "/api/v1/attachments/7/thumbnails/small"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 160
+                }
+            }
+        },
+        "models.Tombstone": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string",
+                    "example": "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2025-11-22T10:00:00Z"
+                },
+                "dog_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "entity_id": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "entity_type": {
+                    "type": "string",
+                    "example": "event"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -2575,6 +5720,38 @@ const docTemplate = `{
                 "RoleConsultant",
                 "RoleAdmin"
             ]
+        },
+        "models.WebhookDeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "WebhookDeliveryPending",
+                "WebhookDeliverySucceeded",
+                "WebhookDeliveryFailed"
+            ]
+        },
+        "realtime.Message": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "data": {},
+                "dog_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "Increases across all dogs, used as SSE id / Last-Event-ID",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
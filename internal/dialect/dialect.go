@@ -0,0 +1,118 @@
+// Package dialect builds the SQL fragments whose syntax differs between the
+// supported databases, Postgres and SQLite, so repositories behave the same on both.
+package dialect
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Supported dialect names, as reported by the GORM dialector
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Unit is a date bucket size
+type Unit string
+
+// Date bucket sizes; weeks start on Monday
+const (
+	Day   Unit = "day"
+	Week  Unit = "week"
+	Month Unit = "month"
+)
+
+// Dialect builds database-specific SQL fragments
+type Dialect interface {
+	// Name returns Postgres or SQLite
+	Name() string
+	// ILike returns a case-insensitive "column LIKE ?" condition with one placeholder
+	ILike(column string) string
+	// DateBucket returns an expression truncating a timestamp column to the first
+	// day of its bucket in UTC, formatted as YYYY-MM-DD
+	DateBucket(column string, unit Unit) string
+	// DayNumber converts a YYYY-MM-DD text expression into a sequential day number
+	DayNumber(expr string) string
+}
+
+// Of returns the dialect of the database; anything but SQLite gets Postgres syntax
+func Of(db *gorm.DB) Dialect {
+	if db.Dialector.Name() == SQLite {
+		return sqlite{}
+	}
+	return postgres{}
+}
+
+// InsertIgnore returns a clause that skips inserting a row conflicting on the given
+// columns. GORM renders ON CONFLICT the same way for both dialects.
+func InsertIgnore(columns ...string) clause.OnConflict {
+	return clause.OnConflict{Columns: clauseColumns(columns), DoNothing: true}
+}
+
+// Upsert returns a clause that updates the given columns of a row conflicting on
+// the conflict columns instead of inserting it; no columns updates all of them
+func Upsert(conflict []string, update ...string) clause.OnConflict {
+	onConflict := clause.OnConflict{Columns: clauseColumns(conflict)}
+	if len(update) == 0 {
+		onConflict.UpdateAll = true
+	} else {
+		onConflict.DoUpdates = clause.AssignmentColumns(update)
+	}
+	return onConflict
+}
+
+func clauseColumns(names []string) []clause.Column {
+	columns := make([]clause.Column, len(names))
+	for i, name := range names {
+		columns[i] = clause.Column{Name: name}
+	}
+	return columns
+}
+
+// postgres dialect
+type postgres struct{}
+
+func (postgres) Name() string { return Postgres }
+
+func (postgres) ILike(column string) string {
+	return column + " ILIKE ?"
+}
+
+// DateBucket expects a TIMESTAMP WITH TIME ZONE column, as events.at is.
+// AT TIME ZONE 'UTC' turns it into the UTC wall time, so date_trunc and to_char
+// do not depend on the session TimeZone.
+func (postgres) DateBucket(column string, unit Unit) string {
+	return "to_char(date_trunc('" + string(unit) + "', " + column + " AT TIME ZONE 'UTC'), 'YYYY-MM-DD')"
+}
+
+func (postgres) DayNumber(expr string) string {
+	return "(CAST(" + expr + " AS date) - DATE '1970-01-01')"
+}
+
+// sqlite dialect. Timestamps are stored as text in UTC (see gormConfig in main).
+type sqlite struct{}
+
+func (sqlite) Name() string { return SQLite }
+
+// ILike relies on LIKE being case-insensitive in SQLite. Without the ICU extension
+// this only covers ASCII letters, and so does LOWER().
+func (sqlite) ILike(column string) string {
+	return column + " LIKE ?"
+}
+
+func (sqlite) DateBucket(column string, unit Unit) string {
+	switch unit {
+	case Week:
+		// The Sunday on or after the date, minus six days
+		return "date(" + column + ", 'weekday 0', '-6 days')"
+	case Month:
+		return "strftime('%Y-%m-01', " + column + ")"
+	default:
+		return "strftime('%Y-%m-%d', " + column + ")"
+	}
+}
+
+func (sqlite) DayNumber(expr string) string {
+	return "CAST(julianday(" + expr + ") AS INTEGER)"
+}
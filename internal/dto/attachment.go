@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+// AttachUploadsRequest attaches completed resumable uploads instead of sending files
+type AttachUploadsRequest struct {
+	UploadIDs []string `json:"upload_ids" binding:"required,min=1,max=10,dive,uuid" example:"0b8f1e2a-3c4d-4e5f-8a9b-0c1d2e3f4a5b"`
+}
+
+// AttachmentLinkResponse is a short-lived signed URL of a stored file, for
+// clients that cannot send the Authorization header, e.g. <img src>
+type AttachmentLinkResponse struct {
+	URL       string    `json:"url" example:"http://localhost:8080/files/events/2025/11/3f2a9c.jpg?expires=1763805900&signature=9b1c..."`
+	ExpiresAt time.Time `json:"expires_at" example:"2025-11-22T10:05:00Z"`
+}
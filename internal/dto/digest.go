@@ -0,0 +1,10 @@
+package dto
+
+// DailyDigest summarizes a dog's activity during one UTC day
+type DailyDigest struct {
+	DogID       uint             `json:"dog_id" example:"1"`
+	Date        string           `json:"date" example:"2025-11-23"`
+	Events      map[string]int64 `json:"events"` // Event counts by type
+	TotalEvents int64            `json:"total_events" example:"5"`
+	Comments    int64            `json:"comments" example:"2"`
+}
@@ -1,12 +1,19 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+)
 
 // CreateEventRequest for creating a new event
 type CreateEventRequest struct {
-	DogID  *uint      `json:"dog_id" example:"1"`
-	Type   string     `json:"type" binding:"required,max=50" example:"walk"`
-	Note   string     `json:"note" binding:"max=255" example:"morning walk"`
-	At     *time.Time `json:"at" example:"2025-11-22T10:00:00Z"`
-	AttachmentURL *string `json:"-"` // Set by handler after upload, not from JSON
+	DogID           *uint               `json:"dog_id" example:"1"`
+	Type            string              `json:"type" binding:"required,max=50" example:"walk"`
+	Note            string              `json:"note" binding:"max=255" example:"morning walk"`
+	At              *time.Time          `json:"at" example:"2025-11-22T10:00:00Z"`
+	DurationMinutes *int                `json:"duration_minutes" binding:"omitempty,min=0" example:"30"`
+	AmountGrams     *int                `json:"amount_grams" binding:"omitempty,min=0" example:"150"`
+	ClientID        *string             `json:"client_id" binding:"omitempty,uuid" example:"6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"` // Optional client-generated UUID
+	Attachments     []models.Attachment `json:"-"`                                                                                 // Set by handler after upload, not from JSON
 } // if not specified, use now()
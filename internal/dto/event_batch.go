@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/you/pawtrack/internal/models"
+
+// Batch creation modes
+const (
+	BatchModeAtomic  = "atomic"  // all items are created or none
+	BatchModePartial = "partial" // valid items are created, failures are reported per item
+)
+
+// MaxBatchEvents is the maximum number of events accepted in one batch
+const MaxBatchEvents = 100
+
+// BatchCreateEventsRequest for creating many events in one call
+type BatchCreateEventsRequest struct {
+	Mode   string               `json:"mode" binding:"omitempty,oneof=atomic partial" example:"atomic"` // default: atomic
+	Events []CreateEventRequest `json:"events" binding:"required,min=1"`                                // at most MaxBatchEvents, items are validated one by one
+}
+
+// BatchEventItem is a validated batch item together with its position in the request
+type BatchEventItem struct {
+	Index int
+	Event CreateEventRequest
+}
+
+// BatchEventResult is the outcome of a single batch item
+type BatchEventResult struct {
+	Index  int           `json:"index" example:"0"`
+	Status int           `json:"status" example:"201"` // 201, 400 for validation errors, 404 if the dog is missing or not accessible
+	Event  *models.Event `json:"event,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// BatchCreateEventsResponse for returning batch creation results
+type BatchCreateEventsResponse struct {
+	Mode    string             `json:"mode" example:"atomic"`
+	Created int                `json:"created" example:"2"`
+	Failed  int                `json:"failed" example:"0"`
+	Results []BatchEventResult `json:"results"`
+}
@@ -1,12 +1,17 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+)
 
 // CreateCommentRequest for creating a new event comment
 type CreateCommentRequest struct {
-	EventID uint   `json:"event_id" binding:"required"`
-	Content string `json:"content" binding:"required"`
-	AttachmentURL *string `json:"-"` // Set by handler after upload
+	EventID     uint                `json:"event_id" binding:"required"`
+	Content     string              `json:"content" binding:"required"`
+	ClientID    *string             `json:"client_id" binding:"omitempty,uuid"` // Optional client-generated UUID
+	Attachments []models.Attachment `json:"-"`                                  // Set by handler after upload
 }
 
 // UpdateCommentRequest for updating an event comment
@@ -16,14 +21,17 @@ type UpdateCommentRequest struct {
 
 // CommentResponse for returning comment details
 type CommentResponse struct {
-	ID        uint      `json:"id"`
-	EventID   uint      `json:"event_id"`
-	UserID    uint      `json:"user_id"`
-	UserName  string    `json:"user_name"`
-	UserRole  string    `json:"user_role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint                `json:"id"`
+	EventID       uint                `json:"event_id"`
+	UserID        uint                `json:"user_id"`
+	UserName      string              `json:"user_name"`
+	UserRole      string              `json:"user_role"`
+	Content       string              `json:"content"`
+	AttachmentURL *string             `json:"attachment_url,omitempty"` // Deprecated: download path of the first attachment, see Attachments
+	Attachments   []models.Attachment `json:"attachments,omitempty"`
+	ClientID      *string             `json:"client_id,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
 }
 
 // CommentListResponse for returning list of comments
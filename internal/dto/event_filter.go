@@ -8,14 +8,21 @@ import (
 
 // EventFilterParams contains all possible filters for listing events
 type EventFilterParams struct {
-	// Date range
+	// Date range (date-only precision)
 	FromDate *time.Time `form:"from_date" time_format:"2006-01-02"`
 	ToDate   *time.Time `form:"to_date" time_format:"2006-01-02"`
 
+	// Datetime range (RFC3339 precision)
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// Dog filter
+	DogID *uint `form:"dog_id"`
+
 	// Type filter (comma-separated)
 	Types string `form:"types"`
 
-	// Text search (searches in notes and dog name)
+	// Full-text search in notes; results are ranked unless sort_by is given
 	Search string `form:"search"`
 
 	// Dog name exact match
@@ -26,7 +33,7 @@ type EventFilterParams struct {
 	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
 
 	// Sorting
-	SortBy    string `form:"sort_by" binding:"omitempty,oneof=created_at type"`
+	SortBy    string `form:"sort_by" binding:"omitempty,oneof=at created_at type"`
 	SortOrder string `form:"sort_order" binding:"omitempty,oneof=asc desc"`
 
 	// Context (filled by handler)
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/you/pawtrack/internal/models"
+)
+
+// JobListParams contains query parameters for listing background jobs
+type JobListParams struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending running succeeded dead"`
+	Type     string `form:"type" example:"webhook.deliver"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// JobResponse represents a background job, including its payload
+type JobResponse struct {
+	models.Job
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+// JobListResponse represents a page of background jobs, newest first
+type JobListResponse struct {
+	Jobs       []JobResponse `json:"jobs"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"page_size"`
+	TotalCount int64         `json:"total_count"`
+	TotalPages int           `json:"total_pages"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// Stats grouping periods
+const (
+	StatsGroupDay   = "day"
+	StatsGroupWeek  = "week"
+	StatsGroupMonth = "month"
+)
+
+// DogStatsParams contains query parameters for per-dog statistics
+type DogStatsParams struct {
+	// Bucket size for the activity histogram
+	GroupBy string `form:"group_by,default=day" binding:"omitempty,oneof=day week month"`
+
+	// Range over event occurrence time (RFC3339); defaults depend on group_by
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// Type filter (comma-separated)
+	Types string `form:"types"`
+}
+
+// StatsBucket holds event counts for one period
+type StatsBucket struct {
+	Period string           `json:"period" example:"2025-11-01"` // First day of the period (YYYY-MM-DD, UTC)
+	Counts map[string]int64 `json:"counts"`                      // Event count by type
+	Total  int64            `json:"total"`
+}
+
+// StatsTypeTotals holds totals for one event type over the whole range
+type StatsTypeTotals struct {
+	Type            string `json:"type" example:"walk"`
+	Count           int64  `json:"count" example:"42"`
+	DurationMinutes int64  `json:"duration_minutes" example:"1260"`
+	AmountGrams     int64  `json:"amount_grams" example:"0"`
+}
+
+// StatsStreak is the longest run of consecutive days with at least one event of a type
+type StatsStreak struct {
+	Type  string `json:"type" example:"walk"`
+	Days  int64  `json:"days" example:"12"`
+	Start string `json:"start" example:"2025-11-03"`
+	End   string `json:"end" example:"2025-11-14"`
+}
+
+// DogStatsResponse represents aggregated activity for a dog
+type DogStatsResponse struct {
+	DogID   uint              `json:"dog_id"`
+	GroupBy string            `json:"group_by"`
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Buckets []StatsBucket     `json:"buckets"`
+	Totals  []StatsTypeTotals `json:"totals"`
+	Streaks []StatsStreak     `json:"streaks"`
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+)
+
+// Outcomes of pushed records
+const (
+	SyncStatusCreated  = "created"  // record was created
+	SyncStatusExists   = "exists"   // record with this client_id was already pushed by the caller
+	SyncStatusConflict = "conflict" // record cannot be applied because the server state changed
+	SyncStatusRejected = "rejected" // record is invalid or the caller has no access
+)
+
+// MaxSyncPushItems is the maximum number of records of each kind in one push
+const MaxSyncPushItems = 500
+
+// Page sizes of a pull, in records of all kinds together
+const (
+	DefaultSyncPullLimit = 500
+	MaxSyncPullLimit     = 1000
+)
+
+// SyncPullParams contains query parameters for pulling changes
+type SyncPullParams struct {
+	Since string `form:"since"` // Token from the previous pull; empty for a full snapshot
+	Limit int    `form:"limit"` // Page size, up to MaxSyncPullLimit; DefaultSyncPullLimit if empty
+}
+
+// SyncPullResponse contains a page of everything visible to the caller that changed
+// since the checkpoint. Records may be repeated across pulls and should be upserted by id.
+type SyncPullResponse struct {
+	HasMore    bool               `json:"has_more"`   // More pages follow; pull again with next_since right away
+	NextSince  string             `json:"next_since"` // Pass as since on the next pull
+	Token      string             `json:"token"`      // Same as next_since, for clients predating pages
+	Full       bool               `json:"full"`       // True on every page of a full snapshot
+	Dogs       []models.Dog       `json:"dogs"`
+	Events     []models.Event     `json:"events"`
+	Comments   []CommentResponse  `json:"comments"`
+	Notes      []NoteResponse     `json:"notes"`
+	Tombstones []models.Tombstone `json:"tombstones"`
+	ServerTime time.Time          `json:"server_time"`
+}
+
+// SyncEventPush is an event created offline
+type SyncEventPush struct {
+	CreateEventRequest
+	ClientID string `json:"client_id" binding:"required,uuid" example:"6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"`
+}
+
+// SyncCommentPush is an event comment created offline. The event is referenced
+// either by server ID or by the client_id of an event pushed earlier.
+type SyncCommentPush struct {
+	ClientID      string  `json:"client_id" binding:"required,uuid"`
+	EventID       *uint   `json:"event_id" binding:"required_without=EventClientID"`
+	EventClientID *string `json:"event_client_id" binding:"omitempty,uuid"`
+	Content       string  `json:"content" binding:"required"`
+}
+
+// SyncNotePush is a consultant note created offline
+type SyncNotePush struct {
+	CreateNoteRequest
+	ClientID string `json:"client_id" binding:"required,uuid"`
+}
+
+// SyncPushRequest contains records created offline. Events are applied first,
+// so comments can reference events from the same push by event_client_id.
+type SyncPushRequest struct {
+	Events   []SyncEventPush   `json:"events" binding:"max=500"`
+	Comments []SyncCommentPush `json:"comments" binding:"max=500"`
+	Notes    []SyncNotePush    `json:"notes" binding:"max=500"`
+}
+
+// SyncPushResult is the outcome of a single pushed record
+type SyncPushResult struct {
+	ClientID string `json:"client_id" example:"6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"`
+	Status   string `json:"status" example:"created"`
+	ID       uint   `json:"id,omitempty" example:"15"` // Server ID for created and exists
+	Reason   string `json:"reason,omitempty"`
+}
+
+// SyncPushResponse contains per-record results of a push
+type SyncPushResponse struct {
+	Events   []SyncPushResult `json:"events"`
+	Comments []SyncPushResult `json:"comments"`
+	Notes    []SyncPushResult `json:"notes"`
+}
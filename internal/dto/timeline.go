@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+)
+
+// Timeline item types
+const (
+	TimelineItemEvent   = "event"
+	TimelineItemComment = "comment"
+	TimelineItemNote    = "note"
+)
+
+// TimelineParams contains query parameters for the dog timeline
+type TimelineParams struct {
+	Cursor string `form:"cursor"` // Opaque cursor from the previous page's next_cursor
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// TimelineItem is a single entry of the dog timeline, discriminated by Type.
+// Exactly one of Event, Comment or Note is set.
+type TimelineItem struct {
+	Type    string           `json:"type" example:"event"`
+	ID      uint             `json:"id" example:"15"`
+	At      time.Time        `json:"at" example:"2025-11-23T08:00:00Z"` // Event time for events, creation time otherwise
+	Event   *models.Event    `json:"event,omitempty"`
+	Comment *CommentResponse `json:"comment,omitempty"`
+	Note    *NoteResponse    `json:"note,omitempty"`
+}
+
+// TimelineResponse represents a page of the dog timeline, newest first
+type TimelineResponse struct {
+	Items      []TimelineItem `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+)
+
+// CreateWebhookRequest represents the request body for registering a webhook endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2000" example:"https://example.com/hooks/pawtrack"`
+	Description string   `json:"description" binding:"max=255" example:"CRM sync"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1,dive,required" example:"event.created,comment.created"`
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook endpoint.
+// Omitted fields are left unchanged.
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,url,max=2000"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1,dive,required"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookResponse represents a webhook endpoint
+type WebhookResponse struct {
+	ID          uint      `json:"id" example:"1"`
+	UserID      uint      `json:"user_id" example:"3"`
+	URL         string    `json:"url" example:"https://example.com/hooks/pawtrack"`
+	Description string    `json:"description" example:"CRM sync"`
+	EventTypes  []string  `json:"event_types" example:"event.created,comment.created"`
+	Active      bool      `json:"active" example:"true"`
+	Secret      string    `json:"secret,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"` // Only returned on creation
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryParams contains query parameters for the delivery log
+type WebhookDeliveryParams struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// WebhookDeliveryResponse represents one delivery of a webhook, including the sent body
+type WebhookDeliveryResponse struct {
+	models.WebhookDelivery
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+// WebhookDeliveryListResponse represents a page of the delivery log, newest first
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"page_size"`
+	TotalCount int64                     `json:"total_count"`
+	TotalPages int                       `json:"total_pages"`
+}
+
+// WebhookPayload is the JSON body posted to webhook endpoints
+type WebhookPayload struct {
+	ID        string      `json:"id" example:"5c0e7f5e0d3b4a0c9a4f8f6b7d1e2a3c"` // Same for every endpoint and redelivery, use it to deduplicate
+	Type      string      `json:"type" example:"event.created"`
+	DogID     uint        `json:"dog_id" example:"1"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"log"
+	"sync"
+
+	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Publisher is what services use to publish domain events
+type Publisher interface {
+	// Publish publishes an event that is not part of a database change
+	Publish(event Event)
+
+	// PublishTx returns a hook publishing the events of a repository change.
+	// build runs in the change's transaction, after the write, and returns the
+	// events, if any. The outbox stores them in that transaction, so that they
+	// are relayed if and only if the change commits; the bus dispatches them
+	// once it has committed.
+	PublishTx(build func(tx *gorm.DB) ([]Event, error)) repository.TxHook
+}
+
+// Handler receives published events
+type Handler func(event Event)
+
+// Bus dispatches events synchronously to subscribers in the order they subscribed
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []subscription
+}
+
+// subscription is a handler with the event types it is interested in
+type subscription struct {
+	handler Handler
+	types   map[string]bool // nil means all types
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for the given event types, or for all events if none are given
+func (b *Bus) Subscribe(handler Handler, types ...string) {
+	sub := subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, sub)
+}
+
+// Publish dispatches the event right away
+func (b *Bus) Publish(event Event) {
+	b.Dispatch(event)
+}
+
+// PublishTx dispatches the built events after the commit, so that subscribers
+// neither see uncommitted changes nor wait on the transaction's locks
+func (b *Bus) PublishTx(build func(tx *gorm.DB) ([]Event, error)) repository.TxHook {
+	return func(tx *gorm.DB) (func(), error) {
+		events, err := build(tx)
+		if err != nil || len(events) == 0 {
+			return nil, err
+		}
+		return func() {
+			for _, event := range events {
+				b.Dispatch(event)
+			}
+		}, nil
+	}
+}
+
+// Dispatch calls the subscribers of the event. A panicking subscriber is logged
+// and does not prevent the others from running.
+func (b *Bus) Dispatch(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, sub := range handlers {
+		if sub.types != nil && !sub.types[event.Type()] {
+			continue
+		}
+		dispatchSafely(sub.handler, event)
+	}
+}
+
+// dispatchSafely runs a handler, recovering from panics
+func dispatchSafely(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("events: subscriber panicked on %s: %v", event.Type(), r)
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,194 @@
+// Package events is an in-process bus for domain events published by services.
+// Side effects such as the real-time feed and webhooks subscribe to the bus
+// instead of being called from service code.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/models"
+)
+
+// Event types, also used as message types of the real-time feed and webhooks
+const (
+	TypeEventCreated   = "event.created"
+	TypeEventDeleted   = "event.deleted"
+	TypeCommentPosted  = "comment.created"
+	TypeCommentUpdated = "comment.updated"
+	TypeCommentDeleted = "comment.deleted"
+	TypeNoteCreated    = "note.created"
+	TypeNoteUpdated    = "note.updated"
+	TypeNoteDeleted    = "note.deleted"
+	TypeInviteAccepted = "invite.accepted"
+	TypeDailyDigest    = "digest.daily"
+)
+
+// Event is a domain event. Visibility follows the dog: whoever can see the dog
+// can see the event, unless Recipient restricts it further.
+type Event interface {
+	Type() string
+	DogID() uint
+	// Recipient limits the event to one user (and admins); nil means no restriction
+	Recipient() *uint
+	// Payload is the representation sent to clients
+	Payload() interface{}
+}
+
+// Deleted is the payload of *.deleted events
+type Deleted struct {
+	ID uint `json:"id"`
+}
+
+// EventCreated is published after an event has been recorded for a dog
+type EventCreated struct {
+	Event *models.Event `json:"event"`
+}
+
+func (e *EventCreated) Type() string         { return TypeEventCreated }
+func (e *EventCreated) DogID() uint          { return *e.Event.DogID }
+func (e *EventCreated) Recipient() *uint     { return nil }
+func (e *EventCreated) Payload() interface{} { return e.Event }
+
+// EventDeleted is published after an event has been deleted
+type EventDeleted struct {
+	ID  uint `json:"id"`
+	Dog uint `json:"dog_id"`
+}
+
+func (e *EventDeleted) Type() string         { return TypeEventDeleted }
+func (e *EventDeleted) DogID() uint          { return e.Dog }
+func (e *EventDeleted) Recipient() *uint     { return nil }
+func (e *EventDeleted) Payload() interface{} { return Deleted{ID: e.ID} }
+
+// CommentPosted is published after a comment has been added to an event
+type CommentPosted struct {
+	Comment *dto.CommentResponse `json:"comment"`
+	Dog     uint                 `json:"dog_id"`
+}
+
+func (e *CommentPosted) Type() string         { return TypeCommentPosted }
+func (e *CommentPosted) DogID() uint          { return e.Dog }
+func (e *CommentPosted) Recipient() *uint     { return nil }
+func (e *CommentPosted) Payload() interface{} { return e.Comment }
+
+// CommentUpdated is published after a comment has been edited
+type CommentUpdated struct {
+	Comment *dto.CommentResponse `json:"comment"`
+	Dog     uint                 `json:"dog_id"`
+}
+
+func (e *CommentUpdated) Type() string         { return TypeCommentUpdated }
+func (e *CommentUpdated) DogID() uint          { return e.Dog }
+func (e *CommentUpdated) Recipient() *uint     { return nil }
+func (e *CommentUpdated) Payload() interface{} { return e.Comment }
+
+// CommentDeleted is published after a comment has been deleted
+type CommentDeleted struct {
+	ID  uint `json:"id"`
+	Dog uint `json:"dog_id"`
+}
+
+func (e *CommentDeleted) Type() string         { return TypeCommentDeleted }
+func (e *CommentDeleted) DogID() uint          { return e.Dog }
+func (e *CommentDeleted) Recipient() *uint     { return nil }
+func (e *CommentDeleted) Payload() interface{} { return Deleted{ID: e.ID} }
+
+// NoteCreated is published after a consultant has written a note; notes are
+// private to their author
+type NoteCreated struct {
+	Note *dto.NoteResponse `json:"note"`
+}
+
+func (e *NoteCreated) Type() string         { return TypeNoteCreated }
+func (e *NoteCreated) DogID() uint          { return e.Note.DogID }
+func (e *NoteCreated) Recipient() *uint     { return &e.Note.ConsultantID }
+func (e *NoteCreated) Payload() interface{} { return e.Note }
+
+// NoteUpdated is published after a note has been edited
+type NoteUpdated struct {
+	Note *dto.NoteResponse `json:"note"`
+}
+
+func (e *NoteUpdated) Type() string         { return TypeNoteUpdated }
+func (e *NoteUpdated) DogID() uint          { return e.Note.DogID }
+func (e *NoteUpdated) Recipient() *uint     { return &e.Note.ConsultantID }
+func (e *NoteUpdated) Payload() interface{} { return e.Note }
+
+// NoteDeleted is published after a note has been deleted
+type NoteDeleted struct {
+	ID           uint `json:"id"`
+	Dog          uint `json:"dog_id"`
+	ConsultantID uint `json:"consultant_id"`
+}
+
+func (e *NoteDeleted) Type() string         { return TypeNoteDeleted }
+func (e *NoteDeleted) DogID() uint          { return e.Dog }
+func (e *NoteDeleted) Recipient() *uint     { return &e.ConsultantID }
+func (e *NoteDeleted) Payload() interface{} { return Deleted{ID: e.ID} }
+
+// InviteAccepted is published after a consultant has accepted an invite and
+// gained access to the dog
+type InviteAccepted struct {
+	Invite *models.Invite `json:"invite"`
+}
+
+func (e *InviteAccepted) Type() string         { return TypeInviteAccepted }
+func (e *InviteAccepted) DogID() uint          { return e.Invite.DogID }
+func (e *InviteAccepted) Recipient() *uint     { return nil }
+func (e *InviteAccepted) Payload() interface{} { return e.Invite }
+
+// DailyDigest is published by the scheduler with a summary of a dog's activity
+// during the previous day
+type DailyDigest struct {
+	Digest *dto.DailyDigest `json:"digest"`
+}
+
+func (e *DailyDigest) Type() string         { return TypeDailyDigest }
+func (e *DailyDigest) DogID() uint          { return e.Digest.DogID }
+func (e *DailyDigest) Recipient() *uint     { return nil }
+func (e *DailyDigest) Payload() interface{} { return e.Digest }
+
+// registry creates empty events by type, for decoding stored events
+var registry = map[string]func() Event{
+	TypeEventCreated:   func() Event { return &EventCreated{} },
+	TypeEventDeleted:   func() Event { return &EventDeleted{} },
+	TypeCommentPosted:  func() Event { return &CommentPosted{} },
+	TypeCommentUpdated: func() Event { return &CommentUpdated{} },
+	TypeCommentDeleted: func() Event { return &CommentDeleted{} },
+	TypeNoteCreated:    func() Event { return &NoteCreated{} },
+	TypeNoteUpdated:    func() Event { return &NoteUpdated{} },
+	TypeNoteDeleted:    func() Event { return &NoteDeleted{} },
+	TypeInviteAccepted: func() Event { return &InviteAccepted{} },
+	TypeDailyDigest:    func() Event { return &DailyDigest{} },
+}
+
+// Types lists all event types
+func Types() []string {
+	return []string{
+		TypeEventCreated,
+		TypeEventDeleted,
+		TypeCommentPosted,
+		TypeCommentUpdated,
+		TypeCommentDeleted,
+		TypeNoteCreated,
+		TypeNoteUpdated,
+		TypeNoteDeleted,
+		TypeInviteAccepted,
+		TypeDailyDigest,
+	}
+}
+
+// Decode restores an event encoded with json.Marshal
+func Decode(eventType string, data []byte) (Event, error) {
+	factory, ok := registry[eventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	event := factory()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", eventType, err)
+	}
+	return event, nil
+}
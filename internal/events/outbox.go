@@ -0,0 +1,150 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
+)
+
+// outboxBatchSize is the number of stored events relayed per query
+const outboxBatchSize = 100
+
+// outboxClaim is how long a relay holds the events it claimed; events of a relay
+// that stopped are relayed by another one after this time
+const outboxClaim = time.Minute
+
+// outboxInstances numbers the outboxes of the process, to tell their relays apart
+var outboxInstances atomic.Int64
+
+// Outbox is a Publisher that stores events in the outbox table and relays them
+// to the bus in the background. Events of repository changes are stored in the
+// change's transaction. An event is removed only after its subscribers have
+// run, so events stored before a crash are dispatched after the restart (at
+// least once: subscribers may see an event twice). With several instances,
+// each batch of events is claimed by one relay.
+type Outbox struct {
+	repo         repository.OutboxRepository
+	bus          *Bus
+	pollInterval time.Duration
+	wake         chan struct{}
+	relayName    string
+}
+
+// NewOutbox creates an outbox relaying to the bus; pollInterval is a fallback for
+// events stored by other instances, local ones are relayed immediately
+func NewOutbox(repo repository.OutboxRepository, bus *Bus, pollInterval time.Duration) *Outbox {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	host, _ := os.Hostname()
+	return &Outbox{
+		repo:         repo,
+		bus:          bus,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, 1),
+		relayName:    fmt.Sprintf("%s-%d-%d", host, os.Getpid(), outboxInstances.Add(1)),
+	}
+}
+
+// Publish stores the event for the relay. If it cannot be stored, it is
+// dispatched directly rather than dropped.
+func (o *Outbox) Publish(event Event) {
+	if err := o.store(nil, event); err != nil {
+		log.Printf("events: %v, dispatching directly", err)
+		o.bus.Dispatch(event)
+		return
+	}
+	o.notify()
+}
+
+// PublishTx stores the built events in the transaction of the change. Failing
+// to store them fails the change, so that no event is lost.
+func (o *Outbox) PublishTx(build func(tx *gorm.DB) ([]Event, error)) repository.TxHook {
+	return func(tx *gorm.DB) (func(), error) {
+		events, err := build(tx)
+		if err != nil || len(events) == 0 {
+			return nil, err
+		}
+		for _, event := range events {
+			if err := o.store(tx, event); err != nil {
+				return nil, err
+			}
+		}
+		return o.notify, nil
+	}
+}
+
+// store appends the event to the outbox in tx, or on its own if tx is nil
+func (o *Outbox) store(tx *gorm.DB, event Event) error {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = o.repo.Append(tx, &models.OutboxEvent{Type: event.Type(), Payload: string(data)})
+	}
+	if err != nil {
+		return fmt.Errorf("store %s in outbox: %w", event.Type(), err)
+	}
+	return nil
+}
+
+// notify wakes the relay for newly stored events
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run relays stored events until ctx is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			if o.relay() < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+// relay claims one batch of stored events, dispatches it in order and returns its size
+func (o *Outbox) relay() int {
+	now := time.Now().UTC()
+	stored, err := o.repo.Claim(o.relayName, outboxBatchSize, now, now.Add(outboxClaim))
+	if err != nil {
+		log.Printf("events: claim outbox events: %v", err)
+		return 0
+	}
+
+	for _, record := range stored {
+		event, err := Decode(record.Type, []byte(record.Payload))
+		if err != nil {
+			// Retrying cannot help, e.g. a type removed in a newer version
+			log.Printf("events: dropping outbox event %d: %v", record.ID, err)
+		} else {
+			o.bus.Dispatch(event)
+		}
+
+		if err := o.repo.Remove(o.relayName, []uint{record.ID}); err != nil {
+			log.Printf("events: remove outbox event %d: %v", record.ID, err)
+			return 0
+		}
+	}
+	return len(stored)
+}
@@ -0,0 +1,202 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// recorder collects dispatched events
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func newDog(t *testing.T, db *gorm.DB) uint {
+	t.Helper()
+	owner := &models.User{Name: "Owner", Email: "owner@example.com", PasswordHash: "x", Role: models.RoleOwner}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	dog := &models.Dog{OwnerID: owner.ID, Name: "Rex"}
+	if err := db.Create(dog).Error; err != nil {
+		t.Fatal(err)
+	}
+	return dog.ID
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// created builds the EventCreated of an event
+func created(event *models.Event) func(tx *gorm.DB) ([]Event, error) {
+	return func(tx *gorm.DB) ([]Event, error) {
+		return []Event{&EventCreated{Event: event}}, nil
+	}
+}
+
+func TestOutboxStoresEventsWithTheChange(t *testing.T) {
+	db := testdb.Open(t)
+	dogID := newDog(t, db)
+	bus := NewBus()
+	var got recorder
+	bus.Subscribe(got.handle)
+	outbox := NewOutbox(repository.NewOutboxRepository(db), bus, time.Hour)
+	eventRepo := repository.NewEventRepository(db, nil)
+
+	event := &models.Event{DogID: &dogID, Type: "walk", At: time.Now()}
+	if err := eventRepo.Create(event, outbox.PublishTx(created(event))); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, &models.OutboxEvent{}); n != 1 {
+		t.Fatalf("expected the event in the outbox, found %d", n)
+	}
+	if got.count() != 0 {
+		t.Fatal("event dispatched before the relay ran")
+	}
+
+	if n := outbox.relay(); n != 1 {
+		t.Fatalf("relayed %d events", n)
+	}
+	if got.count() != 1 || got.events[0].(*EventCreated).Event.ID != event.ID {
+		t.Fatalf("unexpected dispatch: %+v", got.events)
+	}
+	if n := countRows(t, db, &models.OutboxEvent{}); n != 0 {
+		t.Fatalf("relayed event left in the outbox: %d", n)
+	}
+}
+
+func TestOutboxRollsBackWithTheChange(t *testing.T) {
+	db := testdb.Open(t)
+	dogID := newDog(t, db)
+	outbox := NewOutbox(repository.NewOutboxRepository(db), NewBus(), time.Hour)
+	eventRepo := repository.NewEventRepository(db, nil)
+
+	// A failed write stores no event
+	clientID := "6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"
+	if err := eventRepo.Create(&models.Event{DogID: &dogID, Type: "walk", At: time.Now(), ClientID: &clientID}, nil); err != nil {
+		t.Fatal(err)
+	}
+	duplicate := &models.Event{DogID: &dogID, Type: "walk", At: time.Now(), ClientID: &clientID}
+	if err := eventRepo.Create(duplicate, outbox.PublishTx(created(duplicate))); err == nil {
+		t.Fatal("expected the duplicate client id to fail")
+	}
+	if n := countRows(t, db, &models.OutboxEvent{}); n != 0 {
+		t.Fatalf("event of a failed change stored: %d", n)
+	}
+
+	// A failure to build the events undoes the change
+	failing := &models.Event{DogID: &dogID, Type: "meds", At: time.Now()}
+	buildErr := errors.New("build failed")
+	err := eventRepo.Create(failing, outbox.PublishTx(func(tx *gorm.DB) ([]Event, error) {
+		return nil, buildErr
+	}))
+	if !errors.Is(err, buildErr) {
+		t.Fatalf("expected %v, got %v", buildErr, err)
+	}
+	var meds int64
+	db.Model(&models.Event{}).Where("type = ?", "meds").Count(&meds)
+	if meds != 0 {
+		t.Fatal("change kept although its events were not stored")
+	}
+}
+
+func TestBusPublishTxDispatchesAfterCommit(t *testing.T) {
+	db := testdb.Open(t)
+	dogID := newDog(t, db)
+	bus := NewBus()
+	var got recorder
+	bus.Subscribe(got.handle)
+	eventRepo := repository.NewEventRepository(db, nil)
+
+	event := &models.Event{DogID: &dogID, Type: "walk", At: time.Now()}
+	err := eventRepo.Create(event, bus.PublishTx(func(tx *gorm.DB) ([]Event, error) {
+		if got.count() != 0 {
+			t.Error("dispatched inside the transaction")
+		}
+		return []Event{&EventCreated{Event: event}}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.count() != 1 {
+		t.Fatalf("expected one dispatch after the commit, got %d", got.count())
+	}
+
+	duplicate := &models.Event{DogID: &dogID, Type: "walk", At: time.Now(), ID: event.ID}
+	if err := eventRepo.Create(duplicate, bus.PublishTx(created(duplicate))); err == nil {
+		t.Fatal("expected the duplicate id to fail")
+	}
+	if got.count() != 1 {
+		t.Fatal("event of a failed change dispatched")
+	}
+}
+
+func TestOutboxRelaysDispatchEachEventOnce(t *testing.T) {
+	db := testdb.Open(t)
+	dogID := newDog(t, db)
+	repo := repository.NewOutboxRepository(db)
+
+	const total = 250
+	seen := make(map[uint]int)
+	var mu sync.Mutex
+	bus := NewBus()
+	bus.Subscribe(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[event.(*EventDeleted).ID]++
+	})
+
+	first := NewOutbox(repo, bus, time.Hour)
+	second := NewOutbox(repo, bus, time.Hour)
+	for i := 1; i <= total; i++ {
+		first.Publish(&EventDeleted{ID: uint(i), Dog: dogID})
+	}
+
+	var wg sync.WaitGroup
+	for _, outbox := range []*Outbox{first, second} {
+		wg.Add(1)
+		go func(outbox *Outbox) {
+			defer wg.Done()
+			for outbox.relay() > 0 {
+			}
+		}(outbox)
+	}
+	wg.Wait()
+
+	if len(seen) != total {
+		t.Fatalf("dispatched %d of %d events", len(seen), total)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("event %d dispatched %d times", id, n)
+		}
+	}
+	if n := countRows(t, db, &models.OutboxEvent{}); n != 0 {
+		t.Fatalf("%d events left in the outbox", n)
+	}
+}
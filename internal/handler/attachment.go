@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/storage"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AttachmentHandler HTTP request handler for attachment downloads
+type AttachmentHandler struct {
+	service   service.AttachmentService
+	uploads   service.UploadService
+	images    service.ImageService
+	scans     service.ScanService
+	storage   storage.FileStorage
+	urlExpiry time.Duration
+}
+
+// NewAttachmentHandler creates a new attachment handler. Downloads redirect to
+// signed storage URLs valid for urlExpiry.
+func NewAttachmentHandler(service service.AttachmentService, uploads service.UploadService, images service.ImageService, scans service.ScanService, storage storage.FileStorage, urlExpiry time.Duration) *AttachmentHandler {
+	return &AttachmentHandler{
+		service:   service,
+		uploads:   uploads,
+		images:    images,
+		scans:     scans,
+		storage:   storage,
+		urlExpiry: urlExpiry,
+	}
+}
+
+// GetContent godoc
+// @Summary      Download an attachment
+// @Description  Redirects to a short-lived signed URL of the file if the user can see the event the attachment belongs to, directly or through a comment.
+// @Description  For links and <img> tags, where the Authorization header cannot be set, get the signed URL from /attachments/{id}/link.
+// @Description  Images and videos open inline; documents and other files are served with Content-Disposition: attachment.
+// @Description  Files are downloadable once the malware scan finds them clean: 423 while quarantined, 403 when infected.
+// @Tags         attachments
+// @Security     BearerAuth
+// @Param        id  path  int  true  "Attachment ID"
+// @Success      302
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /attachments/{id}/content [get]
+func (h *AttachmentHandler) GetContent(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	h.redirect(c, attachment, attachment.Key, download(attachment.Filename, attachment.ContentType))
+}
+
+// GetContentLink godoc
+// @Summary      Get a signed URL of an attachment
+// @Description  Returns the short-lived signed URL GET /attachments/{id}/content redirects to, with the same access rules.
+// @Description  The URL needs no token, so it can be used in links and <img> tags.
+// @Tags         attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path  int  true  "Attachment ID"
+// @Success      200  {object}  dto.AttachmentLinkResponse
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /attachments/{id}/link [get]
+func (h *AttachmentHandler) GetContentLink(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	h.link(c, attachment, attachment.Key, download(attachment.Filename, attachment.ContentType))
+}
+
+// GetThumbnail godoc
+// @Summary      Download a thumbnail of an image
+// @Description  Redirects to a short-lived signed URL of a thumbnail of a JPEG, PNG or WebP attachment, with the same access rules as the file.
+// @Description  Sizes by the longer side: small 160px, medium 480px, large 1280px; smaller images are not scaled up.
+// @Tags         attachments
+// @Security     BearerAuth
+// @Param        id    path  int     true  "Attachment ID"
+// @Param        size  path  string  true  "Thumbnail size"  Enums(small, medium, large)
+// @Success      302
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /attachments/{id}/thumbnails/{size} [get]
+func (h *AttachmentHandler) GetThumbnail(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	thumbnail := attachment.Thumbnail(c.Param("size"))
+	if thumbnail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thumbnail not found"})
+		return
+	}
+	h.redirect(c, attachment, thumbnail.Key, download("", thumbnail.ContentType))
+}
+
+// GetThumbnailLink godoc
+// @Summary      Get a signed URL of a thumbnail
+// @Description  Returns the short-lived signed URL GET /attachments/{id}/thumbnails/{size} redirects to, for use in <img> tags.
+// @Tags         attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path  int     true  "Attachment ID"
+// @Param        size  path  string  true  "Thumbnail size"  Enums(small, medium, large)
+// @Success      200  {object}  dto.AttachmentLinkResponse
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      423  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /attachments/{id}/thumbnails/{size}/link [get]
+func (h *AttachmentHandler) GetThumbnailLink(c *gin.Context) {
+	attachment, ok := h.load(c)
+	if !ok {
+		return
+	}
+	thumbnail := attachment.Thumbnail(c.Param("size"))
+	if thumbnail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thumbnail not found"})
+		return
+	}
+	h.link(c, attachment, thumbnail.Key, download("", thumbnail.ContentType))
+}
+
+// load returns the attachment of the request if the user may download it and
+// the malware scan found it clean
+func (h *AttachmentHandler) load(c *gin.Context) (*models.Attachment, bool) {
+	id := uint(utils.Atoi(c.Param("id")))
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+
+	attachment, err := h.service.GetAttachment(id, userID, role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"}) // Return 404 to avoid leaking existence
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attachment"})
+		return nil, false
+	}
+
+	switch attachment.ScanStatus {
+	case models.ScanClean:
+		return attachment, true
+	case models.ScanInfected:
+		c.JSON(http.StatusForbidden, gin.H{"error": "attachment is infected and cannot be downloaded"})
+	default:
+		c.Header("Retry-After", "10")
+		c.JSON(http.StatusLocked, gin.H{"error": "attachment is being scanned for malware"})
+	}
+	return nil, false
+}
+
+// redirect sends the client to a signed URL of a file of the attachment
+func (h *AttachmentHandler) redirect(c *gin.Context, attachment *models.Attachment, key string, download storage.Download) {
+	url, ok := h.sign(c, attachment, key, download)
+	if !ok {
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// link returns a signed URL of a file of the attachment
+func (h *AttachmentHandler) link(c *gin.Context, attachment *models.Attachment, key string, download storage.Download) {
+	expiresAt := time.Now().UTC().Add(h.urlExpiry)
+	url, ok := h.sign(c, attachment, key, download)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dto.AttachmentLinkResponse{URL: url, ExpiresAt: expiresAt})
+}
+
+// sign returns a signed URL of a file of the attachment, valid for urlExpiry,
+// and marks the response as not cacheable
+func (h *AttachmentHandler) sign(c *gin.Context, attachment *models.Attachment, key string, download storage.Download) (string, bool) {
+	// Attachments are served by the configured backend only
+	if attachment.Backend != h.storage.Name() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "attachment is kept in another storage backend"})
+		return "", false
+	}
+
+	url, err := h.storage.GetSignedURL(key, h.urlExpiry, download)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign attachment url"})
+		return "", false
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	return url, true
+}
+
+// download describes how a stored file is served: images and videos inline,
+// anything else saved under its name
+func download(filename, contentType string) storage.Download {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !utils.AllowedMimeTypes[mediaType] {
+		// Files stored before types were detected from content
+		contentType = "application/octet-stream"
+	}
+	return storage.Download{Filename: filename, ContentType: contentType, Inline: utils.InlineMimeTypes[mediaType]}
+}
+
+// AddToEvent godoc
+// @Summary      Attach files to an event
+// @Description  Upload one or more files (repeated "file" parts, up to 10) to an event of a dog the user can access
+// @Description  Alternatively send JSON {"upload_ids": [...]} to attach completed resumable uploads (see /uploads).
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int   true  "Event ID"
+// @Param        file  formData  file  true  "File, may be repeated"
+// @Success      201   {array}   models.Attachment
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      413   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /events/{id}/attachments [post]
+func (h *AttachmentHandler) AddToEvent(c *gin.Context) {
+	h.add(c, h.service.AddToEvent)
+}
+
+// AddToComment godoc
+// @Summary      Attach files to a comment
+// @Description  Upload one or more files (repeated "file" parts, up to 10) to a comment. Only the author or an admin can attach files.
+// @Description  Alternatively send JSON {"upload_ids": [...]} to attach completed resumable uploads (see /uploads).
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int   true  "Comment ID"
+// @Param        file  formData  file  true  "File, may be repeated"
+// @Success      201   {array}   models.Attachment
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      413   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /event-comments/{id}/attachments [post]
+func (h *AttachmentHandler) AddToComment(c *gin.Context) {
+	h.add(c, h.service.AddToComment)
+}
+
+// AddToNote godoc
+// @Summary      Attach files to a consultant note
+// @Description  Upload one or more files (repeated "file" parts, up to 10) to a note of the consultant
+// @Description  Alternatively send JSON {"upload_ids": [...]} to attach completed resumable uploads (see /uploads).
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int   true  "Note ID"
+// @Param        file  formData  file  true  "File, may be repeated"
+// @Success      201   {array}   models.Attachment
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      413   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /consultant-notes/{id}/attachments [post]
+func (h *AttachmentHandler) AddToNote(c *gin.Context) {
+	h.add(c, h.service.AddToNote)
+}
+
+// add uploads the request's files and attaches them with the given service method
+func (h *AttachmentHandler) add(c *gin.Context, attach func(ownerID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error)) {
+	ownerID := uint(utils.Atoi(c.Param("id")))
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Completed resumable uploads are attached by ID, files otherwise
+	fromUploads := c.ContentType() == "application/json"
+	var uploaded []models.Attachment
+	if fromUploads {
+		var req dto.AttachUploadsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		uploaded, err = h.uploads.Attachments(req.UploadIDs, userID)
+		if err != nil {
+			if errors.Is(err, service.ErrUploadNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load uploads"})
+			return
+		}
+	} else {
+		upload, ok := readMultipart(c, h.storage, h.images)
+		if !ok {
+			return
+		}
+		uploaded = upload.attachments
+	}
+	if len(uploaded) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing file"})
+		return
+	}
+
+	attachments, err := attach(ownerID, uploaded, userID, role)
+	if err != nil {
+		// Files of uploads stay with the uploads until attached or expired
+		if !fromUploads {
+			removeStored(h.storage, uploaded)
+		}
+		if errors.Is(err, service.ErrAttachmentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author can attach files"})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"}) // Return 404 to avoid leaking existence
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save attachments"})
+		return
+	}
+
+	h.scans.Submit(c.Request.Context(), attachments)
+	c.JSON(http.StatusCreated, attachments)
+}
+
+// DeleteAttachment godoc
+// @Summary      Remove an attachment
+// @Description  Remove a file from its event, comment or note. Event files are removed by those who may delete the event, comment and note files by their author.
+// @Tags         attachments
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Attachment ID"
+// @Success      204
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	id := uint(utils.Atoi(c.Param("id")))
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	attachment, err := h.service.DeleteAttachment(id, userID, role)
+	if err != nil {
+		if errors.Is(err, service.ErrAttachmentForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete attachment"})
+		return
+	}
+
+	removeStored(h.storage, []models.Attachment{*attachment})
+	c.Status(http.StatusNoContent)
+}
+
+// FileHandler serves files of the local storage to holders of a signed URL
+type FileHandler struct {
+	storage *storage.LocalStorage
+}
+
+// NewFileHandler creates a new file handler
+func NewFileHandler(storage *storage.LocalStorage) *FileHandler {
+	return &FileHandler{storage: storage}
+}
+
+// ServeFile serves GET /files/*key?expires=&signature= URLs issued by
+// LocalStorage.GetSignedURL. Files are served same-origin, so browsers are
+// told not to guess their type and not to run anything in them.
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	query := c.Request.URL.Query()
+	path, err := h.storage.Resolve(c.Param("key"), query)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLExpired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "link expired"})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	if contentType := query.Get("response-content-type"); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	if disposition := query.Get("response-content-disposition"); disposition != "" {
+		c.Header("Content-Disposition", disposition)
+	}
+	c.File(path)
+}
@@ -3,9 +3,12 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/you/pawtrack/internal/dto"
 	"github.com/you/pawtrack/internal/middleware"
 	"github.com/you/pawtrack/internal/service"
@@ -18,26 +21,32 @@ import (
 type EventHandler struct {
 	service service.EventService
 	storage storage.FileStorage
+	images  service.ImageService
+	scans   service.ScanService
 }
 
 // NewEventHandler creates a new event handler
-func NewEventHandler(service service.EventService, storage storage.FileStorage) *EventHandler {
+func NewEventHandler(service service.EventService, storage storage.FileStorage, images service.ImageService, scans service.ScanService) *EventHandler {
 	return &EventHandler{
 		service: service,
 		storage: storage,
+		images:  images,
+		scans:   scans,
 	}
 }
 
 // CreateEvent godoc
 // @Summary      Create a new event
-// @Description  Create a new pet event with optional file attachment
+// @Description  Create a new pet event with optional file attachments (repeated "file" parts, up to 10)
 // @Tags         events
 // @Accept       multipart/form-data
 // @Produce      json
 // @Security     BearerAuth
 // @Param        data  formData  string  true  "Event Data (JSON)"
+// @Param        file  formData  file    false "Attachment, may be repeated"
 // @Success      201   {object}  models.Event
 // @Failure      400   {object}  map[string]string
+// @Failure      413   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
 // @Router       /events [post]
 func (h *EventHandler) CreateEvent(c *gin.Context) {
@@ -52,55 +61,133 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 			return
 		}
 	} else {
-		// Multipart form
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse form"})
+		// Multipart form, streamed: files are stored as they arrive
+		upload, ok := readMultipart(c, h.storage, h.images)
+		if !ok {
 			return
 		}
 
 		// Parse JSON data from form field
-		dataStr := c.PostForm("data")
-		if dataStr == "" {
+		if len(upload.data) == 0 {
+			removeStored(h.storage, upload.attachments)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "missing data field"})
 			return
 		}
 
-		if err := json.Unmarshal([]byte(dataStr), &req); err != nil {
+		if err := json.Unmarshal(upload.data, &req); err != nil {
+			removeStored(h.storage, upload.attachments)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON in data field"})
 			return
 		}
 
-		// Handle file upload if present
-		file, header, err := c.Request.FormFile("file")
-		if err == nil {
-			defer file.Close()
-
-			// Validate file
-			if err := utils.ValidateFile(file, header); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-
-			// Upload file
-			fileURL, err := h.storage.Upload(file, header.Filename, header.Header.Get("Content-Type"))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
-				return
-			}
-
-			req.AttachmentURL = &fileURL
-		}
+		// "file" may be repeated
+		req.Attachments = upload.attachments
 	}
 
 	event, err := h.service.CreateEvent(&req)
 	if err != nil {
+		removeStored(h.storage, req.Attachments)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db create failed"})
 		return
 	}
 
+	h.scans.Submit(c.Request.Context(), event.Attachments)
 	c.JSON(http.StatusCreated, event)
 }
 
+// CreateEventsBatch godoc
+// @Summary      Create events in bulk
+// @Description  Create up to 100 events in one call. Every item is validated like POST /events; items for dogs the user cannot access fail with 404.
+// @Description  mode=atomic (default) creates all items or none; mode=partial creates the valid items and reports failures per item.
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.BatchCreateEventsRequest  true  "Events"
+// @Success      201      {object}  dto.BatchCreateEventsResponse  "All items created"
+// @Success      207      {object}  dto.BatchCreateEventsResponse  "Partial mode, some items failed"
+// @Failure      400      {object}  map[string]string
+// @Failure      422      {object}  dto.BatchCreateEventsResponse  "Atomic mode, nothing created"
+// @Failure      500      {object}  map[string]string
+// @Router       /events/batch [post]
+func (h *EventHandler) CreateEventsBatch(c *gin.Context) {
+	var req dto.BatchCreateEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Events) > dto.MaxBatchEvents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d events per batch", dto.MaxBatchEvents)})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = dto.BatchModeAtomic
+	}
+	atomic := req.Mode == dto.BatchModeAtomic
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userRole, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Validate each item on its own so failures can be reported per index
+	var results []dto.BatchEventResult
+	items := make([]dto.BatchEventItem, 0, len(req.Events))
+	for i := range req.Events {
+		if err := binding.Validator.ValidateStruct(&req.Events[i]); err != nil {
+			results = append(results, dto.BatchEventResult{Index: i, Status: http.StatusBadRequest, Error: err.Error()})
+			continue
+		}
+		items = append(items, dto.BatchEventItem{Index: i, Event: req.Events[i]})
+	}
+
+	if atomic && len(results) > 0 {
+		for _, item := range items {
+			results = append(results, dto.BatchEventResult{Index: item.Index, Status: http.StatusFailedDependency, Error: "not created: another item in the batch failed"})
+		}
+		c.JSON(http.StatusUnprocessableEntity, newBatchResponse(req.Mode, results))
+		return
+	}
+
+	created, err := h.service.CreateEventsBatch(items, atomic, userID, userRole)
+	if err != nil && !errors.Is(err, service.ErrBatchRejected) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db create failed"})
+		return
+	}
+	results = append(results, created...)
+	resp := newBatchResponse(req.Mode, results)
+
+	switch {
+	case errors.Is(err, service.ErrBatchRejected):
+		c.JSON(http.StatusUnprocessableEntity, resp)
+	case resp.Failed > 0:
+		c.JSON(http.StatusMultiStatus, resp)
+	default:
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+// newBatchResponse orders results by item index and counts outcomes
+func newBatchResponse(mode string, results []dto.BatchEventResult) *dto.BatchCreateEventsResponse {
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	resp := &dto.BatchCreateEventsResponse{Mode: mode, Results: results}
+	for _, r := range results {
+		if r.Status == http.StatusCreated {
+			resp.Created++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
+
 // ListEvents godoc
 // @Summary      List events with filtering
 // @Description  Get paginated events with filters and sorting
@@ -109,12 +196,15 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 // @Security     BearerAuth
 // @Param        from_date    query     string  false  "From date (YYYY-MM-DD)"
 // @Param        to_date      query     string  false  "To date (YYYY-MM-DD)"
+// @Param        from         query     string  false  "From datetime (RFC3339)"
+// @Param        to           query     string  false  "To datetime (RFC3339)"
+// @Param        dog_id       query     int     false  "Filter by dog ID"
 // @Param        types        query     string  false  "Event types (comma-separated)"
 // @Param        search       query     string  false  "Search in notes and dog names"
 // @Param        dog_name     query     string  false  "Filter by dog name"
 // @Param        page         query     int     false  "Page number" default(1)
 // @Param        page_size    query     int     false  "Page size" default(20)
-// @Param        sort_by      query     string  false  "Sort by field" Enums(created_at, type)
+// @Param        sort_by      query     string  false  "Sort by field" Enums(at, created_at, type)
 // @Param        sort_order   query     string  false  "Sort order" Enums(asc, desc)
 // @Success      200          {object}  dto.EventListResponse
 // @Failure      400          {object}  map[string]string
@@ -17,12 +17,16 @@ import (
 type EventCommentHandler struct {
 	service service.EventCommentService
 	storage storage.FileStorage
+	images  service.ImageService
+	scans   service.ScanService
 }
 
-func NewEventCommentHandler(service service.EventCommentService, storage storage.FileStorage) *EventCommentHandler {
+func NewEventCommentHandler(service service.EventCommentService, storage storage.FileStorage, images service.ImageService, scans service.ScanService) *EventCommentHandler {
 	return &EventCommentHandler{
 		service: service,
 		storage: storage,
+		images:  images,
+		scans:   scans,
 	}
 }
 
@@ -38,6 +42,7 @@ func NewEventCommentHandler(service service.EventCommentService, storage storage
 // @Failure      400      {object}  map[string]string
 // @Failure      401      {object}  map[string]string
 // @Failure      403      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Security     BearerAuth
 // @Router       /events/{id}/comments [post]
@@ -67,44 +72,27 @@ func (h *EventCommentHandler) CreateComment(c *gin.Context) {
 			return
 		}
 	} else {
-		// Multipart form
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse form"})
+		// Multipart form, streamed: files are stored as they arrive
+		upload, ok := readMultipart(c, h.storage, h.images)
+		if !ok {
 			return
 		}
 
 		// Parse JSON data from form field
-		dataStr := c.PostForm("data")
-		if dataStr == "" {
+		if len(upload.data) == 0 {
+			removeStored(h.storage, upload.attachments)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "missing data field"})
 			return
 		}
 
-		if err := json.Unmarshal([]byte(dataStr), &req); err != nil {
+		if err := json.Unmarshal(upload.data, &req); err != nil {
+			removeStored(h.storage, upload.attachments)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON in data field"})
 			return
 		}
 
-		// Handle file upload if present
-		file, header, err := c.Request.FormFile("file")
-		if err == nil {
-			defer file.Close()
-
-			// Validate file
-			if err := utils.ValidateFile(file, header); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-
-			// Upload file
-			fileURL, err := h.storage.Upload(file, header.Filename, header.Header.Get("Content-Type"))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
-				return
-			}
-
-			req.AttachmentURL = &fileURL
-		}
+		// "file" may be repeated
+		req.Attachments = upload.attachments
 	}
 
 	// Override event_id from URL
@@ -112,6 +100,7 @@ func (h *EventCommentHandler) CreateComment(c *gin.Context) {
 
 	comment, err := h.service.CreateComment(&req, userID, role)
 	if err != nil {
+		removeStored(h.storage, req.Attachments)
 		if err.Error() == "not authorized" || err.Error() == "event has no associated dog" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
@@ -120,6 +109,7 @@ func (h *EventCommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
+	h.scans.Submit(c.Request.Context(), comment.Attachments)
 	c.JSON(http.StatusCreated, comment)
 }
 
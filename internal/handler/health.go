@@ -5,28 +5,32 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/migrator"
 	"gorm.io/gorm"
 )
 
 // HealthHandler health check handler
 type HealthHandler struct {
-	db *gorm.DB
+	db     *gorm.DB
+	dbType string
 }
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler(db *gorm.DB) *HealthHandler {
-	return &HealthHandler{db: db}
+// NewHealthHandler creates a new health handler; dbType selects the migrations
+// the schema version is compared with
+func NewHealthHandler(db *gorm.DB, dbType string) *HealthHandler {
+	return &HealthHandler{db: db, dbType: dbType}
 }
 
 type healthResponse struct {
-	OK  bool   `json:"ok"`
-	DB  string `json:"db"`
-	Now string `json:"now"`
+	OK     bool             `json:"ok"`
+	DB     string           `json:"db"`
+	Schema *migrator.Status `json:"schema,omitempty"` // Omitted if the database is down
+	Now    string           `json:"now"`
 }
 
 // HealthCheck godoc
 // @Summary      Health check
-// @Description  Check service health and DB connection
+// @Description  Check service health, DB connection and schema version
 // @Tags         system
 // @Produce      json
 // @Success      200  {object}  healthResponse
@@ -39,9 +43,17 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 		}
 	}
 
+	var schema *migrator.Status
+	if status == "up" {
+		if current, err := migrator.Current(h.db, h.dbType); err == nil {
+			schema = &current
+		}
+	}
+
 	c.JSON(http.StatusOK, healthResponse{
-		OK:  true,
-		DB:  status,
-		Now: time.Now().UTC().Format(time.RFC3339),
+		OK:     true,
+		DB:     status,
+		Schema: schema,
+		Now:    time.Now().UTC().Format(time.RFC3339),
 	})
 }
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// JobHandler HTTP request handler for background job administration
+type JobHandler struct {
+	service service.JobService
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(service service.JobService) *JobHandler {
+	return &JobHandler{service: service}
+}
+
+// ListJobs godoc
+// @Summary      List background jobs
+// @Description  Jobs of all types, newest first (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status     query     string  false  "Filter by status"  Enums(pending, running, succeeded, dead)
+// @Param        type       query     string  false  "Filter by job type"
+// @Param        page       query     int     false  "Page number"  default(1)
+// @Param        page_size  query     int     false  "Page size"    default(20)
+// @Success      200        {object}  dto.JobListResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      401        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /admin/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	var params dto.JobListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.service.ListJobs(&params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetJob godoc
+// @Summary      Get background job
+// @Description  Job details with payload and last error (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Job ID"
+// @Success      200  {object}  dto.JobResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/jobs/{id} [get]
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.service.GetJob(uint(utils.Atoi(c.Param("id"))))
+	if err != nil {
+		h.handleError(c, err, "failed to get job")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob godoc
+// @Summary      Retry dead job
+// @Description  Puts a dead-lettered job back in the queue with fresh attempts (admin only)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Job ID"
+// @Success      202  {object}  dto.JobResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/jobs/{id}/retry [post]
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	job, err := h.service.RetryJob(uint(utils.Atoi(c.Param("id"))))
+	if err != nil {
+		h.handleError(c, err, "failed to retry job")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// handleError maps job service errors to responses
+func (h *JobHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrJobNotDead):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -19,13 +19,25 @@ func SetupRouter(
 	consultantHandler *ConsultantHandler,
 	consultantNoteHandler *ConsultantNoteHandler,
 	eventCommentHandler *EventCommentHandler,
+	statsHandler *StatsHandler,
+	timelineHandler *TimelineHandler,
+	syncHandler *SyncHandler,
+	streamHandler *StreamHandler,
+	webhookHandler *WebhookHandler,
+	jobHandler *JobHandler,
+	attachmentHandler *AttachmentHandler,
+	tusHandler *TusHandler,
+	fileHandler *FileHandler, // nil unless files are kept in local storage
 	authService service.AuthService,
+	idempotencyService service.IdempotencyService,
 ) *gin.Engine {
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(middleware.Logger("access_token"), gin.Recovery())
 
-	// Serve uploaded files (for local storage)
-	router.Static("/uploads", "./uploads")
+	// Uploaded files (local storage) - only with a signed URL, see /attachments/:id/content
+	if fileHandler != nil {
+		router.GET("/files/*key", fileHandler.ServeFile)
+	}
 
 	// Health check
 	router.GET("/health", healthHandler.HealthCheck)
@@ -52,12 +64,28 @@ func SetupRouter(
 		// Public users endpoint (for backward compatibility)
 		api.POST("/users", userHandler.CreateUser)
 
+		// tus discovery is sent without credentials
+		api.OPTIONS("/uploads", tusHandler.Protocol(), tusHandler.Options)
+
+		// Real-time streams - EventSource and browser WebSocket cannot set headers,
+		// so the token may also come from the access_token query parameter
+		streams := api.Group("/")
+		streams.Use(middleware.TokenFromQuery("access_token"), middleware.AuthMiddleware(authService))
+		{
+			streams.GET("/dogs/:id/stream", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), streamHandler.Stream)
+			streams.GET("/dogs/:id/ws", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), streamHandler.WebSocket)
+		}
+
 		// Protected routes (require authentication)
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware(authService))
+
+		// Retry-safe creation for clients sending an Idempotency-Key header
+		idempotent := middleware.Idempotency(idempotencyService, maxUploadRequestSize)
 		{
 			// Events - require authentication
-			protected.POST("/events", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL), eventHandler.CreateEvent)
+			protected.POST("/events", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL), idempotent, eventHandler.CreateEvent)
+			protected.POST("/events/batch", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL), idempotent, eventHandler.CreateEventsBatch)
 			protected.GET("/events", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), eventHandler.ListEvents)
 			protected.GET("/events/:id", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), eventHandler.GetEvent)
 			protected.DELETE("/events/:id", middleware.RequireAnyPermission(permissions.EVENTS_DELETE_OWN, permissions.EVENTS_DELETE_ALL), eventHandler.DeleteEvent)
@@ -68,6 +96,12 @@ func SetupRouter(
 			protected.GET("/dogs/:id", middleware.RequireAnyPermission(permissions.DOGS_VIEW_OWN, permissions.DOGS_VIEW_ASSIGNED, permissions.DOGS_VIEW_ALL), dogHandler.GetDog)
 			protected.PUT("/dogs/:id", middleware.RequireAnyPermission(permissions.DOGS_UPDATE_OWN, permissions.DOGS_UPDATE_ALL), dogHandler.UpdateDog)
 			protected.DELETE("/dogs/:id", middleware.RequireAnyPermission(permissions.DOGS_DELETE_OWN, permissions.DOGS_DELETE_ALL), dogHandler.DeleteDog)
+			protected.GET("/dogs/:id/stats", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), statsHandler.GetDogStats)
+			protected.GET("/dogs/:id/timeline", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), timelineHandler.GetDogTimeline)
+
+			// Offline sync - pull needs read access, pushed records are authorized one by one
+			protected.GET("/sync", middleware.RequireAnyPermission(permissions.DOGS_VIEW_OWN, permissions.DOGS_VIEW_ASSIGNED, permissions.DOGS_VIEW_ALL), syncHandler.Pull)
+			protected.POST("/sync", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL, permissions.EVENT_COMMENTS_CREATE_OWN, permissions.EVENT_COMMENTS_CREATE_ASSIGNED, permissions.CONSULTANT_NOTES_CREATE), syncHandler.Push)
 
 			// Users - require authentication
 			protected.GET("/users", middleware.RequireAnyPermission(permissions.USERS_VIEW_OWN, permissions.USERS_VIEW_ALL), userHandler.ListUsers)
@@ -79,24 +113,56 @@ func SetupRouter(
 			protected.PUT("/consultants/profile", middleware.RequirePermission(permissions.CONSULTANTS_PROFILE_UPDATE), consultantHandler.UpdateProfile)
 			protected.GET("/consultants", middleware.RequirePermission(permissions.CONSULTANTS_SEARCH), consultantHandler.SearchConsultants)
 			protected.GET("/consultants/:id", middleware.RequirePermission(permissions.CONSULTANTS_SEARCH), consultantHandler.GetProfile)
-			protected.POST("/consultants/:id/invite", middleware.RequirePermission(permissions.CONSULTANTS_INVITE), consultantHandler.InviteConsultant)
+			protected.POST("/consultants/:id/invite", middleware.RequirePermission(permissions.CONSULTANTS_INVITE), idempotent, consultantHandler.InviteConsultant)
 
 			// Invites - require authentication
-			protected.POST("/invites/accept", middleware.RequirePermission(permissions.CONSULTANTS_INVITES_ACCEPT), consultantHandler.AcceptInvite)
+			protected.POST("/invites/accept", middleware.RequirePermission(permissions.CONSULTANTS_INVITES_ACCEPT), idempotent, consultantHandler.AcceptInvite)
 
 			// Consultant Notes - require authentication
-			protected.POST("/consultant-notes", middleware.RequirePermission(permissions.CONSULTANT_NOTES_CREATE), consultantNoteHandler.CreateNote)
+			protected.POST("/consultant-notes", middleware.RequirePermission(permissions.CONSULTANT_NOTES_CREATE), idempotent, consultantNoteHandler.CreateNote)
 			protected.GET("/consultant-notes", middleware.RequireAnyPermission(permissions.CONSULTANT_NOTES_VIEW_OWN, permissions.CONSULTANT_NOTES_VIEW_ALL), consultantNoteHandler.ListNotes)
 			protected.GET("/consultant-notes/:id", middleware.RequireAnyPermission(permissions.CONSULTANT_NOTES_VIEW_OWN, permissions.CONSULTANT_NOTES_VIEW_ALL), consultantNoteHandler.GetNote)
 			protected.PUT("/consultant-notes/:id", middleware.RequirePermission(permissions.CONSULTANT_NOTES_UPDATE_OWN), consultantNoteHandler.UpdateNote)
 			protected.DELETE("/consultant-notes/:id", middleware.RequirePermission(permissions.CONSULTANT_NOTES_DELETE_OWN), consultantNoteHandler.DeleteNote)
 
 			// Event Comments - require authentication
-			protected.POST("/events/:id/comments", middleware.RequireAnyPermission(permissions.EVENT_COMMENTS_CREATE_OWN, permissions.EVENT_COMMENTS_CREATE_ASSIGNED), eventCommentHandler.CreateComment)
+			protected.POST("/events/:id/comments", middleware.RequireAnyPermission(permissions.EVENT_COMMENTS_CREATE_OWN, permissions.EVENT_COMMENTS_CREATE_ASSIGNED), idempotent, eventCommentHandler.CreateComment)
 			protected.GET("/events/:id/comments", middleware.RequireAnyPermission(permissions.EVENT_COMMENTS_VIEW_OWN, permissions.EVENT_COMMENTS_VIEW_ASSIGNED), eventCommentHandler.ListComments)
 			protected.GET("/event-comments/:id", middleware.RequireAnyPermission(permissions.EVENT_COMMENTS_VIEW_OWN, permissions.EVENT_COMMENTS_VIEW_ASSIGNED), eventCommentHandler.GetComment)
 			protected.PUT("/event-comments/:id", middleware.RequirePermission(permissions.EVENT_COMMENTS_UPDATE_AUTHORED), eventCommentHandler.UpdateComment)
 			protected.DELETE("/event-comments/:id", middleware.RequirePermission(permissions.EVENT_COMMENTS_DELETE_AUTHORED), eventCommentHandler.DeleteComment)
+
+			// Attachments - add files to events, comments and notes, remove single files
+			// Downloads redirect to signed URLs; /link returns one for <img src>
+			protected.GET("/attachments/:id/content", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), attachmentHandler.GetContent)
+			protected.GET("/attachments/:id/link", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), attachmentHandler.GetContentLink)
+			protected.GET("/attachments/:id/thumbnails/:size", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), attachmentHandler.GetThumbnail)
+			protected.GET("/attachments/:id/thumbnails/:size/link", middleware.RequireAnyPermission(permissions.EVENTS_VIEW_OWN, permissions.EVENTS_VIEW_ASSIGNED, permissions.EVENTS_VIEW_ALL), attachmentHandler.GetThumbnailLink)
+			protected.POST("/events/:id/attachments", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL), idempotent, attachmentHandler.AddToEvent)
+			protected.POST("/event-comments/:id/attachments", middleware.RequirePermission(permissions.EVENT_COMMENTS_UPDATE_AUTHORED), idempotent, attachmentHandler.AddToComment)
+			protected.POST("/consultant-notes/:id/attachments", middleware.RequirePermission(permissions.CONSULTANT_NOTES_UPDATE_OWN), idempotent, attachmentHandler.AddToNote)
+			protected.DELETE("/attachments/:id", middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL, permissions.EVENT_COMMENTS_UPDATE_AUTHORED, permissions.CONSULTANT_NOTES_UPDATE_OWN), attachmentHandler.DeleteAttachment)
+
+			// Resumable uploads (tus 1.0) - completed uploads are attached by ID through the routes above
+			uploads := protected.Group("/uploads", tusHandler.Protocol(), middleware.RequireAnyPermission(permissions.EVENTS_CREATE_OWN, permissions.EVENTS_CREATE_ASSIGNED, permissions.EVENTS_CREATE_ALL, permissions.EVENT_COMMENTS_UPDATE_AUTHORED, permissions.CONSULTANT_NOTES_UPDATE_OWN))
+			uploads.POST("", tusHandler.CreateUpload)
+			uploads.HEAD("/:id", tusHandler.GetOffset)
+			uploads.PATCH("/:id", tusHandler.WriteChunk)
+			uploads.DELETE("/:id", tusHandler.DeleteUpload)
+
+			// Webhooks - own endpoints, admins manage all
+			protected.POST("/webhooks", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), idempotent, webhookHandler.CreateWebhook)
+			protected.GET("/webhooks", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.ListWebhooks)
+			protected.GET("/webhooks/:id", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.GetWebhook)
+			protected.PUT("/webhooks/:id", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.UpdateWebhook)
+			protected.DELETE("/webhooks/:id", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.DeleteWebhook)
+			protected.GET("/webhooks/:id/deliveries", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.ListDeliveries)
+			protected.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", middleware.RequireAnyPermission(permissions.WEBHOOKS_MANAGE_OWN, permissions.WEBHOOKS_MANAGE_ALL), webhookHandler.Redeliver)
+
+			// Background jobs - admin only
+			protected.GET("/admin/jobs", middleware.RequirePermission(permissions.JOBS_MANAGE), jobHandler.ListJobs)
+			protected.GET("/admin/jobs/:id", middleware.RequirePermission(permissions.JOBS_MANAGE), jobHandler.GetJob)
+			protected.POST("/admin/jobs/:id/retry", middleware.RequirePermission(permissions.JOBS_MANAGE), jobHandler.RetryJob)
 		}
 	}
 
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// StatsHandler HTTP request handler for dog statistics
+type StatsHandler struct {
+	service service.StatsService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(service service.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// GetDogStats godoc
+// @Summary      Get dog statistics
+// @Description  Event counts by type grouped by day, week or month, totals of structured fields and longest daily streaks
+// @Tags         dogs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int     true   "Dog ID"
+// @Param        group_by  query     string  false  "Grouping period" Enums(day, week, month) default(day)
+// @Param        from      query     string  false  "From datetime (RFC3339)"
+// @Param        to        query     string  false  "To datetime (RFC3339)"
+// @Param        types     query     string  false  "Event types (comma-separated)"
+// @Success      200       {object}  dto.DogStatsResponse
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /dogs/{id}/stats [get]
+func (h *StatsHandler) GetDogStats(c *gin.Context) {
+	id := uint(utils.Atoi(c.Param("id")))
+
+	var params dto.DogStatsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.From != nil && params.To != nil && !params.From.Before(*params.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	stats, err := h.service.GetDogStats(id, &params, userID, role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"}) // Return 404 to avoid leaking existence
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/realtime"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// streamHeartbeat keeps idle connections open through proxies
+const streamHeartbeat = 25 * time.Second
+
+// resetEvent tells a resuming client that messages were lost and it should reload
+const resetEvent = "reset"
+
+// StreamHandler HTTP request handler for real-time dog feeds
+type StreamHandler struct {
+	service     service.StreamService
+	accessCheck time.Duration
+	upgrader    websocket.Upgrader
+}
+
+// NewStreamHandler creates a new stream handler. Open streams recheck access to
+// the dog every accessCheck and are closed once it is lost.
+func NewStreamHandler(service service.StreamService, accessCheck time.Duration) *StreamHandler {
+	if accessCheck <= 0 {
+		accessCheck = time.Minute
+	}
+	return &StreamHandler{
+		service:     service,
+		accessCheck: accessCheck,
+		upgrader: websocket.Upgrader{
+			// Authentication uses bearer tokens, not cookies, so cross-origin sockets are safe
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Stream godoc
+// @Summary      Stream dog changes (SSE)
+// @Description  Server-Sent Events with created/updated/deleted events, comments and notes of a dog.
+// @Description  Reconnect with the Last-Event-ID header (or last_event_id query) to receive missed messages; a "reset" event means some were lost.
+// @Description  EventSource clients may pass the JWT as access_token query parameter.
+// @Description  Access is rechecked while the stream is open; the stream ends once it is lost and reconnecting returns 404.
+// @Tags         dogs
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        id             path      int     true   "Dog ID"
+// @Param        Last-Event-ID  header    int     false  "ID of the last received message"
+// @Param        last_event_id  query     int     false  "Same as Last-Event-ID"
+// @Param        access_token   query     string  false  "JWT if the Authorization header cannot be set"
+// @Success      200            {object}  realtime.Message
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /dogs/{id}/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	sub, backlog, complete, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	id := uint(utils.Atoi(c.Param("id")))
+	userID, role := h.viewer(c)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !complete {
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", resetEvent)
+	}
+	for i := range backlog {
+		if err := writeSSE(c, &backlog[i]); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	accessCheck := time.NewTicker(h.accessCheck)
+	defer accessCheck.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-accessCheck.C:
+			if h.accessLost(id, userID, role) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case msg, open := <-sub.C:
+			if !open {
+				// Too slow or shutting down; the client reconnects with Last-Event-ID
+				return
+			}
+			if !msg.VisibleTo(userID, role) {
+				continue
+			}
+			if err := writeSSE(c, &msg); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket godoc
+// @Summary      Stream dog changes (WebSocket)
+// @Description  Same messages as /dogs/{id}/stream as JSON text frames. A {"type":"reset"} frame means some messages were lost.
+// @Tags         dogs
+// @Security     BearerAuth
+// @Param        id             path      int     true   "Dog ID"
+// @Param        last_event_id  query     int     false  "ID of the last received message"
+// @Param        access_token   query     string  false  "JWT if the Authorization header cannot be set"
+// @Success      101            {object}  realtime.Message
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /dogs/{id}/ws [get]
+func (h *StreamHandler) WebSocket(c *gin.Context) {
+	sub, backlog, complete, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	id := uint(utils.Atoi(c.Param("id")))
+	userID, role := h.viewer(c)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	// Reader: handles control frames and notices when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if !complete {
+		if err := conn.WriteJSON(gin.H{"type": resetEvent}); err != nil {
+			return
+		}
+	}
+	for i := range backlog {
+		if err := conn.WriteJSON(&backlog[i]); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	accessCheck := time.NewTicker(h.accessCheck)
+	defer accessCheck.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-accessCheck.C:
+			if h.accessLost(id, userID, role) {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "access revoked"),
+					time.Now().Add(time.Second))
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		case msg, open := <-sub.C:
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect with last_event_id"),
+					time.Now().Add(time.Second))
+				return
+			}
+			if !msg.VisibleTo(userID, role) {
+				continue
+			}
+			if err := conn.WriteJSON(&msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe authorizes the request and subscribes to the dog; on failure the
+// error response is already written
+func (h *StreamHandler) subscribe(c *gin.Context) (*realtime.Subscription, []realtime.Message, bool, bool) {
+	id := uint(utils.Atoi(c.Param("id")))
+
+	lastEventID, err := parseLastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event id"})
+		return nil, nil, false, false
+	}
+
+	userID, role := h.viewer(c)
+	sub, backlog, complete, err := h.service.Subscribe(id, lastEventID, userID, role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"}) // Return 404 to avoid leaking existence
+			return nil, nil, false, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to subscribe"})
+		return nil, nil, false, false
+	}
+	return sub, backlog, complete, true
+}
+
+// accessLost reports whether the user can no longer see the dog, e.g. after it
+// was deleted or a consultant's access was revoked. Other errors keep the stream
+// open until the next check.
+func (h *StreamHandler) accessLost(dogID uint, userID uint, role models.UserRole) bool {
+	err := h.service.CheckAccess(dogID, userID, role)
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+		return true
+	}
+	log.Printf("stream: checking access to dog %d: %v", dogID, err)
+	return false
+}
+
+// viewer returns the authenticated user; AuthMiddleware guarantees both values
+func (h *StreamHandler) viewer(c *gin.Context) (uint, models.UserRole) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	role, _ := middleware.GetUserRoleFromContext(c)
+	return userID, role
+}
+
+// parseLastEventID reads the resume position from the header or query
+func parseLastEventID(c *gin.Context) (uint64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+// writeSSE writes one message in text/event-stream format
+func writeSSE(c *gin.Context, msg *realtime.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+	return err
+}
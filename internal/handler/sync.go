@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/service"
+)
+
+// SyncHandler HTTP request handler for offline-first sync
+type SyncHandler struct {
+	service service.SyncService
+}
+
+// NewSyncHandler creates a new sync handler
+func NewSyncHandler(service service.SyncService) *SyncHandler {
+	return &SyncHandler{service: service}
+}
+
+// Pull godoc
+// @Summary      Pull changes since a checkpoint
+// @Description  Dogs, events, comments and notes visible to the caller that were created or updated since the token, plus tombstones for deletions.
+// @Description  Without since a full snapshot is returned. Records may repeat across pulls and should be upserted by id.
+// @Description  Results are paged: while has_more is true, pull again with next_since to get the rest.
+// @Tags         sync
+// @Produce      json
+// @Security     BearerAuth
+// @Param        since  query     string  false  "Token from the previous pull"
+// @Param        limit  query     int     false  "Page size, records of all kinds together (default 500, max 1000)"
+// @Success      200    {object}  dto.SyncPullResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /sync [get]
+func (h *SyncHandler) Pull(c *gin.Context) {
+	var params dto.SyncPullParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Limit < 0 || params.Limit > dto.MaxSyncPullLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", dto.MaxSyncPullLimit)})
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	resp, err := h.service.Pull(params.Since, params.Limit, userID, role)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSyncToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Push godoc
+// @Summary      Push records created offline
+// @Description  Creates events, comments and notes made offline, identified by client-generated UUIDs.
+// @Description  Each record is authorized like the regular create endpoints and gets its own result: created, exists (already pushed), conflict or rejected.
+// @Tags         sync
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.SyncPushRequest  true  "Offline records"
+// @Success      200      {object}  dto.SyncPushResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /sync [post]
+func (h *SyncHandler) Push(c *gin.Context) {
+	var req dto.SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Validate records one by one so a bad record does not block the rest
+	var rejected dto.SyncPushResponse
+	valid := dto.SyncPushRequest{}
+	for _, item := range req.Events {
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			rejected.Events = append(rejected.Events, rejectedPush(item.ClientID, err))
+			continue
+		}
+		valid.Events = append(valid.Events, item)
+	}
+	for _, item := range req.Comments {
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			rejected.Comments = append(rejected.Comments, rejectedPush(item.ClientID, err))
+			continue
+		}
+		valid.Comments = append(valid.Comments, item)
+	}
+	for _, item := range req.Notes {
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			rejected.Notes = append(rejected.Notes, rejectedPush(item.ClientID, err))
+			continue
+		}
+		valid.Notes = append(valid.Notes, item)
+	}
+
+	resp, err := h.service.Push(&valid, userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply changes"})
+		return
+	}
+	resp.Events = append(resp.Events, rejected.Events...)
+	resp.Comments = append(resp.Comments, rejected.Comments...)
+	resp.Notes = append(resp.Notes, rejected.Notes...)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// rejectedPush builds the result for a record that failed validation
+func rejectedPush(clientID string, err error) dto.SyncPushResult {
+	return dto.SyncPushResult{ClientID: clientID, Status: dto.SyncStatusRejected, Reason: err.Error()}
+}
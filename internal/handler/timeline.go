@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// TimelineHandler HTTP request handler for the dog timeline
+type TimelineHandler struct {
+	service service.TimelineService
+}
+
+// NewTimelineHandler creates a new timeline handler
+func NewTimelineHandler(service service.TimelineService) *TimelineHandler {
+	return &TimelineHandler{service: service}
+}
+
+// GetDogTimeline godoc
+// @Summary      Get dog timeline
+// @Description  Events, event comments and visible consultant notes of a dog merged into one feed, newest first
+// @Tags         dogs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int     true   "Dog ID"
+// @Param        cursor  query     string  false  "Cursor from the previous page (next_cursor)"
+// @Param        limit   query     int     false  "Page size" default(20)
+// @Success      200     {object}  dto.TimelineResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /dogs/{id}/timeline [get]
+func (h *TimelineHandler) GetDogTimeline(c *gin.Context) {
+	id := uint(utils.Atoi(c.Param("id")))
+
+	var params dto.TimelineParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	timeline, err := h.service.GetDogTimeline(id, &params, userID, role)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"}) // Return 404 to avoid leaking existence
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/imaging"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+)
+
+// tus 1.0 protocol constants, see https://tus.io/protocols/resumable-upload
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,creation-with-upload,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusHandler HTTP request handler for resumable uploads following the tus 1.0
+// protocol. A completed upload is attached to an event, comment or note by ID
+// through the attachment endpoints.
+type TusHandler struct {
+	service service.UploadService
+}
+
+// NewTusHandler creates a new tus handler
+func NewTusHandler(service service.UploadService) *TusHandler {
+	return &TusHandler{service: service}
+}
+
+// Protocol sets Tus-Resumable on responses and rejects requests of another
+// protocol version. OPTIONS, which clients use to discover the server, is exempt.
+func (h *TusHandler) Protocol() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", tusVersion)
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tusVersion {
+			c.Header("Tus-Version", tusVersion)
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "unsupported tus version"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Options godoc
+// @Summary      Discover resumable upload support
+// @Description  tus 1.0 discovery: supported versions, extensions and the largest accepted upload
+// @Tags         uploads
+// @Success      204
+// @Header       204  {string}  Tus-Version    "1.0.0"
+// @Header       204  {string}  Tus-Extension  "creation,creation-with-upload,termination,expiration"
+// @Header       204  {integer} Tus-Max-Size   "Largest accepted upload in bytes"
+// @Router       /uploads [options]
+func (h *TusHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.service.MaxSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload godoc
+// @Summary      Start a resumable upload
+// @Description  tus 1.0 creation. The file name and type are sent base64-encoded in Upload-Metadata ("filename" and "filetype").
+// @Description  The first chunk may be sent in the body with Content-Type application/offset+octet-stream.
+// @Tags         uploads
+// @Security     BearerAuth
+// @Param        Tus-Resumable    header  string  true   "1.0.0"
+// @Param        Upload-Length    header  int     true   "File size in bytes"
+// @Param        Upload-Metadata  header  string  false  "filename <base64>,filetype <base64>"
+// @Success      201
+// @Header       201  {string}  Location        "URL of the upload; its last segment is the upload ID"
+// @Header       201  {string}  Upload-Expires  "When an unfinished or unattached upload is removed"
+// @Failure      400  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Failure      413  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /uploads [post]
+func (h *TusHandler) CreateUpload(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Upload-Length"})
+		return
+	}
+	metadata, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Upload-Metadata"})
+		return
+	}
+	filename := metadata["filename"]
+	if filename == "" {
+		filename = metadata["name"]
+	}
+	filename = truncate(filepath.Base(filename), 255)
+	contentType := metadata["filetype"]
+	if contentType == "" {
+		contentType = metadata["type"]
+	}
+	contentType = truncate(fileContentType(contentType, filename), 100)
+
+	upload, err := h.service.CreateUpload(userID, filename, contentType, length)
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+upload.ID)
+
+	// creation-with-upload
+	if c.ContentType() == tusContentType {
+		upload, err = h.service.WriteChunk(upload.ID, userID, 0, c.Request.Body)
+		if err != nil {
+			h.abort(c, err)
+			return
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	}
+
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// GetOffset godoc
+// @Summary      Get the offset of a resumable upload
+// @Description  tus 1.0 HEAD: the number of bytes received, from which the client resumes
+// @Tags         uploads
+// @Security     BearerAuth
+// @Param        id             path    string  true  "Upload ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Success      200
+// @Header       200  {integer} Upload-Offset  "Bytes received"
+// @Header       200  {integer} Upload-Length  "File size in bytes"
+// @Failure      404
+// @Failure      412
+// @Router       /uploads/{id} [head]
+func (h *TusHandler) GetOffset(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	upload, err := h.service.GetUpload(c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUploadNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	h.setProgress(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// WriteChunk godoc
+// @Summary      Send a chunk of a resumable upload
+// @Description  tus 1.0 PATCH: appends the body at Upload-Offset, which must equal the upload's offset.
+// @Description  When the last byte arrives the file is checked by its content; an upload of a type that is not allowed is removed.
+// @Tags         uploads
+// @Accept       application/offset+octet-stream
+// @Security     BearerAuth
+// @Param        id             path    string  true  "Upload ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Param        Upload-Offset  header  int     true  "Offset of the chunk"
+// @Success      204
+// @Header       204  {integer} Upload-Offset  "Bytes received"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Failure      413  {object}  map[string]string
+// @Failure      415  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /uploads/{id} [patch]
+func (h *TusHandler) WriteChunk(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tusContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Upload-Offset"})
+		return
+	}
+
+	upload, err := h.service.GetUpload(c.Param("id"), userID)
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+	if c.Request.ContentLength > upload.Length-offset {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "chunk exceeds Upload-Length"})
+		return
+	}
+
+	upload, err = h.service.WriteChunk(upload.ID, userID, offset, c.Request.Body)
+	if err != nil {
+		if upload != nil && errors.Is(err, service.ErrUploadOffset) {
+			c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		}
+		h.abort(c, err)
+		return
+	}
+
+	h.setProgress(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUpload godoc
+// @Summary      Cancel a resumable upload
+// @Description  tus 1.0 termination: removes the upload and the bytes received
+// @Tags         uploads
+// @Security     BearerAuth
+// @Param        id             path    string  true  "Upload ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      412  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /uploads/{id} [delete]
+func (h *TusHandler) DeleteUpload(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.service.DeleteUpload(c.Param("id"), userID); err != nil {
+		h.abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setProgress sets the offset headers of an upload
+func (h *TusHandler) setProgress(c *gin.Context, upload *models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// abort responds with the status of an upload service error
+func (h *TusHandler) abort(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+	case errors.Is(err, service.ErrUploadOffset):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the upload"})
+	case errors.Is(err, service.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrFileEmpty), errors.Is(err, utils.ErrFileNotAllowed), errors.Is(err, utils.ErrInvalidDocument),
+		errors.Is(err, imaging.ErrInvalidImage), errors.Is(err, imaging.ErrImageTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("uploads: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store upload"})
+	}
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma-separated
+// "key base64value" pairs, where the value may be omitted
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, errors.New("malformed metadata pair")
+		}
+	}
+	return metadata, nil
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/imaging"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/storage"
+	"github.com/you/pawtrack/internal/utils"
+)
+
+// Limits of multipart requests with attachments
+const (
+	// maxFilesPerRequest limits the repeated "file" parts of one request
+	maxFilesPerRequest = 10
+	// maxDataFieldSize limits the JSON "data" field
+	maxDataFieldSize = 1 << 20
+	// maxUploadRequestSize limits the whole body: every file at its limit, the
+	// data field and some room for part headers
+	maxUploadRequestSize = maxFilesPerRequest*utils.MaxFileSize + 2*maxDataFieldSize
+)
+
+var (
+	errTooManyFiles   = fmt.Errorf("at most %d files per request", maxFilesPerRequest)
+	errDataTooLarge   = errors.New("data field is too large")
+	errStorageFailure = errors.New("failed to upload file")
+)
+
+// multipartUpload is a multipart request read by readMultipart
+type multipartUpload struct {
+	data        []byte              // The "data" field, nil when absent
+	attachments []models.Attachment // Stored "file" parts, in request order
+}
+
+// readMultipart streams a multipart request part by part, without buffering
+// files in memory or temp files. Each "file" part is validated on its first
+// bytes and copied to storage as it arrives, with the size limits enforced
+// while copying. Other parts than "data" and "file" are skipped.
+//
+// On failure the files stored so far are removed, the error response is
+// written and ok is false.
+func readMultipart(c *gin.Context, fs storage.FileStorage, images service.ImageService) (upload *multipartUpload, ok bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadRequestSize)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse form"})
+		return nil, false
+	}
+
+	upload = &multipartUpload{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return upload, true
+		}
+		if err != nil {
+			abortUpload(c, fs, upload.attachments, "", err)
+			return nil, false
+		}
+
+		switch part.FormName() {
+		case "data":
+			upload.data, err = io.ReadAll(io.LimitReader(part, maxDataFieldSize+1))
+			if err == nil && len(upload.data) > maxDataFieldSize {
+				err = errDataTooLarge
+			}
+		case "file":
+			var attachment *models.Attachment
+			if len(upload.attachments) == maxFilesPerRequest {
+				err = errTooManyFiles
+			} else if attachment, err = storePart(fs, images, part); err == nil {
+				upload.attachments = append(upload.attachments, *attachment)
+			}
+		}
+		part.Close()
+
+		if err != nil {
+			abortUpload(c, fs, upload.attachments, part.FileName(), err)
+			return nil, false
+		}
+	}
+}
+
+// storePart validates a file part on its first bytes, then streams it to
+// storage while computing its size and SHA-256. Images are read whole and
+// stored without their metadata, with thumbnails; documents are read whole and
+// checked before they are stored.
+func storePart(fs storage.FileStorage, images service.ImageService, part *multipart.Part) (*models.Attachment, error) {
+	filename := part.FileName()
+
+	src := bufio.NewReaderSize(part, utils.SniffLen)
+	head, err := src.Peek(utils.SniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := utils.ValidateFileHead(filename, head); err != nil {
+		return nil, err
+	}
+
+	contentType := utils.DetectFileType(filename, head)
+
+	body := &uploadReader{r: src, hash: sha256.New()}
+	if images.Supported(contentType) {
+		return storeImage(images, body, filename, contentType)
+	}
+
+	var file io.Reader = body
+	if utils.IsDocument(contentType) {
+		data, _ := io.ReadAll(body)
+		if body.err != nil {
+			return nil, body.err
+		}
+		if err := utils.ValidateDocument(contentType, data); err != nil {
+			return nil, err
+		}
+		file = bytes.NewReader(data)
+	}
+
+	key, err := fs.Upload(file, filename, contentType)
+	if body.err != nil {
+		return nil, body.err // The request, not the storage, failed
+	}
+	if err != nil {
+		log.Printf("attachments: failed to store %s: %v", filename, err)
+		return nil, errStorageFailure
+	}
+
+	return &models.Attachment{
+		Key:         key,
+		Backend:     fs.Name(),
+		Filename:    truncate(filepath.Base(filename), 255),
+		ContentType: truncate(contentType, 100),
+		Size:        body.size,
+		SHA256:      hex.EncodeToString(body.hash.Sum(nil)),
+	}, nil
+}
+
+// storeImage reads an image part, up to MaxFileSize, and stores it through the image service
+func storeImage(images service.ImageService, body *uploadReader, filename, contentType string) (*models.Attachment, error) {
+	data, _ := io.ReadAll(body)
+	if body.err != nil {
+		return nil, body.err
+	}
+
+	attachment, err := images.Store(data, filename, contentType)
+	if err != nil {
+		if errors.Is(err, imaging.ErrInvalidImage) || errors.Is(err, imaging.ErrImageTooLarge) {
+			return nil, err
+		}
+		log.Printf("attachments: failed to store %s: %v", filename, err)
+		return nil, errStorageFailure
+	}
+	attachment.Filename = truncate(filepath.Base(attachment.Filename), 255)
+	return attachment, nil
+}
+
+// fileContentType returns the type a client declared for a file, or the one
+// of its extension when clients without a MIME database send none or
+// application/octet-stream
+func fileContentType(declared, filename string) string {
+	if declared == "" || declared == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			return byExt
+		}
+	}
+	return declared
+}
+
+// uploadReader counts and hashes a file while storage reads it, and fails once
+// the file exceeds MaxFileSize. It keeps the first read error so that a broken
+// or oversized request is not reported as a storage failure.
+type uploadReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+	err  error
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	u.hash.Write(p[:n])
+	u.size += int64(n)
+	if u.size > utils.MaxFileSize {
+		err = utils.ErrFileTooLarge
+	}
+	if err != nil && err != io.EOF && u.err == nil {
+		u.err = err
+	}
+	return n, err
+}
+
+// abortUpload removes the files stored for a failed request and writes the
+// error response; filename names the file being read, if any
+func abortUpload(c *gin.Context, fs storage.FileStorage, stored []models.Attachment, filename string, err error) {
+	removeStored(fs, stored)
+
+	message := err.Error()
+	if filename != "" {
+		message = fmt.Sprintf("%s: %s", filename, message)
+	}
+
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.Is(err, errStorageFailure):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload file"})
+	case errors.As(err, &maxBytes):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+	case errors.Is(err, utils.ErrFileTooLarge), errors.Is(err, errDataTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": message})
+	case errors.Is(err, utils.ErrFileEmpty), errors.Is(err, utils.ErrFileNotAllowed), errors.Is(err, errTooManyFiles),
+		errors.Is(err, utils.ErrInvalidDocument), errors.Is(err, imaging.ErrInvalidImage), errors.Is(err, imaging.ErrImageTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to parse form"})
+	}
+}
+
+// removeStored deletes stored files that are no longer referenced. Failures
+// only leave orphaned files behind, so they are logged.
+func removeStored(fs storage.FileStorage, attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		if attachment.Backend != fs.Name() {
+			continue
+		}
+		for _, key := range attachment.Keys() {
+			if err := fs.Delete(key); err != nil {
+				log.Printf("attachments: failed to delete %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/service"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// WebhookHandler HTTP request handler for webhook endpoints and deliveries
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook godoc
+// @Summary      Register webhook endpoint
+// @Description  Subscribe a URL to event types. Deliveries are signed with the returned secret, which is shown only once.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        webhook  body      dto.CreateWebhookRequest  true  "Webhook data"
+// @Success      201      {object}  dto.WebhookResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, _, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.service.CreateEndpoint(&req, userID)
+	if err != nil {
+		h.handleError(c, err, "failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhook endpoints
+// @Description  Own webhook endpoints; admins see all of them
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   dto.WebhookResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.service.ListEndpoints(userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary      Get webhook endpoint
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Webhook ID"
+// @Success      200  {object}  dto.WebhookResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetEndpoint(uint(utils.Atoi(c.Param("id"))), userID, role)
+	if err != nil {
+		h.handleError(c, err, "failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary      Update webhook endpoint
+// @Description  Change URL, description, event types or pause the endpoint with active=false. Omitted fields are kept.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Webhook ID"
+// @Param        webhook  body      dto.UpdateWebhookRequest  true  "Fields to change"
+// @Success      200      {object}  dto.WebhookResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.service.UpdateEndpoint(uint(utils.Atoi(c.Param("id"))), &req, userID, role)
+	if err != nil {
+		h.handleError(c, err, "failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook endpoint
+// @Description  Removes the endpoint together with its delivery log
+// @Tags         webhooks
+// @Security     BearerAuth
+// @Param        id   path  int  true  "Webhook ID"
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(uint(utils.Atoi(c.Param("id"))), userID, role); err != nil {
+		h.handleError(c, err, "failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Delivery log of an endpoint with attempts, last response and the sent payload, newest first
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int     true   "Webhook ID"
+// @Param        status     query     string  false  "Filter by status (pending, succeeded, failed)"
+// @Param        page       query     int     false  "Page number" default(1)
+// @Param        page_size  query     int     false  "Page size" default(20)
+// @Success      200        {object}  dto.WebhookDeliveryListResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      401        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	var params dto.WebhookDeliveryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(uint(utils.Atoi(c.Param("id"))), &params, userID, role)
+	if err != nil {
+		h.handleError(c, err, "failed to list deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver godoc
+// @Summary      Redeliver webhook
+// @Description  Queues a new delivery with the same payload; the original stays in the log
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id           path      int  true  "Webhook ID"
+// @Param        delivery_id  path      int  true  "Delivery ID"
+// @Success      202          {object}  dto.WebhookDeliveryResponse
+// @Failure      401          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      409          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, role, ok := webhookCaller(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(uint(utils.Atoi(c.Param("id"))), uint(utils.Atoi(c.Param("delivery_id"))), userID, role)
+	if err != nil {
+		h.handleError(c, err, "failed to redeliver webhook")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// handleError maps webhook service errors to responses
+func (h *WebhookHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrUnknownWebhookEventType), errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrWebhookAddressForbidden):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWebhookInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized":
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"}) // 404 to avoid leaking other users' endpoints
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// webhookCaller reads the authenticated user, responding 401 if missing
+func webhookCaller(c *gin.Context) (uint, models.UserRole, bool) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, "", false
+	}
+	role, err := middleware.GetUserRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, "", false
+	}
+	return userID, role, true
+}
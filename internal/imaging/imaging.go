@@ -0,0 +1,183 @@
+// Package imaging re-encodes uploaded photos without their metadata (EXIF GPS
+// coordinates, camera serials, XMP) and renders thumbnails of them.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Errors returned by Process
+var (
+	ErrInvalidImage  = errors.New("image is corrupt or not supported")
+	ErrImageTooLarge = errors.New("image exceeds 50 megapixels")
+)
+
+// MaxPixels bounds the decoded size of an image, which takes 4 bytes a pixel in memory
+const MaxPixels = 50_000_000
+
+// Sizes are the thumbnails rendered for every image, by the longest side in pixels
+var Sizes = []Size{
+	{Name: "small", Max: 160},
+	{Name: "medium", Max: 480},
+	{Name: "large", Max: 1280},
+}
+
+// Size is a thumbnail size
+type Size struct {
+	Name string
+	Max  int
+}
+
+// Result is an image without metadata and its thumbnails
+type Result struct {
+	Data        []byte
+	ContentType string      // That of the processed file, image/png for SVG
+	Thumbnails  []Thumbnail // In the order of Sizes; empty for images that cannot be decoded, such as animated WebP
+}
+
+// Thumbnail is an encoded thumbnail. Images with transparency get PNG thumbnails, others JPEG.
+type Thumbnail struct {
+	Size        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Supported reports whether images of the content type, as detected from the
+// file, are processed
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// Process removes the metadata of an image and renders its thumbnails. JPEG
+// and PNG are decoded and re-encoded, JPEG turned upright first since the EXIF
+// orientation goes with the rest of the metadata. WebP, which has no encoder
+// here, keeps its image data and loses its EXIF and XMP chunks. SVG, which may
+// carry scripts, is rendered to PNG.
+func Process(data []byte, contentType string) (*Result, error) {
+	if contentType == "image/svg+xml" {
+		img, err := rasterizeSVG(data)
+		if err != nil {
+			return nil, err
+		}
+		return encode(img, "image/png")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		return encode(orient(img, jpegOrientation(data)), contentType)
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		return encode(img, contentType)
+	case "image/webp":
+		result := &Result{ContentType: contentType}
+		result.Data, err = stripWebPMetadata(data)
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		// Animated WebP is not decoded, it is kept without thumbnails
+		if img, _ = webp.Decode(bytes.NewReader(data)); img != nil {
+			result.Thumbnails, err = thumbnails(img)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return nil, ErrInvalidImage
+}
+
+// encode encodes a decoded image as JPEG or PNG and renders its thumbnails
+func encode(img image.Image, contentType string) (*Result, error) {
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+	} else if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	thumbs, err := thumbnails(img)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Data: buf.Bytes(), ContentType: contentType, Thumbnails: thumbs}, nil
+}
+
+// thumbnails renders img at every size, each from the next larger one
+func thumbnails(img image.Image) ([]Thumbnail, error) {
+	thumbs := make([]Thumbnail, len(Sizes))
+	src := img
+	for i := len(Sizes) - 1; i >= 0; i-- {
+		width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), Sizes[i].Max)
+		dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+		thumb := Thumbnail{Size: Sizes[i].Name, Width: width, Height: height}
+		var buf bytes.Buffer
+		if dst.Opaque() {
+			thumb.ContentType = "image/jpeg"
+			if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+				return nil, err
+			}
+		} else {
+			thumb.ContentType = "image/png"
+			if err := png.Encode(&buf, dst); err != nil {
+				return nil, err
+			}
+		}
+		thumb.Data = buf.Bytes()
+		thumbs[i] = thumb
+		src = dst
+	}
+	return thumbs, nil
+}
+
+// fit scales width and height down so that the longer side is at most max,
+// keeping the aspect ratio. Images are never scaled up.
+func fit(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+	if width >= height {
+		return max, clampMin(height * max / width)
+	}
+	return clampMin(width * max / height), max
+}
+
+func clampMin(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
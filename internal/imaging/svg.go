@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"math"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// SVGSize is the longer side, in pixels, of the PNG an SVG is rendered to
+const SVGSize = 2048
+
+// rasterizeSVG renders an SVG image. Only shapes are drawn: scripts, event
+// handlers, links, embedded HTML and external references do not survive, which
+// is what makes the result safe to serve. Text is not rendered either.
+func rasterizeSVG(data []byte) (img image.Image, err error) {
+	if !isSVG(data) {
+		return nil, ErrInvalidImage
+	}
+
+	// The parser is fed untrusted input; a panic rejects the file, not the request
+	defer func() {
+		if recover() != nil {
+			img, err = nil, ErrInvalidImage
+		}
+	}()
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	w, h := icon.ViewBox.W, icon.ViewBox.H
+	if !(w > 0 && h > 0) || math.IsInf(w, 0) || math.IsInf(h, 0) {
+		return nil, ErrInvalidImage
+	}
+
+	width, height := SVGSize, SVGSize
+	if w >= h {
+		height = clampMin(int(math.Round(SVGSize * h / w)))
+	} else {
+		width = clampMin(int(math.Round(SVGSize * w / h)))
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	icon.Draw(rasterx.NewDasher(width, height, rasterx.NewScannerGV(width, height, dst, dst.Bounds())), 1)
+	return dst, nil
+}
+
+// isSVG reports whether the root element of an XML document is <svg>
+func isSVG(data []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "svg"
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package jobs runs retryable background work stored in the database.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// ErrUnknownJobType is returned when enqueueing a type without a registered handler
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// maxBackoff caps the delay between retries
+const maxBackoff = 6 * time.Hour
+
+// Options configures the queue
+type Options struct {
+	Workers      int           // Jobs run concurrently
+	PollInterval time.Duration // How often due jobs are looked up when idle
+	DrainTimeout time.Duration // How long running jobs may finish on shutdown before their context is cancelled
+}
+
+// TypeOptions configures a job type
+type TypeOptions struct {
+	MaxAttempts int           // Attempts before the job is dead-lettered
+	Backoff     time.Duration // Delay before the first retry, doubled for each following one
+	Timeout     time.Duration // Run time limit; the job is reclaimed by another worker after it
+}
+
+// Handler runs one job; an error schedules a retry unless it is Permanent
+type Handler func(ctx context.Context, job *models.Job) error
+
+// jobType is a registered handler with its options
+type jobType struct {
+	handler Handler
+	opts    TypeOptions
+}
+
+// Queue is a database-backed job queue with a pool of workers
+type Queue struct {
+	repo  repository.JobRepository
+	opts  Options
+	types map[string]jobType
+	wake  chan struct{}
+	name  string
+}
+
+// NewQueue creates a queue; handlers must be registered before Run
+func NewQueue(repo repository.JobRepository, opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = 30 * time.Second
+	}
+
+	host, _ := os.Hostname()
+	return &Queue{
+		repo:  repo,
+		opts:  opts,
+		types: make(map[string]jobType),
+		wake:  make(chan struct{}, 1),
+		name:  fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+// Register adds a handler for a job type whose payload decodes into T
+func Register[T any](q *Queue, name string, opts TypeOptions, handle func(ctx context.Context, job *models.Job, payload T) error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+
+	q.types[name] = jobType{
+		opts: opts,
+		handler: func(ctx context.Context, job *models.Job) error {
+			var payload T
+			if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+			return handle(ctx, job, payload)
+		},
+	}
+}
+
+// Enqueue adds a job to run as soon as a worker is free
+func (q *Queue) Enqueue(name string, payload interface{}) (*models.Job, error) {
+	return q.EnqueueAt(name, payload, time.Now().UTC())
+}
+
+// EnqueueAt adds a job to run at the given time
+func (q *Queue) EnqueueAt(name string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	t, ok := q.types[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, name)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:        name,
+		Payload:     string(data),
+		Status:      models.JobPending,
+		MaxAttempts: t.opts.MaxAttempts,
+		RunAt:       runAt.UTC(),
+	}
+	if err := q.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	q.Notify()
+	return job, nil
+}
+
+// Notify wakes an idle worker up without waiting for the next poll
+func (q *Queue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// NextAttemptAt returns when a job failing now will be retried, or nil if it
+// has no attempts left
+func (q *Queue) NextAttemptAt(job *models.Job) *time.Time {
+	if job.Attempts >= job.MaxAttempts {
+		return nil
+	}
+
+	delay := q.types[job.Type].opts.Backoff
+	for i := 1; i < job.Attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	next := time.Now().UTC().Add(delay)
+	return &next
+}
+
+// Run processes jobs with the configured number of workers until ctx is cancelled.
+// It then stops claiming new jobs and waits for running ones, cancelling their
+// context if they take longer than DrainTimeout.
+func (q *Queue) Run(ctx context.Context) {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+			q.work(ctx, jobCtx, worker)
+		}(fmt.Sprintf("%s/%d", q.name, i))
+	}
+
+	<-ctx.Done()
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(q.opts.DrainTimeout):
+		log.Printf("jobs: drain timeout, cancelling running jobs")
+		cancelJobs()
+		<-drained
+	}
+}
+
+// work is the loop of one worker
+func (q *Queue) work(ctx, jobCtx context.Context, worker string) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Run jobs back to back while there are due ones
+		for ctx.Err() == nil && q.runNext(jobCtx, worker) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// runNext claims and runs one job; returns false if there was nothing to run
+func (q *Queue) runNext(ctx context.Context, worker string) bool {
+	now := time.Now().UTC()
+	// The lock must outlast the longest job type; each handler is cut off at its own timeout
+	job, err := q.repo.Claim(worker, now, now.Add(q.lockDuration()))
+	if err != nil {
+		log.Printf("jobs: claim: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	t, ok := q.types[job.Type]
+	if !ok {
+		q.bury(job, worker, fmt.Sprintf("no handler registered for %q", job.Type))
+		return true
+	}
+	if job.Attempts > job.MaxAttempts {
+		// Reclaimed after its worker stopped during the last attempt
+		q.bury(job, worker, "lock expired during the last attempt")
+		return true
+	}
+
+	err = q.execute(ctx, t, job)
+	switch {
+	case err == nil:
+		if err := q.repo.Complete(job.ID, worker); err != nil {
+			log.Printf("jobs: complete %d: %v", job.ID, err)
+		}
+	case IsPermanent(err):
+		q.bury(job, worker, err.Error())
+	default:
+		next := q.NextAttemptAt(job)
+		if next == nil {
+			q.bury(job, worker, err.Error())
+			break
+		}
+		if err := q.repo.Reschedule(job.ID, worker, *next, err.Error()); err != nil {
+			log.Printf("jobs: reschedule %d: %v", job.ID, err)
+		}
+	}
+	return true
+}
+
+// execute runs the handler with the type's timeout, turning panics into errors
+func (q *Queue) execute(ctx context.Context, t jobType, job *models.Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.opts.Timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.handler(ctx, job)
+}
+
+// bury dead-letters a job
+func (q *Queue) bury(job *models.Job, worker, reason string) {
+	log.Printf("jobs: %s job %d is dead: %s", job.Type, job.ID, reason)
+	if err := q.repo.Bury(job.ID, worker, reason); err != nil {
+		log.Printf("jobs: bury %d: %v", job.ID, err)
+	}
+}
+
+// lockDuration is the longest timeout of the registered types plus a margin
+func (q *Queue) lockDuration() time.Duration {
+	longest := time.Minute
+	for _, t := range q.types {
+		if t.opts.Timeout > longest {
+			longest = t.opts.Timeout
+		}
+	}
+	return longest + 30*time.Second
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error so that the job is dead-lettered without retries
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
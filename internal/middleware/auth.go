@@ -104,3 +104,17 @@ func GetUserRoleFromContext(c *gin.Context) (models.UserRole, error) {
 
 	return userRole, nil
 }
+
+// TokenFromQuery lets clients that cannot set headers (EventSource, browser WebSocket)
+// pass the JWT as a query parameter. Must run before AuthMiddleware; the
+// parameter is redacted in the access log by Logger.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query(param); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/you/pawtrack/internal/service"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client-generated key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from a stored result
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes mutating endpoints safe to retry. When a request carries an
+// Idempotency-Key header, its response is stored per user and replayed for retries
+// with the same key and body. Bodies of keyed requests are read before the
+// handler runs and are limited to maxBodySize. Must run after AuthMiddleware.
+func Idempotency(idempotencyService service.IdempotencyService, maxBodySize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "idempotency key is too long"})
+			c.Abort()
+			return
+		}
+
+		userID, err := GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Abort()
+			return
+		}
+
+		method := c.Request.Method
+		path := c.Request.URL.RequestURI()
+		h := newFingerprint(method, path)
+		body, cleanup, err := spoolBody(c.Request.Body, h, maxBodySize)
+		if err != nil {
+			if errors.Is(err, errBodyTooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			}
+			c.Abort()
+			return
+		}
+		defer cleanup()
+		c.Request.Body = body
+
+		record, replay, err := idempotencyService.Begin(userID, key, method, path, hex.EncodeToString(h.Sum(nil)))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check idempotency key"})
+			}
+			c.Abort()
+			return
+		}
+
+		if replay {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		completed := false
+		defer func() {
+			// Free the key if the handler panicked so the client can retry
+			if !completed {
+				if err := idempotencyService.Release(record); err != nil {
+					log.Printf("idempotency: release key %d: %v", record.ID, err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			// Server errors are not stored, a retry should run the request again
+			return
+		}
+		if err := idempotencyService.Complete(record, status, recorder.body.Bytes(), recorder.Header().Get("Content-Type")); err != nil {
+			log.Printf("idempotency: store response for key %d: %v", record.ID, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// newFingerprint starts the hash identifying a request by method, path and body;
+// the body is written by spoolBody
+func newFingerprint(method, path string) hash.Hash {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	return h
+}
+
+// maxBufferedBody is the size of request bodies kept in memory for replay to
+// the handler; larger ones, such as file uploads, are spooled to a temp file
+const maxBufferedBody = 1 << 20
+
+// errBodyTooLarge is returned by spoolBody for bodies over the limit
+var errBodyTooLarge = errors.New("request body is too large")
+
+// spoolBody reads a request body of at most maxSize bytes into h and returns a
+// copy of it for the handler. cleanup removes the temp file of a spooled body.
+func spoolBody(r io.Reader, h hash.Hash, maxSize int64) (body io.ReadCloser, cleanup func(), err error) {
+	r = io.LimitReader(r, maxSize+1)
+
+	var buf bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&buf, h), io.LimitReader(r, maxBufferedBody+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > maxSize {
+		return nil, nil, errBodyTooLarge
+	}
+	if n <= maxBufferedBody {
+		return io.NopCloser(&buf), func() {}, nil
+	}
+
+	file, err := os.CreateTemp("", "pawtrack-body-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	if _, err := buf.WriteTo(file); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	rest, err := io.Copy(io.MultiWriter(file, h), r)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if n+rest > maxSize {
+		cleanup()
+		return nil, nil, errBodyTooLarge
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return file, cleanup, nil
+}
+
+// responseRecorder keeps a copy of the response body written by the handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
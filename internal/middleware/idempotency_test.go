@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSpoolBodyLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		size    int
+		maxSize int64
+		err     error
+	}{
+		{"buffered", 1 << 10, 1 << 20, nil},
+		{"buffered over the limit", 2 << 10, 1 << 10, errBodyTooLarge},
+		{"spooled", 3 << 20, 4 << 20, nil},
+		{"spooled at the limit", 4 << 20, 4 << 20, nil},
+		{"spooled over the limit", 4<<20 + 1, 4 << 20, errBodyTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("a"), tc.size)
+			body, cleanup, err := spoolBody(bytes.NewReader(data), sha256.New(), tc.maxSize)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer cleanup()
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("body of %d bytes handed on as %d bytes", len(data), len(got))
+			}
+		})
+	}
+}
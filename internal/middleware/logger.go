@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger is gin.Logger with the values of the given query parameters replaced,
+// so that tokens passed in URLs (see TokenFromQuery) stay out of the access log
+func Logger(redacted ...string) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		param.Path = redactQuery(param.Path, redacted)
+		return formatLog(param)
+	})
+}
+
+// redactQuery replaces the values of the named parameters in the query of path,
+// keeping the order of the others
+func redactQuery(path string, names []string) string {
+	base, query, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+	parts := strings.Split(query, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil {
+			for _, redacted := range names {
+				if name == redacted {
+					parts[i] = key + "=REDACTED"
+				}
+			}
+		}
+	}
+	return base + "?" + strings.Join(parts, "&")
+}
+
+// formatLog formats a request like gin's default logger
+func formatLog(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.ErrorMessage,
+	)
+}
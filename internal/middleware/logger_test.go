@@ -0,0 +1,20 @@
+package middleware
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	names := []string{"access_token"}
+	for path, want := range map[string]string{
+		"/api/v1/dogs/1/stream":                           "/api/v1/dogs/1/stream",
+		"/api/v1/dogs/1/stream?access_token=eyJ.a.b":      "/api/v1/dogs/1/stream?access_token=REDACTED",
+		"/api/v1/dogs/1/ws?since=5&access_token=eyJ&x=1":  "/api/v1/dogs/1/ws?since=5&access_token=REDACTED&x=1",
+		"/api/v1/dogs/1/ws?access%5Ftoken=eyJ":            "/api/v1/dogs/1/ws?access%5Ftoken=REDACTED",
+		"/api/v1/dogs/1/ws?access_token":                  "/api/v1/dogs/1/ws?access_token=REDACTED",
+		"/api/v1/events?page=2&access_token_hint=visible": "/api/v1/events?page=2&access_token_hint=visible",
+		"/api/v1/dogs/1/ws?access_token=a&access_token=b": "/api/v1/dogs/1/ws?access_token=REDACTED&access_token=REDACTED",
+	} {
+		if got := redactQuery(path, names); got != want {
+			t.Errorf("%s: got %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package migrator applies the SQL migrations embedded in the binary with
+// golang-migrate.
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/you/pawtrack/migrations"
+	"gorm.io/gorm"
+)
+
+// versionTable is where golang-migrate records the applied version
+const versionTable = "schema_migrations"
+
+// Status is the schema version of a database
+type Status struct {
+	Version uint `json:"version"` // Last applied migration, 0 if none
+	Dirty   bool `json:"dirty"`   // The last migration failed halfway; fix the schema, then force a version
+	Latest  uint `json:"latest"`  // Newest embedded migration
+}
+
+// Migrator runs the embedded migrations of one database type
+type Migrator struct {
+	m      *migrate.Migrate
+	latest uint
+}
+
+// New opens a migrator for dbType ("postgres" or "sqlite") and a golang-migrate
+// database URL, e.g. postgres://... or sqlite3://pawtrack.db
+func New(dbType, databaseURL string) (*Migrator, error) {
+	src, err := sourceFor(dbType)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := lastVersion(src)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	m.Log = logger{}
+	return &Migrator{m: m, latest: latest}, nil
+}
+
+// Close releases the database connection
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Quiet stops logging each applied migration
+func (mg *Migrator) Quiet() {
+	mg.m.Log = nil
+}
+
+// Up applies all pending migrations
+func (mg *Migrator) Up() error {
+	return ignoreNoChange(mg.m.Up())
+}
+
+// Down rolls back the last n migrations
+func (mg *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+	return ignoreNoChange(mg.m.Steps(-n))
+}
+
+// Goto migrates up or down to the given version
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Force records the version as applied and clears the dirty flag without running
+// any migration; -1 records that none is applied
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Status returns the applied version of the database
+func (mg *Migrator) Status() (Status, error) {
+	version, dirty, err := mg.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, err
+	}
+	return Status{Version: version, Dirty: dirty, Latest: mg.latest}, nil
+}
+
+// Current reads the status of an open database without taking the migration lock
+func Current(db *gorm.DB, dbType string) (Status, error) {
+	src, err := sourceFor(dbType)
+	if err != nil {
+		return Status{}, err
+	}
+	defer src.Close()
+
+	status := Status{}
+	if status.Latest, err = lastVersion(src); err != nil {
+		return Status{}, err
+	}
+	if !db.Migrator().HasTable(versionTable) {
+		return status, nil
+	}
+
+	var row struct {
+		Version int64
+		Dirty   bool
+	}
+	result := db.Table(versionTable).Select("version, dirty").Limit(1).Scan(&row)
+	if result.Error != nil {
+		return Status{}, result.Error
+	}
+	if result.RowsAffected == 1 && row.Version >= 0 {
+		status.Version = uint(row.Version)
+		status.Dirty = row.Dirty
+	}
+	return status, nil
+}
+
+// sourceFor returns the embedded migrations of the database type
+func sourceFor(dbType string) (source.Driver, error) {
+	if _, err := fs.Stat(migrations.FS, dbType); err != nil {
+		return nil, fmt.Errorf("no migrations for database type %q", dbType)
+	}
+	return iofs.New(migrations.FS, dbType)
+}
+
+// lastVersion returns the newest version of a migration source
+func lastVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// ignoreNoChange treats "nothing to migrate" as success
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// logger prints each applied migration
+type logger struct{}
+
+func (logger) Printf(format string, v ...interface{}) { log.Printf("migrate: "+format, v...) }
+func (logger) Verbose() bool                          { return false }
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScanStatus is the state of the malware scan of an attachment
+type ScanStatus string
+
+const (
+	ScanQuarantine ScanStatus = "quarantine" // Not scanned yet, downloads are blocked
+	ScanClean      ScanStatus = "clean"
+	ScanInfected   ScanStatus = "infected" // Malware found, downloads stay blocked
+)
+
+// Attachment is a file uploaded with an event, a comment or a consultant note;
+// exactly one of EventID, CommentID and NoteID is set. Files are private and
+// downloaded through GET /attachments/:id/content, which checks access to the
+// owner and resolves the key to a signed URL of the backend. New attachments
+// are quarantined until the malware scanner finds them clean.
+type Attachment struct {
+	ID          uint        `json:"id" gorm:"primaryKey" example:"7"`
+	EventID     *uint       `json:"-" gorm:"index"`             // Set for event attachments
+	CommentID   *uint       `json:"-" gorm:"index"`             // Set for comment attachments
+	NoteID      *uint       `json:"-" gorm:"index"`             // Set for consultant note attachments
+	Key         string      `json:"-" gorm:"size:500;not null"` // Opaque key returned by FileStorage.Upload
+	Backend     string      `json:"-" gorm:"size:20;not null"`  // FileStorage holding the file, "local" or "s3"
+	Filename    string      `json:"filename" gorm:"size:255;not null" example:"vaccination.pdf"`
+	ContentType string      `json:"content_type" gorm:"size:100;not null" example:"application/pdf"`
+	Size        int64       `json:"size" gorm:"not null" example:"48213"`                                                                                    // Bytes
+	SHA256      string      `json:"sha256" gorm:"column:sha256;size:64;not null" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Hex SHA-256 of the content
+	UploadID    string      `json:"-" gorm:"-"`                                                                                                              // Resumable upload the file comes from, consumed when the attachment is created
+	URL         string      `json:"url" gorm:"-" example:"/api/v1/attachments/7/content"`                                                                    // Download path, resolved on load
+	Thumbnails  []Thumbnail `json:"thumbnails,omitempty" gorm:"foreignKey:AttachmentID;constraint:OnDelete:CASCADE"`                                         // Downscaled copies of JPEG, PNG and WebP images
+	ScanStatus  ScanStatus  `json:"scan_status" gorm:"size:20;not null" example:"clean"`
+	ScanThreat  string      `json:"scan_threat,omitempty" gorm:"size:255" example:"Eicar-Test-Signature"` // Signature found in an infected file
+	ScannedAt   *time.Time  `json:"scanned_at,omitempty" example:"2025-11-22T10:00:05Z"`
+	CreatedAt   time.Time   `json:"created_at" example:"2025-11-22T10:00:00Z"`
+}
+
+// Thumbnail is a downscaled copy of an image attachment, or of a completed
+// resumable upload until it is attached. It is kept in the attachment's backend.
+type Thumbnail struct {
+	ID           uint    `json:"-" gorm:"primaryKey"`
+	AttachmentID *uint   `json:"-" gorm:"index"`                               // Set once attached
+	UploadID     *string `json:"-" gorm:"size:36;index"`                       // Set while the image waits in an upload
+	Size         string  `json:"size" gorm:"size:20;not null" example:"small"` // "small", "medium" or "large", see imaging.Sizes
+	Key          string  `json:"-" gorm:"size:500;not null"`                   // Opaque key returned by FileStorage.Upload
+	ContentType  string  `json:"content_type" gorm:"size:100;not null" example:"image/jpeg"`
+	Width        int     `json:"width" gorm:"not null" example:"160"`
+	Height       int     `json:"height" gorm:"not null" example:"120"`
+	URL          string  `json:"url" gorm:"-" example:"/api/v1/attachments/7/thumbnails/small"` // Download path, resolved on load
+}
+
+// ContentPath returns the API path downloading the attachment
+func (a *Attachment) ContentPath() string {
+	return fmt.Sprintf("/api/v1/attachments/%d/content", a.ID)
+}
+
+// BeforeCreate quarantines new attachments
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ScanStatus == "" {
+		a.ScanStatus = ScanQuarantine
+	}
+	return nil
+}
+
+// AfterFind resolves the download path
+func (a *Attachment) AfterFind(tx *gorm.DB) error {
+	a.URL = a.ContentPath()
+	return nil
+}
+
+// AfterCreate resolves the download path
+func (a *Attachment) AfterCreate(tx *gorm.DB) error {
+	a.URL = a.ContentPath()
+	return nil
+}
+
+// Keys returns the storage keys of the file and its thumbnails
+func (a *Attachment) Keys() []string {
+	keys := []string{a.Key}
+	for _, thumbnail := range a.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	return keys
+}
+
+// Thumbnail returns the thumbnail of the given size, or nil
+func (a *Attachment) Thumbnail(size string) *Thumbnail {
+	for i := range a.Thumbnails {
+		if a.Thumbnails[i].Size == size {
+			return &a.Thumbnails[i]
+		}
+	}
+	return nil
+}
+
+// Path returns the API path downloading the thumbnail of an attachment
+func (t *Thumbnail) Path() string {
+	if t.AttachmentID == nil {
+		return ""
+	}
+	return fmt.Sprintf("/api/v1/attachments/%d/thumbnails/%s", *t.AttachmentID, t.Size)
+}
+
+// AfterFind resolves the download path
+func (t *Thumbnail) AfterFind(tx *gorm.DB) error {
+	t.URL = t.Path()
+	return nil
+}
+
+// AfterCreate resolves the download path
+func (t *Thumbnail) AfterCreate(tx *gorm.DB) error {
+	t.URL = t.Path()
+	return nil
+}
+
+// firstAttachmentURL returns the download path of the first attachment, kept in
+// attachment_url for clients written when only one file was allowed
+func firstAttachmentURL(attachments []Attachment) *string {
+	if len(attachments) == 0 || attachments[0].ID == 0 {
+		return nil
+	}
+	path := attachments[0].ContentPath()
+	return &path
+}
@@ -11,5 +11,5 @@ type Dog struct {
 	Breed     string    `json:"breed" gorm:"size:255" example:"Golden Retriever"`
 	BirthDate time.Time `json:"birth_date" example:"2020-01-01T00:00:00Z"`
 	CreatedAt time.Time `json:"created_at" example:"2025-11-22T10:00:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2025-11-22T10:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"index" example:"2025-11-22T10:00:00Z"`
 }
@@ -1,17 +1,38 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Event represents a tracking event
 // Examples of type: walk, feed, meds, training, vet, note
 type Event struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	DogID     *uint     `json:"dog_id,omitempty" gorm:"index" example:"1"`
-	Dog       *Dog      `json:"dog,omitempty" gorm:"foreignKey:DogID"`
-	Type      string    `json:"type" gorm:"size:50;not null" example:"walk"`
-	Note      string    `json:"note" gorm:"size:255" example:"morning walk"`
-	At        time.Time `json:"at" gorm:"not null" example:"2025-11-22T10:00:00Z"`
-	AttachmentURL *string `json:"attachment_url,omitempty" gorm:"size:500" example:"https://example.com/file.jpg"`
-	CreatedAt time.Time `json:"created_at" example:"2025-11-22T10:00:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2025-11-22T10:00:00Z"`
+	ID              uint         `json:"id" gorm:"primaryKey" example:"1"`
+	DogID           *uint        `json:"dog_id,omitempty" gorm:"index;index:idx_events_dog_id_at,priority:1" example:"1"`
+	Dog             *Dog         `json:"dog,omitempty" gorm:"foreignKey:DogID"`
+	Type            string       `json:"type" gorm:"size:50;not null" example:"walk"`
+	Note            string       `json:"note" gorm:"size:255" example:"morning walk"`
+	At              time.Time    `json:"at" gorm:"not null;index:idx_events_dog_id_at,priority:2" example:"2025-11-22T10:00:00Z"`
+	DurationMinutes *int         `json:"duration_minutes,omitempty" example:"30"`                                   // e.g. walk or training length
+	AmountGrams     *int         `json:"amount_grams,omitempty" example:"150"`                                      // e.g. food portion
+	AttachmentURL   *string      `json:"attachment_url,omitempty" gorm:"-" example:"/api/v1/attachments/7/content"` // Deprecated: download path of the first attachment, see Attachments
+	Attachments     []Attachment `json:"attachments,omitempty" gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE"`
+	ClientID        *string      `json:"client_id,omitempty" gorm:"size:36;uniqueIndex" example:"6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"` // UUID generated by offline clients
+	CreatedAt       time.Time    `json:"created_at" example:"2025-11-22T10:00:00Z"`
+	UpdatedAt       time.Time    `json:"updated_at" gorm:"index" example:"2025-11-22T10:00:00Z"`
+	Highlight       string       `json:"highlight,omitempty" gorm:"-" example:"morning <mark>walk</mark>"` // Set in search results: the note with matches marked, HTML-escaped
+}
+
+// AfterFind resolves the deprecated attachment_url
+func (e *Event) AfterFind(tx *gorm.DB) error {
+	e.AttachmentURL = firstAttachmentURL(e.Attachments)
+	return nil
+}
+
+// AfterCreate resolves the deprecated attachment_url of the event created with attachments
+func (e *Event) AfterCreate(tx *gorm.DB) error {
+	e.AttachmentURL = firstAttachmentURL(e.Attachments)
+	return nil
 }
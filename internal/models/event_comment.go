@@ -1,16 +1,34 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // EventComment represents a comment on an event
 type EventComment struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	EventID   uint      `json:"event_id" gorm:"not null;index"`
-	Event     *Event    `json:"event,omitempty" gorm:"foreignKey:EventID"`
-	UserID    uint      `json:"user_id" gorm:"not null;index"`
-	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Content   string    `json:"content" gorm:"type:text;not null"` // Markdown content
-	AttachmentURL *string `json:"attachment_url,omitempty" gorm:"size:500"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	EventID       uint         `json:"event_id" gorm:"not null;index"`
+	Event         *Event       `json:"event,omitempty" gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE"`
+	UserID        uint         `json:"user_id" gorm:"not null;index"`
+	User          *User        `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Content       string       `json:"content" gorm:"type:text;not null"` // Markdown content
+	AttachmentURL *string      `json:"attachment_url,omitempty" gorm:"-"` // Deprecated: download path of the first attachment, see Attachments
+	Attachments   []Attachment `json:"attachments,omitempty" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+	ClientID      *string      `json:"client_id,omitempty" gorm:"size:36;uniqueIndex"` // UUID generated by offline clients
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" gorm:"index"`
+}
+
+// AfterFind resolves the deprecated attachment_url
+func (c *EventComment) AfterFind(tx *gorm.DB) error {
+	c.AttachmentURL = firstAttachmentURL(c.Attachments)
+	return nil
+}
+
+// AfterCreate resolves the deprecated attachment_url of the comment created with attachments
+func (c *EventComment) AfterCreate(tx *gorm.DB) error {
+	c.AttachmentURL = firstAttachmentURL(c.Attachments)
+	return nil
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the outcome of a mutating request sent with an
+// Idempotency-Key header so that retries can be answered with the same response
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key,priority:1"`
+	Key          string    `json:"key" gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_user_key,priority:2"`
+	Method       string    `json:"method" gorm:"size:10;not null"`
+	Path         string    `json:"path" gorm:"size:500;not null"`
+	Fingerprint  string    `json:"fingerprint" gorm:"size:64;not null"`   // sha256 of method, path and body
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"` // 0 while the original request is in flight
+	ResponseBody []byte    `json:"-"`
+	ContentType  string    `json:"content_type" gorm:"size:100"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
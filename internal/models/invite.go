@@ -9,6 +9,7 @@ const (
 	InvitePending  InviteStatus = "pending"
 	InviteAccepted InviteStatus = "accepted"
 	InviteRejected InviteStatus = "rejected"
+	InviteExpired  InviteStatus = "expired" // Not accepted before ExpiresAt
 )
 
 // Invite represents an invitation for a consultant to manage a dog
@@ -21,7 +22,7 @@ type Invite struct {
 	DogID        uint         `json:"dog_id" gorm:"not null"`
 	Dog          *Dog         `json:"dog,omitempty" gorm:"foreignKey:DogID"`
 	Token        string       `json:"-" gorm:"uniqueIndex;not null;size:255"`
-	Status       InviteStatus `json:"status" gorm:"default:'pending';size:20"`
+	Status       InviteStatus `json:"status" gorm:"default:'pending';size:20;index:idx_invites_status_expires_at,priority:1"`
 	CreatedAt    time.Time    `json:"created_at"`
-	ExpiresAt    time.Time    `json:"expires_at"`
+	ExpiresAt    time.Time    `json:"expires_at" gorm:"index:idx_invites_status_expires_at,priority:2"`
 }
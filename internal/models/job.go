@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// JobStatus represents the state of a background job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead" // Out of attempts or failed permanently, retried only manually
+)
+
+// Job is a unit of background work stored in the database
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type" gorm:"size:100;not null;index"`
+	Payload     string     `json:"-" gorm:"type:text;not null"` // JSON, decoded by the type's handler
+	Status      JobStatus  `json:"status" gorm:"size:20;not null;index:idx_jobs_status_run_at,priority:1"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_jobs_status_run_at,priority:2"` // Not started before this time
+	LockedBy    string     `json:"locked_by,omitempty" gorm:"size:100"`                            // Worker running the job
+	LockedUntil *time.Time `json:"locked_until,omitempty"`                                         // Running jobs past this time are reclaimed
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
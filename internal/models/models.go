@@ -0,0 +1,30 @@
+package models
+
+// All returns a value of every model stored in the database. The schema check
+// compares them with the tables created by the migrations.
+func All() []interface{} {
+	return []interface{}{
+		&User{},
+		&Dog{},
+		&Event{},
+		&EventComment{},
+		&ConsultantProfile{},
+		&ConsultantAccess{},
+		&Invite{},
+		&ConsultantNote{},
+		&Permission{},
+		&UserPermission{},
+		&IdempotencyKey{},
+		&Tombstone{},
+		&WebhookEndpoint{},
+		&WebhookDelivery{},
+		&OutboxEvent{},
+		&Job{},
+		&ScheduledTask{},
+		&Attachment{},
+		&Upload{},
+		&UploadChunk{},
+		&Thumbnail{},
+		&StoredObject{},
+	}
+}
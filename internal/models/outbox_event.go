@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event stored before dispatch, so that subscribers
+// still receive it if the process stops right after the change
+type OutboxEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"size:50;not null"`
+	Payload   string    `json:"payload" gorm:"type:text;not null"` // JSON encoded event
+	CreatedAt time.Time `json:"created_at"`
+
+	LockedBy    string     `json:"locked_by,omitempty" gorm:"size:100"` // Relay dispatching the event
+	LockedUntil *time.Time `json:"locked_until,omitempty"`              // Claimed events past this time are claimed again
+}
@@ -13,9 +13,9 @@ type Permission struct {
 // UserPermission represents the many-to-many relationship between users and permissions
 type UserPermission struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
-	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	UserID       uint      `json:"user_id" gorm:"not null;index;uniqueIndex:idx_user_permissions_user_permission,priority:1"`
 	User         *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	PermissionID uint      `json:"permission_id" gorm:"not null;index"`
+	PermissionID uint      `json:"permission_id" gorm:"not null;index;uniqueIndex:idx_user_permissions_user_permission,priority:2"`
 	Permission   *Permission `json:"permission,omitempty" gorm:"foreignKey:PermissionID"`
 	GrantedAt    time.Time `json:"granted_at"`
 }
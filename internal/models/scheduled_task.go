@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ScheduledTask tracks runs of a scheduled maintenance task. The row doubles as a
+// lock so that only one replica runs each scheduled run.
+type ScheduledTask struct {
+	Name           string     `json:"name" gorm:"primaryKey;size:100"`
+	LockedBy       string     `json:"locked_by,omitempty" gorm:"size:100"` // Replica running the task
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`              // The lock is free after this time even if the replica died
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`               // Scheduled time of the last claimed run
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty" gorm:"type:text"`
+}
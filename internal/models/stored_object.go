@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// StoredObject is a file in a storage backend. RefCount is the number of
+// attachments, thumbnails, uploads and upload chunks with its key, kept by
+// database triggers; uploads of identical content share the file, which is
+// deleted once no row refers to it.
+type StoredObject struct {
+	ID        uint       `gorm:"primaryKey"`
+	Key       string     `gorm:"size:500;not null;uniqueIndex"`
+	Backend   string     `gorm:"size:20;not null"`
+	SHA256    *string    `gorm:"column:sha256;size:64"` // Hex SHA-256 of the content; unknown for files stored before hashing
+	Size      int64      `gorm:"not null;default:0"`
+	RefCount  int        `gorm:"not null;default:0"`
+	ClaimedAt *time.Time // Last reuse by an upload of the same content, which keeps the file until its rows are written
+	CreatedAt time.Time  `gorm:"not null"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Tombstone entity types
+const (
+	TombstoneDog     = "dog"
+	TombstoneEvent   = "event"
+	TombstoneComment = "comment"
+	TombstoneNote    = "note"
+)
+
+// Tombstone records a deleted record so that syncing clients can remove their copy.
+// Tombstones with a UserID are addressed to that user only; the others are visible
+// to everyone who can see the dog.
+type Tombstone struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	EntityType string    `json:"entity_type" gorm:"size:20;not null" example:"event"`
+	EntityID   uint      `json:"entity_id" gorm:"not null" example:"15"`
+	ClientID   *string   `json:"client_id,omitempty" gorm:"size:36" example:"6f1c2a9e-7b1d-4c55-9a3e-0d7f2b8c6e41"`
+	DogID      *uint     `json:"dog_id,omitempty" gorm:"index" example:"1"`
+	UserID     *uint     `json:"-" gorm:"index"`
+	DeletedAt  time.Time `json:"deleted_at" gorm:"not null;index" example:"2025-11-22T10:00:00Z"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Upload is a resumable (tus) upload. The bytes received by each PATCH are
+// stored as an UploadChunk; once Offset reaches Length the chunks are joined
+// into one file under Key. A completed upload waits to be attached to an
+// event, comment or note, which consumes it, or expires.
+type Upload struct {
+	ID          string        `json:"id" gorm:"primaryKey;size:36" example:"0b8f1e2a-3c4d-4e5f-8a9b-0c1d2e3f4a5b"`
+	UserID      uint          `json:"-" gorm:"not null;index"`
+	Filename    string        `json:"filename" gorm:"size:255;not null" example:"walk.mp4"`
+	ContentType string        `json:"content_type" gorm:"size:100;not null" example:"video/mp4"`
+	Length      int64         `json:"length" gorm:"column:upload_length;not null" example:"73400320"`          // Total size in bytes
+	Offset      int64         `json:"offset" gorm:"column:upload_offset;not null;default:0" example:"5242880"` // Bytes received
+	Backend     string        `json:"-" gorm:"size:20;not null"`                                               // FileStorage holding the chunks and the file
+	Key         string        `json:"-" gorm:"size:500"`                                                       // The joined file, set on completion
+	SHA256      string        `json:"-" gorm:"column:sha256;size:64"`                                          // Hex SHA-256, set on completion
+	Size        int64         `json:"-" gorm:"not null;default:0"`                                             // Bytes of the stored file, set on completion; images lose their metadata so it can differ from Length
+	Thumbnails  []Thumbnail   `json:"-" gorm:"foreignKey:UploadID;constraint:OnDelete:CASCADE"`                // Of an image, set on completion and moved to the attachment
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time     `json:"expires_at" gorm:"not null;index"`
+	Chunks      []UploadChunk `json:"-" gorm:"foreignKey:UploadID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// UploadChunk is the part of an upload received by one PATCH request
+type UploadChunk struct {
+	ID        uint   `gorm:"primaryKey"`
+	UploadID  string `gorm:"size:36;not null;index"`
+	Offset    int64  `gorm:"column:chunk_offset;not null"` // Position of the chunk in the file
+	Size      int64  `gorm:"not null"`
+	Key       string `gorm:"size:500;not null"`
+	CreatedAt time.Time
+}
+
+// Complete reports whether all bytes were received and joined
+func (u *Upload) Complete() bool {
+	return u.CompletedAt != nil
+}
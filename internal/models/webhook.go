@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// WebhookDeliveryStatus represents the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookEndpoint is a URL notified about activity visible to its user
+type WebhookEndpoint struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	User        *User  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	URL         string `json:"url" gorm:"size:2000;not null"`
+	Description string `json:"description" gorm:"size:255"`
+	Secret      string `json:"-" gorm:"size:64;not null"` // HMAC key for the X-Pawtrack-Signature header
+
+	// Subscribed types, comma-separated and wrapped in commas (",event.created,comment.created,")
+	// so that a single type can be matched with LIKE
+	EventTypes string `json:"-" gorm:"size:500;not null"`
+
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one notification sent (or to be sent) to an endpoint
+type WebhookDelivery struct {
+	ID           uint                  `json:"id" gorm:"primaryKey"`
+	EndpointID   uint                  `json:"endpoint_id" gorm:"not null;index"`
+	Endpoint     *WebhookEndpoint      `json:"-" gorm:"foreignKey:EndpointID;constraint:OnDelete:CASCADE"`
+	EventType    string                `json:"event_type" gorm:"size:50;not null"`
+	Payload      string                `json:"-" gorm:"type:text;not null"` // JSON body exactly as sent
+	Status       WebhookDeliveryStatus `json:"status" gorm:"size:20;not null;index"`
+	Attempts     int                   `json:"attempts" gorm:"not null;default:0"`
+	RedeliveryOf *uint                 `json:"redelivery_of,omitempty"`
+
+	// Pending deliveries are picked up once this time has passed
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
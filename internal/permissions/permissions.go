@@ -51,6 +51,13 @@ const (
 	USERS_UPDATE_OWN = "USERS_UPDATE_OWN"
 	USERS_UPDATE_ALL = "USERS_UPDATE_ALL"
 	USERS_DELETE_ALL = "USERS_DELETE_ALL"
+
+	// Webhook Permissions
+	WEBHOOKS_MANAGE_OWN = "WEBHOOKS_MANAGE_OWN"
+	WEBHOOKS_MANAGE_ALL = "WEBHOOKS_MANAGE_ALL"
+
+	// Background Job Permissions
+	JOBS_MANAGE = "JOBS_MANAGE"
 )
 
 // AllPermissions lists all available permissions in the system
@@ -93,6 +100,9 @@ var AllPermissions = []string{
 	USERS_UPDATE_OWN,
 	USERS_UPDATE_ALL,
 	USERS_DELETE_ALL,
+	WEBHOOKS_MANAGE_OWN,
+	WEBHOOKS_MANAGE_ALL,
+	JOBS_MANAGE,
 }
 
 // OwnerPermissions defines default permissions for owner role
@@ -112,6 +122,7 @@ var OwnerPermissions = []string{
 	CONSULTANTS_INVITE,
 	USERS_VIEW_OWN,
 	USERS_UPDATE_OWN,
+	WEBHOOKS_MANAGE_OWN,
 }
 
 // ConsultantBasePermissions defines base permissions for consultant role (before invitation)
@@ -121,6 +132,7 @@ var ConsultantBasePermissions = []string{
 	CONSULTANTS_SEARCH,
 	USERS_VIEW_OWN,
 	USERS_UPDATE_OWN,
+	WEBHOOKS_MANAGE_OWN,
 }
 
 // ConsultantAssignedPermissions defines additional permissions granted when consultant accepts invitation
@@ -178,4 +190,7 @@ var AdminPermissions = []string{
 	USERS_UPDATE_OWN,
 	USERS_UPDATE_ALL,
 	USERS_DELETE_ALL,
+	WEBHOOKS_MANAGE_OWN,
+	WEBHOOKS_MANAGE_ALL,
+	JOBS_MANAGE,
 }
@@ -0,0 +1,202 @@
+// Package realtime fans out changes of a dog's records to connected clients.
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/you/pawtrack/internal/events"
+	"github.com/you/pawtrack/internal/models"
+)
+
+// subscriberBuffer is the number of undelivered messages a subscriber may lag behind
+// before it is disconnected; the client then resumes with Last-Event-ID
+const subscriberBuffer = 64
+
+// Message is a change published to the subscribers of a dog
+type Message struct {
+	ID    uint64      `json:"id"` // Increases across all dogs, used as SSE id / Last-Event-ID
+	DogID uint        `json:"dog_id"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	At    time.Time   `json:"at"`
+
+	// Private limits delivery to this user (and admins), e.g. for consultant notes
+	Private *uint `json:"-"`
+}
+
+// VisibleTo reports whether the message may be delivered to the user
+func (m *Message) VisibleTo(userID uint, role models.UserRole) bool {
+	return m.Private == nil || role == models.RoleAdmin || *m.Private == userID
+}
+
+// Hub is an in-process pub/sub keeping the latest messages of each dog for resumption
+type Hub struct {
+	mu         sync.Mutex
+	seq        uint64
+	bufferSize int
+	topics     map[uint]*topic
+	closed     bool
+}
+
+// topic holds the ring buffer and subscribers of one dog
+type topic struct {
+	buffer  []Message // ring buffer, oldest message at next when full
+	next    int
+	full    bool
+	evicted uint64 // ID of the newest message pushed out of the buffer
+	subs    map[*Subscription]struct{}
+}
+
+// Subscription receives messages of one dog. C is closed when the subscriber
+// falls behind or the hub shuts down.
+type Subscription struct {
+	C     <-chan Message
+	ch    chan Message
+	hub   *Hub
+	dogID uint
+}
+
+// NewHub creates a hub keeping up to bufferSize messages per dog
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+	return &Hub{
+		bufferSize: bufferSize,
+		topics:     make(map[uint]*topic),
+	}
+}
+
+// HandleEvent publishes a domain event to the subscribers of its dog, for use as a bus subscriber
+func (h *Hub) HandleEvent(event events.Event) {
+	h.Publish(event.DogID(), event.Type(), event.Payload(), event.Recipient())
+}
+
+// Publish stores the message in the dog's buffer and delivers it to subscribers
+func (h *Hub) Publish(dogID uint, msgType string, data interface{}, private *uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.seq++
+	msg := Message{
+		ID:      h.seq,
+		DogID:   dogID,
+		Type:    msgType,
+		Data:    data,
+		At:      time.Now().UTC(),
+		Private: private,
+	}
+
+	t := h.topic(dogID)
+	if len(t.buffer) < h.bufferSize {
+		t.buffer = append(t.buffer, msg)
+	} else {
+		t.evicted = t.buffer[t.next].ID
+		t.buffer[t.next] = msg
+		t.next = (t.next + 1) % h.bufferSize
+		t.full = true
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.ch <- msg:
+		default:
+			// Never block publishers on a slow client
+			h.remove(t, sub)
+		}
+	}
+}
+
+// Subscribe starts receiving messages of a dog. Messages after lastEventID still in
+// the buffer are returned as backlog; complete is false if some were already dropped
+// and the client should reload its state. A zero lastEventID means no resumption.
+func (h *Hub) Subscribe(dogID uint, lastEventID uint64) (sub *Subscription, backlog []Message, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Message, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, hub: h, dogID: dogID}
+	if h.closed {
+		close(ch)
+		return sub, nil, false
+	}
+
+	t := h.topic(dogID)
+	t.subs[sub] = struct{}{}
+
+	complete = true
+	if lastEventID == 0 {
+		return sub, nil, complete
+	}
+	if lastEventID > h.seq {
+		// The ID comes from before a restart, sequence numbers started over
+		return sub, nil, false
+	}
+
+	if t.evicted > lastEventID {
+		// Some messages the client has not seen are no longer buffered
+		complete = false
+	}
+	for _, msg := range t.ordered() {
+		if msg.ID > lastEventID {
+			backlog = append(backlog, msg)
+		}
+	}
+	return sub, backlog, complete
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if t, ok := s.hub.topics[s.dogID]; ok {
+		if _, subscribed := t.subs[s]; subscribed {
+			s.hub.remove(t, s)
+		}
+	}
+}
+
+// Close disconnects all subscribers, used on shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, t := range h.topics {
+		for sub := range t.subs {
+			h.remove(t, sub)
+		}
+	}
+}
+
+// topic returns the dog's topic, creating it if needed; h.mu must be held
+func (h *Hub) topic(dogID uint) *topic {
+	t, ok := h.topics[dogID]
+	if !ok {
+		t = &topic{subs: make(map[*Subscription]struct{})}
+		h.topics[dogID] = t
+	}
+	return t
+}
+
+// remove unsubscribes and closes the channel; h.mu must be held
+func (h *Hub) remove(t *topic, sub *Subscription) {
+	delete(t.subs, sub)
+	close(sub.ch)
+}
+
+// ordered returns buffered messages oldest first
+func (t *topic) ordered() []Message {
+	if !t.full {
+		return t.buffer
+	}
+	out := make([]Message, 0, len(t.buffer))
+	out = append(out, t.buffer[t.next:]...)
+	return append(out, t.buffer[:t.next]...)
+}
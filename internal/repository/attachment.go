@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// AttachmentRepository interface for attachment data access
+type AttachmentRepository interface {
+	GetByID(id uint) (*models.Attachment, error)
+	Create(attachments []models.Attachment) error
+	Delete(attachment *models.Attachment) error
+	SetScanResult(attachment *models.Attachment) error
+
+	// ListUnqueued returns quarantined attachments created before the given
+	// time that no job of jobType refers to, oldest first
+	ListUnqueued(jobType string, createdBefore time.Time, limit int) ([]models.Attachment, error)
+}
+
+// attachmentRepository implementation of the attachment repository
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// GetByID returns an attachment by ID, with its thumbnails
+func (r *attachmentRepository) GetByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Preload("Thumbnails", thumbnailsInOrder).First(&attachment, id).Error
+	return &attachment, err
+}
+
+// Create adds attachments to existing records. The owners' updated_at is bumped
+// so that syncing clients pick up the change. Completed resumable uploads the
+// files come from are consumed in the same transaction, so an upload is
+// attached once; gorm.ErrRecordNotFound is returned if one is gone. Their
+// thumbnails move to the attachments.
+func (r *attachmentRepository) Create(attachments []models.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	// Thumbnails of uploads exist already, the others are inserted with the attachments
+	uploadThumbnails := make([][]models.Thumbnail, len(attachments))
+	for i := range attachments {
+		if attachments[i].UploadID != "" {
+			uploadThumbnails[i], attachments[i].Thumbnails = attachments[i].Thumbnails, nil
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attachments).Error; err != nil {
+			return err
+		}
+		for _, attachment := range attachments {
+			if attachment.UploadID == "" {
+				continue
+			}
+			err := tx.Model(&models.Thumbnail{}).Where("upload_id = ?", attachment.UploadID).
+				Updates(map[string]interface{}{"attachment_id": attachment.ID, "upload_id": nil}).Error
+			if err != nil {
+				return err
+			}
+			result := tx.Where("id = ? AND completed_at IS NOT NULL", attachment.UploadID).Delete(&models.Upload{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		for i := range attachments {
+			if err := touchAttachmentOwner(tx, &attachments[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for i, thumbnails := range uploadThumbnails {
+		if attachments[i].UploadID == "" {
+			continue
+		}
+		attachments[i].Thumbnails = thumbnails
+		if err == nil {
+			for j := range thumbnails {
+				thumbnails[j].AttachmentID, thumbnails[j].UploadID = &attachments[i].ID, nil
+				thumbnails[j].URL = thumbnails[j].Path()
+			}
+		}
+	}
+	return err
+}
+
+// Delete removes an attachment and bumps its owner's updated_at
+func (r *attachmentRepository) Delete(attachment *models.Attachment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attachment_id = ?", attachment.ID).Delete(&models.Thumbnail{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Attachment{}, attachment.ID).Error; err != nil {
+			return err
+		}
+		return touchAttachmentOwner(tx, attachment)
+	})
+}
+
+// SetScanResult records the scan status, threat and time of an attachment and
+// bumps its owner's updated_at, so that syncing clients see the file become
+// available
+func (r *attachmentRepository) SetScanResult(attachment *models.Attachment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Attachment{}).Where("id = ?", attachment.ID).Updates(map[string]interface{}{
+			"scan_status": attachment.ScanStatus,
+			"scan_threat": attachment.ScanThreat,
+			"scanned_at":  attachment.ScannedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return touchAttachmentOwner(tx, attachment)
+	})
+}
+
+// ListUnqueued returns quarantined attachments without a job. Jobs are matched
+// by their payload, the JSON {"attachment_id":<id>} the scan service queues.
+func (r *attachmentRepository) ListUnqueued(jobType string, createdBefore time.Time, limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.
+		Where("scan_status = ? AND created_at < ?", models.ScanQuarantine, createdBefore).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM jobs
+			WHERE jobs.type = ? AND jobs.payload = '{"attachment_id":' || attachments.id || '}'
+		)`, jobType).
+		Order("id").
+		Limit(limit).
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// touchAttachmentOwner updates updated_at of the record owning the attachment
+func touchAttachmentOwner(tx *gorm.DB, attachment *models.Attachment) error {
+	var owner interface{}
+	var id uint
+	switch {
+	case attachment.EventID != nil:
+		owner, id = &models.Event{}, *attachment.EventID
+	case attachment.CommentID != nil:
+		owner, id = &models.EventComment{}, *attachment.CommentID
+	case attachment.NoteID != nil:
+		owner, id = &models.ConsultantNote{}, *attachment.NoteID
+	default:
+		return nil
+	}
+	return tx.Model(owner).Where("id = ?", id).UpdateColumn("updated_at", time.Now()).Error
+}
+
+// attachmentsInOrder sorts preloaded attachments in upload order and loads their thumbnails
+func attachmentsInOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("attachments.id").Preload("Thumbnails", thumbnailsInOrder)
+}
+
+// thumbnailsInOrder sorts preloaded thumbnails from the smallest
+func thumbnailsInOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("thumbnails.id")
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
 	"github.com/you/pawtrack/internal/dto"
 	"github.com/you/pawtrack/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ConsultantRepository interface {
@@ -12,19 +16,24 @@ type ConsultantRepository interface {
 	Search(criteria *dto.ConsultantSearchRequest) ([]models.ConsultantProfile, int64, error)
 	CreateInvite(invite *models.Invite) error
 	GetInviteByToken(token string) (*models.Invite, error)
-	UpdateInviteStatus(invite *models.Invite) error
+	// UpdateInviteStatus saves the invite, running the optional hook in its transaction
+	UpdateInviteStatus(invite *models.Invite, hook TxHook) error
+	// ExpireInvites marks pending invites past their expiry as expired and returns how many
+	ExpireInvites(now time.Time) (int64, error)
 }
 
 type consultantRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	dialect dialect.Dialect
 }
 
 func NewConsultantRepository(db *gorm.DB) ConsultantRepository {
-	return &consultantRepository{db: db}
+	return &consultantRepository{db: db, dialect: dialect.Of(db)}
 }
 
+// UpdateProfile creates the profile or replaces the existing one
 func (r *consultantRepository) UpdateProfile(profile *models.ConsultantProfile) error {
-	return r.db.Save(profile).Error
+	return r.db.Omit(clause.Associations).Clauses(dialect.Upsert([]string{"user_id"})).Create(profile).Error
 }
 
 func (r *consultantRepository) GetProfile(userID uint) (*models.ConsultantProfile, error) {
@@ -44,19 +53,19 @@ func (r *consultantRepository) Search(criteria *dto.ConsultantSearchRequest) ([]
 
 	if criteria.Query != "" {
 		search := "%" + criteria.Query + "%"
-		query = query.Where("users.name ILIKE ? OR consultant_profiles.surname ILIKE ? OR consultant_profiles.description ILIKE ?", search, search, search)
+		query = query.Where(r.dialect.ILike("users.name")+" OR "+r.dialect.ILike("consultant_profiles.surname")+" OR "+r.dialect.ILike("consultant_profiles.description"), search, search, search)
 	}
 
 	if criteria.Services != "" {
-		query = query.Where("consultant_profiles.services ILIKE ?", "%"+criteria.Services+"%")
+		query = query.Where(r.dialect.ILike("consultant_profiles.services"), "%"+criteria.Services+"%")
 	}
 
 	if criteria.Breeds != "" {
-		query = query.Where("consultant_profiles.breeds ILIKE ?", "%"+criteria.Breeds+"%")
+		query = query.Where(r.dialect.ILike("consultant_profiles.breeds"), "%"+criteria.Breeds+"%")
 	}
 
 	if criteria.Location != "" {
-		query = query.Where("consultant_profiles.location ILIKE ?", "%"+criteria.Location+"%")
+		query = query.Where(r.dialect.ILike("consultant_profiles.location"), "%"+criteria.Location+"%")
 	}
 
 	query.Count(&totalCount)
@@ -77,6 +86,15 @@ func (r *consultantRepository) GetInviteByToken(token string) (*models.Invite, e
 	return &invite, err
 }
 
-func (r *consultantRepository) UpdateInviteStatus(invite *models.Invite) error {
-	return r.db.Save(invite).Error
+func (r *consultantRepository) UpdateInviteStatus(invite *models.Invite, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		return tx.Save(invite).Error
+	})
+}
+
+func (r *consultantRepository) ExpireInvites(now time.Time) (int64, error) {
+	result := r.db.Model(&models.Invite{}).
+		Where("status = ? AND expires_at < ?", models.InvitePending, now).
+		Update("status", models.InviteExpired)
+	return result.RowsAffected, result.Error
 }
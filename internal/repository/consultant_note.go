@@ -1,45 +1,70 @@
 package repository
 
 import (
+	"strings"
 	"time"
 
 	"github.com/you/pawtrack/internal/dto"
 	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/search"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Writes run the optional hook in their transaction, see TxHook.
 type ConsultantNoteRepository interface {
-	Create(note *models.ConsultantNote) error
+	Create(note *models.ConsultantNote, hook TxHook) error
 	GetByID(id uint) (*models.ConsultantNote, error)
-	Update(note *models.ConsultantNote) error
-	Delete(id uint) error
+	Update(note *models.ConsultantNote, hook TxHook) error
+	Delete(id uint, hook TxHook) error
 	List(filters *dto.NoteFilterParams, consultantID uint, isAdmin bool) ([]models.ConsultantNote, int64, error)
+	// WithTx returns the repository working in the transaction tx, for hooks
+	WithTx(tx *gorm.DB) ConsultantNoteRepository
 }
 
 type consultantNoteRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search search.Engine
 }
 
-func NewConsultantNoteRepository(db *gorm.DB) ConsultantNoteRepository {
-	return &consultantNoteRepository{db: db}
+func NewConsultantNoteRepository(db *gorm.DB, searchEngine search.Engine) ConsultantNoteRepository {
+	return &consultantNoteRepository{db: db, search: searchEngine}
 }
 
-func (r *consultantNoteRepository) Create(note *models.ConsultantNote) error {
-	return r.db.Create(note).Error
+func (r *consultantNoteRepository) Create(note *models.ConsultantNote, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		return tx.Create(note).Error
+	})
 }
 
 func (r *consultantNoteRepository) GetByID(id uint) (*models.ConsultantNote, error) {
 	var note models.ConsultantNote
-	err := r.db.Preload("Dog.Owner").Preload("Consultant").First(&note, id).Error
+	err := r.db.Preload("Dog.Owner").Preload("Consultant").Preload("Attachments", attachmentsInOrder).First(&note, id).Error
 	return &note, err
 }
 
-func (r *consultantNoteRepository) Update(note *models.ConsultantNote) error {
-	return r.db.Save(note).Error
+func (r *consultantNoteRepository) Update(note *models.ConsultantNote, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		// Attachments are added and removed through AttachmentRepository
+		return tx.Omit("Attachments").Save(note).Error
+	})
 }
 
-func (r *consultantNoteRepository) Delete(id uint) error {
-	return r.db.Delete(&models.ConsultantNote{}, id).Error
+func (r *consultantNoteRepository) Delete(id uint, hook TxHook) error {
+	return deleteWithTombstones(r.db, id, hook, func(tx *gorm.DB, note *models.ConsultantNote) ([]models.Tombstone, error) {
+		// Notes are private to their author
+		return []models.Tombstone{{
+			EntityType: models.TombstoneNote,
+			EntityID:   note.ID,
+			ClientID:   note.ClientID,
+			DogID:      &note.DogID,
+			UserID:     &note.ConsultantID,
+		}}, nil
+	})
+}
+
+func (r *consultantNoteRepository) WithTx(tx *gorm.DB) ConsultantNoteRepository {
+	return &consultantNoteRepository{db: tx, search: r.search}
 }
 
 func (r *consultantNoteRepository) List(filters *dto.NoteFilterParams, consultantID uint, isAdmin bool) ([]models.ConsultantNote, int64, error) {
@@ -50,17 +75,18 @@ func (r *consultantNoteRepository) List(filters *dto.NoteFilterParams, consultan
 		Joins("LEFT JOIN dogs ON dogs.id = consultant_notes.dog_id").
 		Joins("LEFT JOIN users ON users.id = dogs.owner_id").
 		Preload("Dog.Owner").
-		Preload("Consultant")
+		Preload("Consultant").
+		Preload("Attachments", attachmentsInOrder)
 
 	// RBAC: Consultants see only their notes, admins see all
 	if !isAdmin {
 		query = query.Where("consultant_notes.consultant_id = ?", consultantID)
 	}
 
-	// Search filter
-	if filters.Search != "" {
-		search := "%" + filters.Search + "%"
-		query = query.Where("consultant_notes.title ILIKE ? OR consultant_notes.content ILIKE ?", search, search)
+	// Full-text search in title and content
+	terms := strings.TrimSpace(filters.Search)
+	if terms != "" {
+		query = r.search.Match(query, search.Notes, terms)
 	}
 
 	// Dog filter
@@ -107,11 +133,35 @@ func (r *consultantNoteRepository) List(filters *dto.NoteFilterParams, consultan
 		order = "ASC"
 	}
 
-	query = query.Order(sortField + " " + order)
+	// Search results are ordered by relevance unless a sort field is requested
+	rank := clause.Expression(nil)
+	if terms != "" && filters.SortBy == "" {
+		rank = r.search.Rank(search.Notes, terms)
+	}
+	if rank != nil {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: "?, consultant_notes.id DESC", Vars: []interface{}{rank}}})
+	} else {
+		query = query.Order(sortField + " " + order)
+	}
 
 	// Pagination
 	offset := (filters.Page - 1) * filters.PageSize
-	err := query.Offset(offset).Limit(filters.PageSize).Find(&notes).Error
+	if err := query.Offset(offset).Limit(filters.PageSize).Find(&notes).Error; err != nil {
+		return nil, 0, err
+	}
 
-	return notes, totalCount, err
+	if terms != "" && len(notes) > 0 {
+		ids := make([]uint, len(notes))
+		for i := range notes {
+			ids[i] = notes[i].ID
+		}
+		highlights, err := r.search.Highlight(search.Notes, terms, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range notes {
+			notes[i].Highlight = highlights[notes[i].ID]
+		}
+	}
+	return notes, totalCount, nil
 }
@@ -57,7 +57,29 @@ func (r *dogRepository) Update(dog *models.Dog) error {
 
 // Delete deletes a dog
 func (r *dogRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Dog{}, id).Error
+	return deleteWithTombstones(r.db, id, nil, func(tx *gorm.DB, dog *models.Dog) ([]models.Tombstone, error) {
+		// Consultant access rows go away with the dog, so address the tombstone
+		// to the owner and every consultant who could see the dog
+		var consultantIDs []uint
+		err := tx.Table("consultant_access").
+			Where("dog_id = ? AND revoked_at IS NULL", dog.ID).
+			Pluck("consultant_id", &consultantIDs).Error
+		if err != nil {
+			return nil, err
+		}
+
+		recipients := append([]uint{dog.OwnerID}, consultantIDs...)
+		tombstones := make([]models.Tombstone, 0, len(recipients))
+		for i := range recipients {
+			tombstones = append(tombstones, models.Tombstone{
+				EntityType: models.TombstoneDog,
+				EntityID:   dog.ID,
+				DogID:      &dog.ID,
+				UserID:     &recipients[i],
+			})
+		}
+		return tombstones, nil
+	})
 }
 
 // HasConsultantAccess checks if a consultant has access to a dog
@@ -74,7 +96,7 @@ func (r *dogRepository) GrantConsultantAccess(consultantID, dogID uint) error {
 	access := models.ConsultantAccess{
 		ConsultantID: consultantID,
 		DogID:        dogID,
-		GrantedAt:    time.Now(),
+		GrantedAt:    time.Now().UTC(),
 	}
 	return r.db.Create(&access).Error
 }
@@ -3,31 +3,48 @@ package repository
 import (
 	"github.com/you/pawtrack/internal/dto"
 	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/search"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strings"
 )
 
 // EventRepository interface for event data access
+// Writes run the optional hook in their transaction, see TxHook.
 type EventRepository interface {
-	Create(event *models.Event) error
+	Create(event *models.Event, hook TxHook) error
+	CreateBatch(events []*models.Event, hook TxHook) error
 	List(filters *dto.EventFilterParams) ([]models.Event, int64, error)
 	GetByID(id uint) (*models.Event, error)
-	Delete(id uint) error
+	Delete(id uint, hook TxHook) error
 }
 
 // eventRepository implementation of the event repository
 type eventRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search search.Engine
 }
 
 // NewEventRepository creates a new event repository
-func NewEventRepository(db *gorm.DB) EventRepository {
-	return &eventRepository{db: db}
+func NewEventRepository(db *gorm.DB, searchEngine search.Engine) EventRepository {
+	return &eventRepository{db: db, search: searchEngine}
 }
 
-// Create creates a new event
-func (r *eventRepository) Create(event *models.Event) error {
-	return r.db.Create(event).Error
+// Create creates a new event along with its attachment, if any
+func (r *eventRepository) Create(event *models.Event, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		return tx.Create(event).Error
+	})
+}
+
+// CreateBatch inserts events in batches inside a single transaction
+func (r *eventRepository) CreateBatch(events []*models.Event, hook TxHook) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		return tx.CreateInBatches(events, 50).Error
+	})
 }
 
 // List returns a list of events based on filters
@@ -37,8 +54,8 @@ func (r *eventRepository) List(filters *dto.EventFilterParams) ([]models.Event,
 
 	query := r.db.Model(&models.Event{})
 
-	// Preload dog relationship
-	query = query.Preload("Dog")
+	// Preload dog and attachment relationships
+	query = query.Preload("Dog").Preload("Attachments", attachmentsInOrder)
 
 	// Role-based data access control
 	switch filters.UserRole {
@@ -67,6 +84,17 @@ func (r *eventRepository) List(filters *dto.EventFilterParams) ([]models.Event,
 	if filters.ToDate != nil {
 		query = query.Where("events.at <= ?", filters.ToDate)
 	}
+	if filters.From != nil {
+		query = query.Where("events.at >= ?", filters.From.UTC())
+	}
+	if filters.To != nil {
+		query = query.Where("events.at <= ?", filters.To.UTC())
+	}
+
+	// Dog filter
+	if filters.DogID != nil {
+		query = query.Where("events.dog_id = ?", *filters.DogID)
+	}
 
 	// Event types
 	if filters.Types != "" {
@@ -74,22 +102,11 @@ func (r *eventRepository) List(filters *dto.EventFilterParams) ([]models.Event,
 		query = query.Where("events.type IN ?", types)
 	}
 
-	// Text search (notes or dog name)
-	if filters.Search != "" {
-		searchTerm := "%" + filters.Search + "%"
-		// We need to join dogs if not already joined for owner role
-		// But GORM handles duplicate joins smartly usually, or we can check
-		// For simplicity, let's use a subquery or left join if needed
-		// Since we might have already joined dogs for Owner role, we should be careful.
-		// However, for search we need dog name.
-		// Let's use LEFT JOIN for dogs if not owner (Owner already has INNER JOIN)
-		if filters.UserRole != models.RoleOwner {
-			query = query.Joins("LEFT JOIN dogs d_search ON events.dog_id = d_search.id")
-			query = query.Where("events.note LIKE ? OR d_search.name LIKE ?", searchTerm, searchTerm)
-		} else {
-			// Already joined as 'dogs'
-			query = query.Where("events.note LIKE ? OR dogs.name LIKE ?", searchTerm, searchTerm)
-		}
+	// Full-text search in notes, or the dog's name
+	terms := strings.TrimSpace(filters.Search)
+	if terms != "" {
+		dogName := clause.Expr{SQL: "events.dog_id IN (SELECT id FROM dogs WHERE ?)", Vars: []interface{}{search.Contains("name", terms)}}
+		query = r.search.Match(query, search.Events, terms, dogName)
 	}
 
 	// Dog name exact match
@@ -119,20 +136,48 @@ func (r *eventRepository) List(filters *dto.EventFilterParams) ([]models.Event,
 	if filters.SortOrder != "" {
 		sortOrder = filters.SortOrder
 	}
-	query = query.Order(sortField + " " + sortOrder)
+	// Search results are ordered by relevance unless a sort field is requested
+	rank := clause.Expression(nil)
+	if terms != "" && filters.SortBy == "" {
+		rank = r.search.Rank(search.Events, terms)
+	}
+	if rank != nil {
+		// Tie-breaker keeps pagination stable when ranks collide
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{SQL: "?, events.id DESC", Vars: []interface{}{rank}}})
+	} else {
+		query = query.Order(sortField + " " + sortOrder)
+		// Tie-breaker keeps pagination stable when sort values collide
+		query = query.Order("events.id " + sortOrder)
+	}
 
 	// Pagination
 	offset := (filters.Page - 1) * filters.PageSize
 	query = query.Offset(offset).Limit(filters.PageSize)
 
-	err := query.Find(&events).Error
-	return events, totalCount, err
+	if err := query.Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if terms != "" && len(events) > 0 {
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		highlights, err := r.search.Highlight(search.Events, terms, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range events {
+			events[i].Highlight = highlights[events[i].ID]
+		}
+	}
+	return events, totalCount, nil
 }
 
 // GetByID returns an event by ID
 func (r *eventRepository) GetByID(id uint) (*models.Event, error) {
 	var event models.Event
-	err := r.db.First(&event, id).Error
+	err := r.db.Preload("Attachments", attachmentsInOrder).First(&event, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +185,13 @@ func (r *eventRepository) GetByID(id uint) (*models.Event, error) {
 }
 
 // Delete deletes an event
-func (r *eventRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Event{}, id).Error
+func (r *eventRepository) Delete(id uint, hook TxHook) error {
+	return deleteWithTombstones(r.db, id, hook, func(tx *gorm.DB, event *models.Event) ([]models.Tombstone, error) {
+		return []models.Tombstone{{
+			EntityType: models.TombstoneEvent,
+			EntityID:   event.ID,
+			ClientID:   event.ClientID,
+			DogID:      event.DogID,
+		}}, nil
+	})
 }
@@ -5,12 +5,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// Writes run the optional hook in their transaction, see TxHook.
 type EventCommentRepository interface {
-	Create(comment *models.EventComment) error
+	Create(comment *models.EventComment, hook TxHook) error
 	GetByID(id uint) (*models.EventComment, error)
-	Update(comment *models.EventComment) error
-	Delete(id uint) error
+	Update(comment *models.EventComment, hook TxHook) error
+	Delete(id uint, hook TxHook) error
 	ListByEvent(eventID uint) ([]models.EventComment, error)
+	// WithTx returns the repository working in the transaction tx, for hooks
+	WithTx(tx *gorm.DB) EventCommentRepository
 }
 
 type eventCommentRepository struct {
@@ -21,28 +24,49 @@ func NewEventCommentRepository(db *gorm.DB) EventCommentRepository {
 	return &eventCommentRepository{db: db}
 }
 
-func (r *eventCommentRepository) Create(comment *models.EventComment) error {
-	return r.db.Create(comment).Error
+func (r *eventCommentRepository) Create(comment *models.EventComment, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		return tx.Create(comment).Error
+	})
 }
 
 func (r *eventCommentRepository) GetByID(id uint) (*models.EventComment, error) {
 	var comment models.EventComment
-	err := r.db.Preload("User").Preload("Event.Dog").First(&comment, id).Error
+	err := r.db.Preload("User").Preload("Event.Dog").Preload("Attachments", attachmentsInOrder).First(&comment, id).Error
 	return &comment, err
 }
 
-func (r *eventCommentRepository) Update(comment *models.EventComment) error {
-	return r.db.Save(comment).Error
+func (r *eventCommentRepository) Update(comment *models.EventComment, hook TxHook) error {
+	return transact(r.db, hook, func(tx *gorm.DB) error {
+		// Attachments are added and removed through AttachmentRepository
+		return tx.Omit("Attachments").Save(comment).Error
+	})
 }
 
-func (r *eventCommentRepository) Delete(id uint) error {
-	return r.db.Delete(&models.EventComment{}, id).Error
+func (r *eventCommentRepository) Delete(id uint, hook TxHook) error {
+	return deleteWithTombstones(r.db, id, hook, func(tx *gorm.DB, comment *models.EventComment) ([]models.Tombstone, error) {
+		var event models.Event
+		if err := tx.Select("id", "dog_id").First(&event, comment.EventID).Error; err != nil {
+			return nil, err
+		}
+		return []models.Tombstone{{
+			EntityType: models.TombstoneComment,
+			EntityID:   comment.ID,
+			ClientID:   comment.ClientID,
+			DogID:      event.DogID,
+		}}, nil
+	})
+}
+
+func (r *eventCommentRepository) WithTx(tx *gorm.DB) EventCommentRepository {
+	return &eventCommentRepository{db: tx}
 }
 
 func (r *eventCommentRepository) ListByEvent(eventID uint) ([]models.EventComment, error) {
 	var comments []models.EventComment
 	err := r.db.Where("event_id = ?", eventID).
 		Preload("User").
+		Preload("Attachments", attachmentsInOrder).
 		Order("created_at ASC").
 		Find(&comments).Error
 	return comments, err
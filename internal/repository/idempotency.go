@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// IdempotencyRepository interface for stored idempotent responses
+type IdempotencyRepository interface {
+	// Get returns the record for a user's key
+	Get(userID uint, key string) (*models.IdempotencyKey, error)
+
+	// Reserve inserts a new in-flight record; returns false if the key already exists
+	Reserve(record *models.IdempotencyKey) (bool, error)
+
+	// Complete stores the response of the original request, kept until expiresAt
+	Complete(id uint, statusCode int, body []byte, contentType string, expiresAt time.Time) error
+
+	// Delete removes a record so the key can be used again
+	Delete(id uint) error
+
+	// DeleteIfExpired removes a record only if it is still expired at now, so a
+	// record completed in the meantime is kept
+	DeleteIfExpired(id uint, now time.Time) error
+
+	// DeleteExpired removes records expired before now and returns how many
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// idempotencyRepository implementation of the idempotency repository
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Get returns the record for a user's key
+func (r *idempotencyRepository) Get(userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Reserve inserts a new in-flight record relying on the (user_id, key) unique index,
+// so concurrent requests with the same key cannot both proceed
+func (r *idempotencyRepository) Reserve(record *models.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(dialect.InsertIgnore("user_id", "key")).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Complete stores the response of the original request
+func (r *idempotencyRepository) Complete(id uint, statusCode int, body []byte, contentType string, expiresAt time.Time) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": body,
+		"content_type":  contentType,
+		"expires_at":    expiresAt,
+	}).Error
+}
+
+// Delete removes a record so the key can be used again
+func (r *idempotencyRepository) Delete(id uint) error {
+	return r.db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteIfExpired removes a record only if it is still expired at now
+func (r *idempotencyRepository) DeleteIfExpired(id uint, now time.Time) error {
+	return r.db.Where("id = ? AND expires_at < ?", id, now).Delete(&models.IdempotencyKey{}).Error
+}
+
+// DeleteExpired removes records expired before now
+func (r *idempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// JobFilter selects jobs for listing
+type JobFilter struct {
+	Status string
+	Type   string
+	Offset int
+	Limit  int
+}
+
+// JobRepository interface for the background job queue
+type JobRepository interface {
+	Create(job *models.Job) error
+	GetByID(id uint) (*models.Job, error)
+	List(filter *JobFilter) ([]models.Job, int64, error)
+
+	// Claim locks the next runnable job for the worker until lockedUntil. Runnable
+	// are due pending jobs and running jobs whose lock expired (crashed worker).
+	// Returns nil if there is nothing to run.
+	Claim(worker string, now, lockedUntil time.Time) (*models.Job, error)
+
+	// Complete marks a job claimed by the worker as succeeded
+	Complete(id uint, worker string) error
+	// Reschedule releases a job claimed by the worker to run again at runAt
+	Reschedule(id uint, worker string, runAt time.Time, lastError string) error
+	// Bury marks a job claimed by the worker as dead
+	Bury(id uint, worker string, lastError string) error
+
+	// Retry makes a dead job pending again with fresh attempts; returns false if the job is not dead
+	Retry(id uint, now time.Time) (bool, error)
+
+	// DeleteSucceeded removes jobs that succeeded before the given time and returns how many
+	DeleteSucceeded(before time.Time) (int64, error)
+}
+
+// jobRepository implementation of the job repository
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// Create enqueues a job
+func (r *jobRepository) Create(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+// GetByID returns a job by ID
+func (r *jobRepository) GetByID(id uint) (*models.Job, error) {
+	var job models.Job
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns a page of jobs, newest first
+func (r *jobRepository) List(filter *JobFilter) ([]models.Job, int64, error) {
+	tx := r.db.Model(&models.Job{})
+	if filter.Status != "" {
+		tx = tx.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		tx = tx.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []models.Job
+	err := tx.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&jobs).Error
+	return jobs, total, err
+}
+
+// claimCandidates is how many runnable jobs are looked at per claim, so that
+// workers racing for the same job can fall back to the next one
+const claimCandidates = 5
+
+// Claim takes a job with a conditional update: the row is only taken if its status
+// and attempts are unchanged since it was read, which works the same on SQLite
+// and Postgres without row locks
+func (r *jobRepository) Claim(worker string, now, lockedUntil time.Time) (*models.Job, error) {
+	var candidates []models.Job
+	err := r.db.Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+		models.JobPending, now, models.JobRunning, now).
+		Order("run_at, id").
+		Limit(claimCandidates).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range candidates {
+		job := &candidates[i]
+		result := r.db.Model(&models.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]interface{}{
+				"status":       models.JobRunning,
+				"attempts":     job.Attempts + 1,
+				"locked_by":    worker,
+				"locked_until": lockedUntil,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.LockedBy = worker
+			job.LockedUntil = &lockedUntil
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Complete marks a job claimed by the worker as succeeded
+func (r *jobRepository) Complete(id uint, worker string) error {
+	now := time.Now().UTC()
+	return r.finish(id, worker, map[string]interface{}{
+		"status":       models.JobSucceeded,
+		"locked_by":    "",
+		"locked_until": nil,
+		"finished_at":  now,
+	})
+}
+
+// Reschedule releases a job claimed by the worker to run again at runAt
+func (r *jobRepository) Reschedule(id uint, worker string, runAt time.Time, lastError string) error {
+	return r.finish(id, worker, map[string]interface{}{
+		"status":       models.JobPending,
+		"run_at":       runAt,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   lastError,
+	})
+}
+
+// Bury marks a job claimed by the worker as dead
+func (r *jobRepository) Bury(id uint, worker string, lastError string) error {
+	now := time.Now().UTC()
+	return r.finish(id, worker, map[string]interface{}{
+		"status":       models.JobDead,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   lastError,
+		"finished_at":  now,
+	})
+}
+
+// finish updates a running job only while the worker still holds it, so a worker
+// whose lock expired cannot overwrite the outcome of the one that reclaimed the job
+func (r *jobRepository) finish(id uint, worker string, updates map[string]interface{}) error {
+	return r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, models.JobRunning, worker).
+		Updates(updates).Error
+}
+
+// Retry makes a dead job pending again with fresh attempts
+func (r *jobRepository) Retry(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, models.JobDead).
+		Updates(map[string]interface{}{
+			"status":      models.JobPending,
+			"attempts":    0,
+			"run_at":      now,
+			"finished_at": nil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteSucceeded removes jobs that succeeded before the given time; dead jobs are
+// kept for inspection
+func (r *jobRepository) DeleteSucceeded(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND finished_at < ?", models.JobSucceeded, before).Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// OutboxRepository interface for stored domain events awaiting dispatch
+type OutboxRepository interface {
+	// Append stores an event in the transaction tx of the change it belongs to
+	Append(tx *gorm.DB, event *models.OutboxEvent) error
+	// Claim takes up to limit of the oldest events no other relay holds, in
+	// insertion order, for the relay until lockedUntil
+	Claim(relay string, limit int, now, lockedUntil time.Time) ([]models.OutboxEvent, error)
+	// Remove deletes dispatched events claimed by the relay
+	Remove(relay string, ids []uint) error
+}
+
+// outboxRepository implementation of the outbox repository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Append stores an event
+func (r *outboxRepository) Append(tx *gorm.DB, event *models.OutboxEvent) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(event).Error
+}
+
+// Claim takes events with a conditional update, like job claims: an event is
+// only taken if it is still unclaimed, or its claim expired, when the update
+// runs, which works the same on SQLite and Postgres without row locks
+func (r *outboxRepository) Claim(relay string, limit int, now, lockedUntil time.Time) ([]models.OutboxEvent, error) {
+	var ids []uint
+	err := r.db.Model(&models.OutboxEvent{}).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	result := r.db.Model(&models.OutboxEvent{}).
+		Where("id IN ? AND (locked_until IS NULL OR locked_until < ?)", ids, now).
+		Updates(map[string]interface{}{"locked_by": relay, "locked_until": lockedUntil})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	var events []models.OutboxEvent
+	err = r.db.Where("id IN ? AND locked_by = ?", ids, relay).
+		Order("id").
+		Find(&events).Error
+	return events, err
+}
+
+// Remove deletes dispatched events
+func (r *outboxRepository) Remove(relay string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ? AND locked_by = ?", ids, relay).Delete(&models.OutboxEvent{}).Error
+}
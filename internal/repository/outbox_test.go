@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/testdb"
+)
+
+func TestOutboxClaim(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewOutboxRepository(db)
+	for i := 0; i < 3; i++ {
+		if err := repo.Append(nil, &models.OutboxEvent{Type: "event.deleted", Payload: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	claimed, err := repo.Claim("a", 2, now, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].ID > claimed[1].ID {
+		t.Fatalf("expected the two oldest events in order, got %+v", claimed)
+	}
+
+	// Claimed events are not handed out again while the claim lasts
+	rest, err := repo.Claim("b", 10, now, now.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].ID == claimed[0].ID || rest[0].ID == claimed[1].ID {
+		t.Fatalf("expected the unclaimed event only, got %+v", rest)
+	}
+
+	// An expired claim is taken over, after which the first relay cannot remove the events
+	later := now.Add(2 * time.Minute)
+	taken, err := repo.Claim("b", 10, later, later.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taken) != 2 {
+		t.Fatalf("expected the expired claims to be taken over, got %+v", taken)
+	}
+	if err := repo.Remove("a", []uint{claimed[0].ID, claimed[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&models.OutboxEvent{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("events claimed by another relay removed: %d left", count)
+	}
+
+	if err := repo.Remove("b", []uint{claimed[0].ID, claimed[1].ID, rest[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&models.OutboxEvent{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d events left", count)
+	}
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
 	"github.com/you/pawtrack/internal/models"
 	"gorm.io/gorm"
 )
@@ -52,23 +55,14 @@ func (r *permissionRepository) GrantPermission(userID uint, permissionName strin
 		return err
 	}
 
-	// Check if already granted
-	var count int64
-	r.db.Model(&models.UserPermission{}).
-		Where("user_id = ? AND permission_id = ?", userID, permission.ID).
-		Count(&count)
-
-	if count > 0 {
-		return nil // Already granted
-	}
-
-	// Grant permission
+	// Grant permission; granting it again is a no-op
 	userPerm := &models.UserPermission{
 		UserID:       userID,
 		PermissionID: permission.ID,
+		GrantedAt:    time.Now().UTC(),
 	}
 
-	return r.db.Create(userPerm).Error
+	return r.db.Clauses(dialect.InsertIgnore("user_id", "permission_id")).Create(userPerm).Error
 }
 
 func (r *permissionRepository) GrantPermissions(userID uint, permissionNames []string) error {
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// ScheduledTaskRepository interface for the run locks of scheduled tasks
+type ScheduledTaskRepository interface {
+	// Claim takes the run of a task scheduled at runAt for the holder until lockedUntil.
+	// Returns false if another replica holds the task or has already claimed this run.
+	Claim(name, holder string, runAt, now, lockedUntil time.Time) (bool, error)
+	// Finish releases a claimed task and records the outcome of the run
+	Finish(name, holder string, now time.Time, lastError string) error
+}
+
+// scheduledTaskRepository implementation of the scheduled task repository
+type scheduledTaskRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduledTaskRepository creates a new scheduled task repository
+func NewScheduledTaskRepository(db *gorm.DB) ScheduledTaskRepository {
+	return &scheduledTaskRepository{db: db}
+}
+
+// Claim uses a conditional update on the task's row, which works the same on
+// SQLite and Postgres: only one replica can move last_run_at to runAt
+func (r *scheduledTaskRepository) Claim(name, holder string, runAt, now, lockedUntil time.Time) (bool, error) {
+	err := r.db.Clauses(dialect.InsertIgnore("name")).Create(&models.ScheduledTask{Name: name}).Error
+	if err != nil {
+		return false, err
+	}
+
+	result := r.db.Model(&models.ScheduledTask{}).
+		Where("name = ?", name).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Where("last_run_at IS NULL OR last_run_at < ?", runAt).
+		Updates(map[string]interface{}{
+			"locked_by":    holder,
+			"locked_until": lockedUntil,
+			"last_run_at":  runAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Finish releases a claimed task and records the outcome of the run
+func (r *scheduledTaskRepository) Finish(name, holder string, now time.Time, lastError string) error {
+	return r.db.Model(&models.ScheduledTask{}).
+		Where("name = ? AND locked_by = ?", name, holder).
+		Updates(map[string]interface{}{
+			"locked_by":        "",
+			"locked_until":     nil,
+			"last_finished_at": now,
+			"last_error":       lastError,
+		}).Error
+}
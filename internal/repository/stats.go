@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"github.com/you/pawtrack/internal/dto"
+	"gorm.io/gorm"
+)
+
+// StatsRepository interface for aggregated event queries
+type StatsRepository interface {
+	CountByPeriod(dogID uint, groupBy string, from, to time.Time, types []string) ([]PeriodCount, error)
+	TotalsByType(dogID uint, from, to time.Time, types []string) ([]dto.StatsTypeTotals, error)
+	LongestStreaks(dogID uint, from, to time.Time, types []string) ([]dto.StatsStreak, error)
+
+	// EventCountsByDog returns event counts per dog and type for all dogs with events in the range
+	EventCountsByDog(from, to time.Time) ([]DogTypeCount, error)
+	// CommentCountsByDog returns comment counts per dog for comments posted in the range
+	CommentCountsByDog(from, to time.Time) ([]DogTypeCount, error)
+}
+
+// DogTypeCount is a single (dog, type) aggregation row; Type is empty for comments
+type DogTypeCount struct {
+	DogID uint
+	Type  string
+	Count int64
+}
+
+// PeriodCount is a single (period, type) aggregation row
+type PeriodCount struct {
+	Period string
+	Type   string
+	Count  int64
+}
+
+// statsRepository implementation of the stats repository
+type statsRepository struct {
+	db      *gorm.DB
+	dialect dialect.Dialect
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db, dialect: dialect.Of(db)}
+}
+
+// CountByPeriod returns event counts grouped by period and type
+func (r *statsRepository) CountByPeriod(dogID uint, groupBy string, from, to time.Time, types []string) ([]PeriodCount, error) {
+	var rows []PeriodCount
+	bucket := r.bucketExpr("events.at", groupBy)
+
+	err := r.scope(dogID, from, to, types).
+		Select(bucket + " AS period, events.type AS type, COUNT(*) AS count").
+		Group(bucket + ", events.type").
+		Order("period, type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// TotalsByType returns counts and sums of structured fields per event type
+func (r *statsRepository) TotalsByType(dogID uint, from, to time.Time, types []string) ([]dto.StatsTypeTotals, error) {
+	var rows []dto.StatsTypeTotals
+	err := r.scope(dogID, from, to, types).
+		Select("events.type AS type, COUNT(*) AS count, " +
+			"COALESCE(SUM(events.duration_minutes), 0) AS duration_minutes, " +
+			"COALESCE(SUM(events.amount_grams), 0) AS amount_grams").
+		Group("events.type").
+		Order("type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// LongestStreaks returns the longest run of consecutive days per event type.
+// Uses the gaps-and-islands technique: within a run of consecutive days,
+// day number minus row number is constant.
+func (r *statsRepository) LongestStreaks(dogID uint, from, to time.Time, types []string) ([]dto.StatsStreak, error) {
+	days := r.scope(dogID, from, to, types).
+		Select("DISTINCT events.type AS type, " + r.bucketExpr("events.at", dto.StatsGroupDay) + " AS day")
+
+	islands := r.db.Table("(?) AS d", days).
+		Select("d.type, d.day, " + r.dialect.DayNumber("d.day") + " - ROW_NUMBER() OVER (PARTITION BY d.type ORDER BY d.day) AS grp")
+
+	runs := r.db.Table("(?) AS i", islands).
+		Select(`i.type AS type, COUNT(*) AS days, MIN(i.day) AS start, MAX(i.day) AS "end"`).
+		Group("i.type, i.grp")
+
+	ranked := r.db.Table("(?) AS s", runs).
+		Select("s.*, ROW_NUMBER() OVER (PARTITION BY s.type ORDER BY s.days DESC, s.start DESC) AS rn")
+
+	var rows []dto.StatsStreak
+	err := r.db.Table("(?) AS ranked", ranked).
+		Select(`ranked.type, ranked.days, ranked.start, ranked."end"`).
+		Where("ranked.rn = 1").
+		Order("ranked.type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// EventCountsByDog returns event counts per dog and type for all dogs with events in the range
+func (r *statsRepository) EventCountsByDog(from, to time.Time) ([]DogTypeCount, error) {
+	var rows []DogTypeCount
+	err := r.db.Table("events").
+		Select("events.dog_id AS dog_id, events.type AS type, COUNT(*) AS count").
+		Where("events.at >= ? AND events.at < ?", from.UTC(), to.UTC()).
+		Group("events.dog_id, events.type").
+		Order("dog_id, type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CommentCountsByDog returns comment counts per dog for comments posted in the range
+func (r *statsRepository) CommentCountsByDog(from, to time.Time) ([]DogTypeCount, error) {
+	var rows []DogTypeCount
+	err := r.db.Table("event_comments").
+		Select("events.dog_id AS dog_id, COUNT(*) AS count").
+		Joins("JOIN events ON events.id = event_comments.event_id").
+		Where("event_comments.created_at >= ? AND event_comments.created_at < ?", from.UTC(), to.UTC()).
+		Group("events.dog_id").
+		Order("dog_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// scope builds the base query shared by all aggregations
+func (r *statsRepository) scope(dogID uint, from, to time.Time, types []string) *gorm.DB {
+	query := r.db.Table("events").
+		Where("events.dog_id = ?", dogID).
+		Where("events.at >= ? AND events.at < ?", from.UTC(), to.UTC())
+	if len(types) > 0 {
+		query = query.Where("events.type IN ?", types)
+	}
+	return query
+}
+
+// bucketExpr returns a SQL expression that truncates a timestamp column to the
+// first day of its period, formatted as YYYY-MM-DD in UTC
+func (r *statsRepository) bucketExpr(column, groupBy string) string {
+	unit := dialect.Day
+	switch groupBy {
+	case dto.StatsGroupWeek:
+		unit = dialect.Week
+	case dto.StatsGroupMonth:
+		unit = dialect.Month
+	}
+	return r.dialect.DateBucket(column, unit)
+}
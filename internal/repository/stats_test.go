@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/testdb"
+	"gorm.io/gorm"
+)
+
+func TestStatsCountByPeriodInUTC(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testCountByPeriodInUTC(t, testdb.Open(t))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		// A session time zone far from UTC shifts any bucket not computed in UTC
+		testCountByPeriodInUTC(t, testdb.OpenPostgres(t, "Pacific/Auckland"))
+	})
+}
+
+func testCountByPeriodInUTC(t *testing.T, db *gorm.DB) {
+	owner := models.User{Name: "Owner", Email: "owner@example.com", PasswordHash: "x", Role: models.RoleOwner}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	dog := models.Dog{OwnerID: owner.ID, Name: "Rex"}
+	if err := db.Create(&dog).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Close to day, week and month boundaries in UTC
+	for _, at := range []time.Time{
+		time.Date(2025, 3, 2, 23, 30, 0, 0, time.UTC), // Sunday
+		time.Date(2025, 3, 3, 0, 30, 0, 0, time.UTC),  // Monday
+		time.Date(2025, 3, 31, 20, 0, 0, 0, time.UTC), // Last day of the month
+	} {
+		if err := db.Create(&models.Event{DogID: &dog.ID, Type: "walk", At: at}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := NewStatsRepository(db)
+	from, to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	for groupBy, want := range map[string]string{
+		dto.StatsGroupDay:   "[2025-03-02:1 2025-03-03:1 2025-03-31:1]",
+		dto.StatsGroupWeek:  "[2025-02-24:1 2025-03-03:1 2025-03-31:1]",
+		dto.StatsGroupMonth: "[2025-03-01:3]",
+	} {
+		rows, err := repo.CountByPeriod(dog.ID, groupBy, from, to, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(rows))
+		for i, row := range rows {
+			got[i] = fmt.Sprintf("%s:%d", row.Period, row.Count)
+		}
+		if fmt.Sprint(got) != want {
+			t.Errorf("%s: expected %s, got %v", groupBy, want, got)
+		}
+	}
+}
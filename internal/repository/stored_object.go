@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// StoredObjectRepository interface for the records of stored files. Reference
+// counts are kept by database triggers on the referring tables.
+type StoredObjectRepository interface {
+	// Create records a newly stored file, not referred to yet
+	Create(object *models.StoredObject) error
+
+	// Claim returns the key of a referenced file of the backend with the given
+	// content and marks it reused at now, so that it is kept until the new rows
+	// refer to it. Returns "" if there is none.
+	Claim(backend string, sha256 string, size int64, now time.Time) (string, error)
+
+	// Release deletes the record of a file of the backend no row refers to and
+	// no upload claimed since cutoff. Returns true if the file may be deleted:
+	// its record was deleted or it had none.
+	Release(backend string, key string, cutoff time.Time) (bool, error)
+
+	// ListReleasable returns up to limit unreferenced files of the backend, after
+	// afterID in id order, stored and last claimed before cutoff
+	ListReleasable(backend string, cutoff time.Time, afterID uint, limit int) ([]models.StoredObject, error)
+
+	// ListReferenced returns the keys and creation times of the backend's files
+	// that rows refer to
+	ListReferenced(backend string) ([]models.StoredObject, error)
+
+	// KnownKeys returns every key that is recorded or referred to
+	KnownKeys() (map[string]bool, error)
+
+	// CountUntracked returns the number of referenced keys without a record
+	CountUntracked() (int64, error)
+
+	// TrackUntracked records the referenced keys without a record, unreferenced
+	// until recounted, and returns their number
+	TrackUntracked() (int64, error)
+
+	// CountMiscounted returns the number of the backend's records whose reference
+	// count differs from the rows referring to them
+	CountMiscounted(backend string) (int64, error)
+
+	// Recount corrects the reference counts of the backend's records and returns
+	// the number corrected
+	Recount(backend string) (int64, error)
+}
+
+// storedObjectRepository implementation of the stored object repository
+type storedObjectRepository struct {
+	db *gorm.DB
+}
+
+// NewStoredObjectRepository creates a new stored object repository
+func NewStoredObjectRepository(db *gorm.DB) StoredObjectRepository {
+	return &storedObjectRepository{db: db}
+}
+
+// referencesSQL selects the key and backend of every row referring to a file.
+// Thumbnails and chunks are kept in the backend of their attachment or upload.
+const referencesSQL = `SELECT key, backend FROM attachments
+UNION ALL
+SELECT t.key, COALESCE(a.backend, u.backend, '') FROM thumbnails t
+LEFT JOIN attachments a ON a.id = t.attachment_id
+LEFT JOIN uploads u ON u.id = t.upload_id
+UNION ALL
+SELECT key, backend FROM uploads WHERE key IS NOT NULL AND key <> ''
+UNION ALL
+SELECT c.key, u.backend FROM upload_chunks c JOIN uploads u ON u.id = c.upload_id`
+
+// refCountSQL counts the rows referring to the key of a stored_objects row
+const refCountSQL = `(SELECT COUNT(*) FROM attachments WHERE attachments.key = stored_objects.key) +
+(SELECT COUNT(*) FROM thumbnails WHERE thumbnails.key = stored_objects.key) +
+(SELECT COUNT(*) FROM uploads WHERE uploads.key = stored_objects.key) +
+(SELECT COUNT(*) FROM upload_chunks WHERE upload_chunks.key = stored_objects.key)`
+
+// untrackedSQL restricts referencesSQL to keys without a record
+const untrackedSQL = `FROM (` + referencesSQL + `) refs
+WHERE NOT EXISTS (SELECT 1 FROM stored_objects WHERE stored_objects.key = refs.key)`
+
+// Create records a newly stored file
+func (r *storedObjectRepository) Create(object *models.StoredObject) error {
+	return r.db.Create(object).Error
+}
+
+// Claim finds a file with the content and marks it claimed, guarded by its
+// reference count so that a file being released is not reused
+func (r *storedObjectRepository) Claim(backend string, sha256 string, size int64, now time.Time) (string, error) {
+	var object models.StoredObject
+	err := r.db.Where("backend = ? AND sha256 = ? AND size = ? AND ref_count > 0", backend, sha256, size).
+		First(&object).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result := r.db.Model(&models.StoredObject{}).
+		Where("id = ? AND ref_count > 0", object.ID).
+		Update("claimed_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return "", result.Error
+	}
+	return object.Key, nil
+}
+
+// Release deletes the record if it is unreferenced and unclaimed
+func (r *storedObjectRepository) Release(backend string, key string, cutoff time.Time) (bool, error) {
+	result := r.db.Where("key = ? AND backend = ? AND ref_count <= 0 AND (claimed_at IS NULL OR claimed_at < ?)", key, backend, cutoff).
+		Delete(&models.StoredObject{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var count int64
+	err := r.db.Model(&models.StoredObject{}).Where("key = ?", key).Count(&count).Error
+	return count == 0, err
+}
+
+// ListReleasable returns unreferenced files older than cutoff
+func (r *storedObjectRepository) ListReleasable(backend string, cutoff time.Time, afterID uint, limit int) ([]models.StoredObject, error) {
+	var objects []models.StoredObject
+	err := r.db.Where("backend = ? AND ref_count <= 0 AND created_at < ? AND (claimed_at IS NULL OR claimed_at < ?) AND id > ?", backend, cutoff, cutoff, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&objects).Error
+	return objects, err
+}
+
+// ListReferenced returns the backend's referenced files
+func (r *storedObjectRepository) ListReferenced(backend string) ([]models.StoredObject, error) {
+	var objects []models.StoredObject
+	err := r.db.Select("id", "key", "created_at").
+		Where("backend = ? AND ref_count > 0", backend).
+		Find(&objects).Error
+	return objects, err
+}
+
+// KnownKeys returns the recorded and the referenced keys
+func (r *storedObjectRepository) KnownKeys() (map[string]bool, error) {
+	rows, err := r.db.Raw(`SELECT key FROM stored_objects UNION SELECT key FROM (` + referencesSQL + `) refs`).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+	return keys, rows.Err()
+}
+
+// CountUntracked counts the referenced keys without a record
+func (r *storedObjectRepository) CountUntracked() (int64, error) {
+	var count int64
+	err := r.db.Raw(`SELECT COUNT(DISTINCT refs.key) ` + untrackedSQL).Scan(&count).Error
+	return count, err
+}
+
+// TrackUntracked inserts records for the referenced keys without one
+func (r *storedObjectRepository) TrackUntracked() (int64, error) {
+	result := r.db.Exec(`INSERT INTO stored_objects (key, backend, size, ref_count, created_at)
+SELECT refs.key, MIN(refs.backend), 0, 0, ? `+untrackedSQL+`
+GROUP BY refs.key`, time.Now().UTC())
+	return result.RowsAffected, result.Error
+}
+
+// CountMiscounted counts the backend's records with a wrong reference count
+func (r *storedObjectRepository) CountMiscounted(backend string) (int64, error) {
+	var count int64
+	err := r.db.Raw(`SELECT COUNT(*) FROM stored_objects WHERE backend = ? AND ref_count <> `+refCountSQL, backend).
+		Scan(&count).Error
+	return count, err
+}
+
+// Recount sets the reference counts of the backend's records from the rows
+// referring to them
+func (r *storedObjectRepository) Recount(backend string) (int64, error) {
+	result := r.db.Exec(`UPDATE stored_objects SET ref_count = `+refCountSQL+`
+WHERE backend = ? AND ref_count <> `+refCountSQL, backend)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// SyncScope describes whose changes to read, since when and which page
+type SyncScope struct {
+	UserID uint
+	Role   models.UserRole
+	Since  *time.Time  // nil reads a full snapshot
+	After  *SyncCursor // Continues after this record, nil starts from the beginning
+	Limit  int         // Maximum number of records, 0 for no limit
+}
+
+// SyncCursor is the position of a record in sync order: by change time, then id
+type SyncCursor struct {
+	At time.Time // updated_at, or deleted_at for tombstones
+	ID uint
+}
+
+// SyncRepository interface for delta sync reads and client ID lookups
+type SyncRepository interface {
+	ChangedDogs(scope *SyncScope) ([]models.Dog, error)
+	ChangedEvents(scope *SyncScope) ([]models.Event, error)
+	ChangedComments(scope *SyncScope) ([]models.EventComment, error)
+	ChangedNotes(scope *SyncScope) ([]models.ConsultantNote, error)
+	Tombstones(scope *SyncScope) ([]models.Tombstone, error)
+
+	GetEventByClientID(clientID string) (*models.Event, error)
+	GetCommentByClientID(clientID string) (*models.EventComment, error)
+	GetNoteByClientID(clientID string) (*models.ConsultantNote, error)
+
+	// IsDeleted reports whether a tombstone exists for the entity
+	IsDeleted(entityType string, id uint) (bool, error)
+	// IsDeletedByClientID reports whether a tombstone exists for the client ID
+	IsDeletedByClientID(entityType string, clientID string) (bool, error)
+}
+
+// syncRepository implementation of the sync repository
+type syncRepository struct {
+	db *gorm.DB
+}
+
+// NewSyncRepository creates a new sync repository
+func NewSyncRepository(db *gorm.DB) SyncRepository {
+	return &syncRepository{db: db}
+}
+
+// visibleDogIDs returns a subquery of dog IDs the user can see, nil for admins
+func (r *syncRepository) visibleDogIDs(scope *SyncScope) *gorm.DB {
+	switch scope.Role {
+	case models.RoleAdmin:
+		return nil
+	case models.RoleConsultant:
+		return r.db.Table("consultant_access").Select("dog_id").
+			Where("consultant_id = ? AND revoked_at IS NULL", scope.UserID)
+	default:
+		return r.db.Table("dogs").Select("id").Where("owner_id = ?", scope.UserID)
+	}
+}
+
+// grantedDogIDs returns a subquery of dogs that became visible to a consultant
+// since the checkpoint; their existing records are sent in full
+func (r *syncRepository) grantedDogIDs(scope *SyncScope) *gorm.DB {
+	return r.db.Table("consultant_access").Select("dog_id").
+		Where("consultant_id = ? AND revoked_at IS NULL AND granted_at >= ?", scope.UserID, *scope.Since)
+}
+
+// changed restricts a query to records visible to the user and changed since the checkpoint
+func (r *syncRepository) changed(query *gorm.DB, scope *SyncScope, updatedAtColumn, dogIDColumn string) *gorm.DB {
+	if visible := r.visibleDogIDs(scope); visible != nil {
+		query = query.Where(dogIDColumn+" IN (?)", visible)
+	}
+	if scope.Since == nil {
+		return query
+	}
+	if scope.Role == models.RoleConsultant {
+		return query.Where(updatedAtColumn+" >= ? OR "+dogIDColumn+" IN (?)", *scope.Since, r.grantedDogIDs(scope))
+	}
+	return query.Where(updatedAtColumn+" >= ?", *scope.Since)
+}
+
+// page orders a query in sync order and applies the scope's cursor and limit
+func page(query *gorm.DB, scope *SyncScope, atColumn, idColumn string) *gorm.DB {
+	if scope.After != nil {
+		query = query.Where(atColumn+" > ? OR ("+atColumn+" = ? AND "+idColumn+" > ?)", scope.After.At, scope.After.At, scope.After.ID)
+	}
+	if scope.Limit > 0 {
+		query = query.Limit(scope.Limit)
+	}
+	return query.Order(atColumn + " ASC, " + idColumn + " ASC")
+}
+
+// ChangedDogs returns visible dogs created or updated since the checkpoint
+func (r *syncRepository) ChangedDogs(scope *SyncScope) ([]models.Dog, error) {
+	var dogs []models.Dog
+	query := r.changed(r.db.Model(&models.Dog{}), scope, "dogs.updated_at", "dogs.id")
+	err := page(query, scope, "dogs.updated_at", "dogs.id").Find(&dogs).Error
+	return dogs, err
+}
+
+// ChangedEvents returns visible events created or updated since the checkpoint
+func (r *syncRepository) ChangedEvents(scope *SyncScope) ([]models.Event, error) {
+	var events []models.Event
+	query := r.changed(r.db.Model(&models.Event{}).Preload("Attachments", attachmentsInOrder), scope, "events.updated_at", "events.dog_id")
+	err := page(query, scope, "events.updated_at", "events.id").Find(&events).Error
+	return events, err
+}
+
+// ChangedComments returns comments on visible events created or updated since the checkpoint
+func (r *syncRepository) ChangedComments(scope *SyncScope) ([]models.EventComment, error) {
+	var comments []models.EventComment
+	query := r.db.Model(&models.EventComment{}).
+		Select("event_comments.*").
+		Joins("JOIN events ON events.id = event_comments.event_id").
+		Preload("User").
+		Preload("Attachments", attachmentsInOrder)
+	query = r.changed(query, scope, "event_comments.updated_at", "events.dog_id")
+	err := page(query, scope, "event_comments.updated_at", "event_comments.id").Find(&comments).Error
+	return comments, err
+}
+
+// ChangedNotes returns notes created or updated since the checkpoint.
+// Admins get all notes, consultants their own, owners none.
+func (r *syncRepository) ChangedNotes(scope *SyncScope) ([]models.ConsultantNote, error) {
+	var notes []models.ConsultantNote
+
+	query := r.db.Model(&models.ConsultantNote{}).Preload("Dog.Owner").Preload("Consultant").Preload("Attachments", attachmentsInOrder)
+	switch scope.Role {
+	case models.RoleAdmin:
+	case models.RoleConsultant:
+		query = query.Where("consultant_notes.consultant_id = ?", scope.UserID)
+	default:
+		return notes, nil
+	}
+	if scope.Since != nil {
+		query = query.Where("consultant_notes.updated_at >= ?", *scope.Since)
+	}
+
+	err := page(query, scope, "consultant_notes.updated_at", "consultant_notes.id").Find(&notes).Error
+	return notes, err
+}
+
+// Tombstones returns deletions since the checkpoint the user should know about
+func (r *syncRepository) Tombstones(scope *SyncScope) ([]models.Tombstone, error) {
+	var tombstones []models.Tombstone
+	if scope.Since == nil {
+		// A full snapshot has nothing to delete on the client
+		return tombstones, nil
+	}
+
+	query := r.db.Model(&models.Tombstone{}).Where("deleted_at >= ?", *scope.Since)
+	if visible := r.visibleDogIDs(scope); visible != nil {
+		query = query.Where("user_id = ? OR (user_id IS NULL AND dog_id IN (?))", scope.UserID, visible)
+	}
+
+	err := page(query, scope, "deleted_at", "id").Find(&tombstones).Error
+	return tombstones, err
+}
+
+// GetEventByClientID returns an event by its client-generated ID
+func (r *syncRepository) GetEventByClientID(clientID string) (*models.Event, error) {
+	var event models.Event
+	err := r.db.Preload("Attachments", attachmentsInOrder).Where("client_id = ?", clientID).First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetCommentByClientID returns a comment by its client-generated ID
+func (r *syncRepository) GetCommentByClientID(clientID string) (*models.EventComment, error) {
+	var comment models.EventComment
+	err := r.db.Preload("Attachments", attachmentsInOrder).Where("client_id = ?", clientID).First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetNoteByClientID returns a consultant note by its client-generated ID
+func (r *syncRepository) GetNoteByClientID(clientID string) (*models.ConsultantNote, error) {
+	var note models.ConsultantNote
+	err := r.db.Preload("Attachments", attachmentsInOrder).Where("client_id = ?", clientID).First(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// IsDeleted reports whether a tombstone exists for the entity
+func (r *syncRepository) IsDeleted(entityType string, id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Tombstone{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsDeletedByClientID reports whether a tombstone exists for the client ID
+func (r *syncRepository) IsDeletedByClientID(entityType string, clientID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Tombstone{}).
+		Where("entity_type = ? AND client_id = ?", entityType, clientID).
+		Count(&count).Error
+	return count > 0, err
+}
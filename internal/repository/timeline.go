@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// TimelineEntry is a reference to a timeline item before hydration
+type TimelineEntry struct {
+	Kind       string
+	ID         uint
+	OccurredAt time.Time
+}
+
+// TimelineQuery describes which items of a dog's timeline to fetch
+type TimelineQuery struct {
+	DogID uint
+
+	// Notes visibility: nil disables notes, zero includes all notes,
+	// any other value includes only notes written by that consultant
+	NoteAuthorID *uint
+
+	// Keyset cursor: only items strictly after this one (in descending order)
+	After *TimelineEntry
+
+	Limit int
+}
+
+// TimelineRepository interface for the merged dog timeline
+type TimelineRepository interface {
+	List(query *TimelineQuery) ([]TimelineEntry, error)
+	LoadEvents(ids []uint) ([]models.Event, error)
+	LoadComments(ids []uint) ([]models.EventComment, error)
+	LoadNotes(ids []uint) ([]models.ConsultantNote, error)
+}
+
+// timelineRepository implementation of the timeline repository
+type timelineRepository struct {
+	db *gorm.DB
+}
+
+// NewTimelineRepository creates a new timeline repository
+func NewTimelineRepository(db *gorm.DB) TimelineRepository {
+	return &timelineRepository{db: db}
+}
+
+// List returns timeline entries of events, comments and notes ordered
+// by occurrence time, newest first
+func (r *timelineRepository) List(query *TimelineQuery) ([]TimelineEntry, error) {
+	sql := `SELECT 'event' AS kind, e.id AS id, e.at AS occurred_at FROM events e WHERE e.dog_id = ?
+		UNION ALL
+		SELECT 'comment' AS kind, c.id AS id, c.created_at AS occurred_at FROM event_comments c
+		JOIN events ce ON ce.id = c.event_id WHERE ce.dog_id = ?`
+	args := []interface{}{query.DogID, query.DogID}
+
+	if query.NoteAuthorID != nil {
+		sql += `
+		UNION ALL
+		SELECT 'note' AS kind, n.id AS id, n.created_at AS occurred_at FROM consultant_notes n WHERE n.dog_id = ?`
+		args = append(args, query.DogID)
+		if *query.NoteAuthorID != 0 {
+			sql += ` AND n.consultant_id = ?`
+			args = append(args, *query.NoteAuthorID)
+		}
+	}
+
+	tx := r.db.Table("("+sql+") AS timeline", args...).Select("kind, id, occurred_at")
+
+	if after := query.After; after != nil {
+		tx = tx.Where("occurred_at < ? OR (occurred_at = ? AND (kind < ? OR (kind = ? AND id < ?)))",
+			after.OccurredAt, after.OccurredAt, after.Kind, after.Kind, after.ID)
+	}
+
+	var entries []TimelineEntry
+	err := tx.Order("occurred_at DESC, kind DESC, id DESC").
+		Limit(query.Limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
+// LoadEvents returns events by IDs with their dog
+func (r *timelineRepository) LoadEvents(ids []uint) ([]models.Event, error) {
+	var events []models.Event
+	if len(ids) == 0 {
+		return events, nil
+	}
+	err := r.db.Preload("Dog").Preload("Attachments", attachmentsInOrder).Where("id IN ?", ids).Find(&events).Error
+	return events, err
+}
+
+// LoadComments returns comments by IDs with their author
+func (r *timelineRepository) LoadComments(ids []uint) ([]models.EventComment, error) {
+	var comments []models.EventComment
+	if len(ids) == 0 {
+		return comments, nil
+	}
+	err := r.db.Preload("User").Preload("Attachments", attachmentsInOrder).Where("id IN ?", ids).Find(&comments).Error
+	return comments, err
+}
+
+// LoadNotes returns consultant notes by IDs with dog and owner
+func (r *timelineRepository) LoadNotes(ids []uint) ([]models.ConsultantNote, error) {
+	var notes []models.ConsultantNote
+	if len(ids) == 0 {
+		return notes, nil
+	}
+	err := r.db.Preload("Dog.Owner").Preload("Consultant").Preload("Attachments", attachmentsInOrder).Where("id IN ?", ids).Find(&notes).Error
+	return notes, err
+}
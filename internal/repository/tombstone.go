@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// deleteWithTombstones deletes a record and records tombstones for it in one transaction,
+// along with the hook, if any. build receives the loaded record and returns the
+// tombstones to write. Deleting a missing record is not an error, like a plain gorm Delete.
+func deleteWithTombstones[T any](db *gorm.DB, id uint, hook TxHook, build func(tx *gorm.DB, record *T) ([]models.Tombstone, error)) error {
+	return transact(db, hook, func(tx *gorm.DB) error {
+		var record T
+		if err := tx.First(&record, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		tombstones, err := build(tx, &record)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for i := range tombstones {
+			tombstones[i].DeletedAt = now
+		}
+		if len(tombstones) > 0 {
+			if err := tx.Create(&tombstones).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&record, id).Error
+	})
+}
@@ -0,0 +1,33 @@
+package repository
+
+import "gorm.io/gorm"
+
+// TxHook runs inside the transaction of a repository change once the change is
+// written, e.g. to store the domain events of the change in the outbox. An
+// error rolls the change back. The returned function, if not nil, is called
+// after the transaction has committed.
+type TxHook func(tx *gorm.DB) (func(), error)
+
+// transact runs write and then the hook, if any, in one transaction and calls
+// the hook's follow-up once the transaction has committed
+func transact(db *gorm.DB, hook TxHook, write func(tx *gorm.DB) error) error {
+	var committed func()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		if hook == nil {
+			return nil
+		}
+		var err error
+		committed, err = hook(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if committed != nil {
+		committed()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// UploadRepository interface for resumable upload data access
+type UploadRepository interface {
+	// Create inserts a new upload
+	Create(upload *models.Upload) error
+
+	// GetByID returns an upload with its chunks in file order
+	GetByID(id string) (*models.Upload, error)
+
+	// ListCompleted returns the user's completed uploads among ids
+	ListCompleted(ids []string, userID uint) ([]models.Upload, error)
+
+	// AddChunk records a received chunk and advances the upload's offset past it.
+	// Returns false if the offset moved since the chunk was started.
+	AddChunk(upload *models.Upload, chunk *models.UploadChunk) (bool, error)
+
+	// Complete stores the joined file of an upload with its thumbnails and drops its chunks
+	Complete(upload *models.Upload) error
+
+	// Delete removes an upload with its chunks and thumbnails
+	Delete(id string) error
+
+	// ListExpired returns up to limit uploads expired before now, with chunks
+	ListExpired(now time.Time, limit int) ([]models.Upload, error)
+}
+
+// uploadRepository implementation of the upload repository
+type uploadRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadRepository creates a new upload repository
+func NewUploadRepository(db *gorm.DB) UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+// Create inserts a new upload
+func (r *uploadRepository) Create(upload *models.Upload) error {
+	return r.db.Create(upload).Error
+}
+
+// GetByID returns an upload with its chunks in file order
+func (r *uploadRepository) GetByID(id string) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.Preload("Chunks", chunksInOrder).Preload("Thumbnails", thumbnailsInOrder).First(&upload, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// ListCompleted returns the user's completed uploads among ids
+func (r *uploadRepository) ListCompleted(ids []string, userID uint) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := r.db.Preload("Thumbnails", thumbnailsInOrder).
+		Where("id IN ? AND user_id = ? AND completed_at IS NOT NULL", ids, userID).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// AddChunk records a received chunk and advances the offset, guarded by the
+// offset the chunk started at so that concurrent PATCH requests cannot both
+// append at the same position
+func (r *uploadRepository) AddChunk(upload *models.Upload, chunk *models.UploadChunk) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Upload{}).
+			Where("id = ? AND upload_offset = ?", upload.ID, chunk.Offset).
+			Updates(map[string]interface{}{
+				"upload_offset": chunk.Offset + chunk.Size,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		chunk.UploadID = upload.ID
+		if err := tx.Create(chunk).Error; err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	if err != nil || !added {
+		return false, err
+	}
+	upload.Offset = chunk.Offset + chunk.Size
+	upload.Chunks = append(upload.Chunks, *chunk)
+	return true, nil
+}
+
+// Complete stores the joined file of an upload with its thumbnails and drops its chunks
+func (r *uploadRepository) Complete(upload *models.Upload) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("upload_id = ?", upload.ID).Delete(&models.UploadChunk{}).Error; err != nil {
+			return err
+		}
+		for i := range upload.Thumbnails {
+			upload.Thumbnails[i].UploadID = &upload.ID
+		}
+		if len(upload.Thumbnails) > 0 {
+			if err := tx.Create(&upload.Thumbnails).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Upload{}).Where("id = ?", upload.ID).Updates(map[string]interface{}{
+			"key":          upload.Key,
+			"filename":     upload.Filename,
+			"sha256":       upload.SHA256,
+			"size":         upload.Size,
+			"content_type": upload.ContentType,
+			"completed_at": upload.CompletedAt,
+			"updated_at":   time.Now(),
+		}).Error
+	})
+}
+
+// Delete removes an upload with its chunks and thumbnails
+func (r *uploadRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("upload_id = ?", id).Delete(&models.UploadChunk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("upload_id = ?", id).Delete(&models.Thumbnail{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Upload{}, "id = ?", id).Error
+	})
+}
+
+// ListExpired returns up to limit uploads expired before now, with chunks
+func (r *uploadRepository) ListExpired(now time.Time, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := r.db.Preload("Chunks", chunksInOrder).Preload("Thumbnails", thumbnailsInOrder).
+		Where("expires_at < ?", now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// chunksInOrder sorts preloaded chunks by their position in the file
+func chunksInOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("chunk_offset")
+}
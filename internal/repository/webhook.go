@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository interface for webhook endpoints and their delivery log
+type WebhookRepository interface {
+	CreateEndpoint(endpoint *models.WebhookEndpoint) error
+	GetEndpoint(id uint) (*models.WebhookEndpoint, error)
+	// ListEndpoints returns the user's endpoints, or all endpoints if userID is nil
+	ListEndpoints(userID *uint) ([]models.WebhookEndpoint, error)
+	UpdateEndpoint(endpoint *models.WebhookEndpoint) error
+	DeleteEndpoint(id uint) error
+
+	// MatchingEndpoints returns active endpoints subscribed to the event type whose
+	// users can see the dog; private restricts delivery to that user and admins
+	MatchingEndpoints(dogID uint, eventType string, private *uint) ([]models.WebhookEndpoint, error)
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+	ListDeliveries(endpointID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error)
+
+	// SaveAttempt stores the outcome of a delivery attempt
+	SaveAttempt(delivery *models.WebhookDelivery) error
+}
+
+// webhookRepository implementation of the webhook repository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateEndpoint adds a new endpoint
+func (r *webhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+// GetEndpoint returns an endpoint by ID
+func (r *webhookRepository) GetEndpoint(id uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.First(&endpoint, id).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// ListEndpoints returns the user's endpoints, or all endpoints if userID is nil
+func (r *webhookRepository) ListEndpoints(userID *uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	tx := r.db.Order("id")
+	if userID != nil {
+		tx = tx.Where("user_id = ?", *userID)
+	}
+	err := tx.Find(&endpoints).Error
+	return endpoints, err
+}
+
+// UpdateEndpoint saves all fields of the endpoint
+func (r *webhookRepository) UpdateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes an endpoint together with its delivery log
+func (r *webhookRepository) DeleteEndpoint(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookEndpoint{}, id).Error
+	})
+}
+
+// MatchingEndpoints applies the same visibility as the API: admins see every dog,
+// owners their own dogs and consultants the dogs they currently have access to
+func (r *webhookRepository) MatchingEndpoints(dogID uint, eventType string, private *uint) ([]models.WebhookEndpoint, error) {
+	tx := r.db.Model(&models.WebhookEndpoint{}).
+		Select("webhook_endpoints.*").
+		Joins("JOIN users ON users.id = webhook_endpoints.user_id").
+		Where("webhook_endpoints.active = ?", true).
+		Where("webhook_endpoints.event_types LIKE ?", "%,"+eventType+",%").
+		Where(`(users.role = ?
+			OR (users.role = ? AND EXISTS (SELECT 1 FROM dogs WHERE dogs.id = ? AND dogs.owner_id = users.id))
+			OR (users.role = ? AND EXISTS (SELECT 1 FROM consultant_access ca WHERE ca.dog_id = ? AND ca.consultant_id = users.id AND ca.revoked_at IS NULL)))`,
+			models.RoleAdmin, models.RoleOwner, dogID, models.RoleConsultant, dogID)
+
+	if private != nil {
+		tx = tx.Where("(users.role = ? OR users.id = ?)", models.RoleAdmin, *private)
+	}
+
+	var endpoints []models.WebhookEndpoint
+	err := tx.Find(&endpoints).Error
+	return endpoints, err
+}
+
+// CreateDeliveries inserts new deliveries
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// GetDelivery returns a delivery by ID
+func (r *webhookRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of an endpoint's deliveries, newest first
+func (r *webhookRepository) ListDeliveries(endpointID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	tx := r.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := tx.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// SaveAttempt stores the outcome of a delivery attempt
+func (r *webhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at",
+	).Updates(delivery).Error
+}
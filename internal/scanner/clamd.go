@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the largest chunk sent to clamd; clamd's default
+// StreamMaxLength is 25 MB, which is counted over all chunks
+const clamdChunkSize = 64 * 1024
+
+// maxClamdReply limits how much of a reply is read
+const maxClamdReply = 4096
+
+// Clamd scans files with a ClamAV daemon, streaming them over its INSTREAM
+// command, so clamd needs no access to the storage
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd creates a clamd client. address is "tcp://host:port", "host:port"
+// or "unix:///path/to/clamd.sock"; timeout limits each command.
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	if address == "" {
+		return nil, errors.New("clamd: address is empty")
+	}
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	return &Clamd{network: network, address: address, timeout: timeout}, nil
+}
+
+// Name returns "clamd"
+func (c *Clamd) Name() string { return "clamd" }
+
+// Ping checks that clamd answers
+func (c *Clamd) Ping(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams the file to clamd in chunks, each prefixed with its length as
+// a big-endian uint32, and reads the verdict after the zero-length chunk
+func (c *Clamd) Scan(ctx context.Context, file io.Reader) (Result, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	if err := sendStream(conn, file); err != nil {
+		// clamd stops reading and replies when the stream is over its limit
+		if reply, replyErr := readReply(conn); replyErr == nil && reply != "" {
+			return parseScanReply(reply)
+		}
+		return Result{}, err
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return Result{}, err
+	}
+	return parseScanReply(reply)
+}
+
+// dial connects to clamd; the connection is closed when ctx is done
+func (c *Clamd) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	return &clamdConn{Conn: conn, stop: stop}, nil
+}
+
+// clamdConn stops watching the context when closed
+type clamdConn struct {
+	net.Conn
+	stop func() bool
+}
+
+func (c *clamdConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// sendStream writes an INSTREAM command with the file
+func sendStream(conn net.Conn, file io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := io.ReadFull(file, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	return nil
+}
+
+// readReply reads a reply of a z-prefixed command, terminated by a null byte
+func readReply(conn net.Conn) (string, error) {
+	reply, err := io.ReadAll(io.LimitReader(conn, maxClamdReply))
+	if err != nil && len(reply) == 0 {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	if i := bytes.IndexByte(reply, 0); i >= 0 {
+		reply = reply[:i]
+	}
+	return strings.TrimSpace(string(reply)), nil
+}
+
+// parseScanReply interprets "stream: OK", "stream: <signature> FOUND" and
+// "<message> ERROR"
+func parseScanReply(reply string) (Result, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.Contains(verdict, "size limit exceeded"):
+		return Result{}, ErrTooLarge
+	case verdict == "":
+		return Result{}, errors.New("clamd: empty reply")
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", verdict)
+	}
+}
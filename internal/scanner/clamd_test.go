@@ -0,0 +1,236 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eicar is the EICAR anti-virus test file, found by every scanner
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd speaks the part of the clamd protocol the client uses: zPING and
+// zINSTREAM, replying to one command per connection
+type fakeClamd struct {
+	maxStream int // StreamMaxLength; 0 for no limit
+	hang      bool
+
+	mu      sync.Mutex
+	streams [][]byte // Files received
+	chunks  []int    // Sizes of the chunks received
+}
+
+func newFakeClamd(t *testing.T, network string, fake *fakeClamd) string {
+	address := "127.0.0.1:0"
+	if network == "unix" {
+		address = filepath.Join(t.TempDir(), "clamd.sock")
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+	if network == "unix" {
+		return "unix://" + address
+	}
+	return "tcp://" + listener.Addr().String()
+}
+
+func (f *fakeClamd) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch command {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00"))
+	case "zINSTREAM\x00":
+		var stream bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			f.mu.Lock()
+			f.chunks = append(f.chunks, int(size))
+			f.mu.Unlock()
+			if _, err := io.CopyN(&stream, r, int64(size)); err != nil {
+				return
+			}
+			if f.maxStream > 0 && stream.Len() > f.maxStream {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+		}
+		if f.hang {
+			time.Sleep(time.Second)
+			return
+		}
+		f.mu.Lock()
+		f.streams = append(f.streams, stream.Bytes())
+		f.mu.Unlock()
+		if bytes.Contains(stream.Bytes(), []byte(eicar)) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestClamdScan(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			fake := &fakeClamd{}
+			clamd, err := NewClamd(newFakeClamd(t, network, fake), 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+
+			if err := clamd.Ping(ctx); err != nil {
+				t.Fatalf("Ping: %v", err)
+			}
+
+			result, err := clamd.Scan(ctx, strings.NewReader("vaccination record"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Infected {
+				t.Fatalf("clean file reported infected: %+v", result)
+			}
+
+			result, err = clamd.Scan(ctx, strings.NewReader("prefix "+eicar))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Infected || result.Threat != "Eicar-Test-Signature" {
+				t.Fatalf("result = %+v, want infected with Eicar-Test-Signature", result)
+			}
+		})
+	}
+}
+
+func TestClamdScanChunks(t *testing.T) {
+	fake := &fakeClamd{}
+	clamd, err := NewClamd(newFakeClamd(t, "tcp", fake), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := bytes.Repeat([]byte("0123456789abcdef"), (2*clamdChunkSize+100)/16)
+	if _, err := clamd.Scan(context.Background(), bytes.NewReader(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty file is a stream of no chunks
+	if _, err := clamd.Scan(context.Background(), bytes.NewReader(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.streams) != 2 || !bytes.Equal(fake.streams[0], file) || len(fake.streams[1]) != 0 {
+		t.Fatal("clamd did not receive the files")
+	}
+	if len(fake.chunks) != 3 || fake.chunks[0] != clamdChunkSize {
+		t.Fatalf("chunks = %v, want two of %d and the rest", fake.chunks, clamdChunkSize)
+	}
+}
+
+func TestClamdScanErrors(t *testing.T) {
+	t.Run("Size Limit", func(t *testing.T) {
+		clamd, err := NewClamd(newFakeClamd(t, "tcp", &fakeClamd{maxStream: 1000}), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = clamd.Scan(context.Background(), bytes.NewReader(make([]byte, 4*clamdChunkSize)))
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("err = %v, want ErrTooLarge", err)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := listener.Addr().String()
+		listener.Close()
+
+		clamd, err := NewClamd(address, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := clamd.Scan(context.Background(), strings.NewReader("x")); err == nil {
+			t.Fatal("scan succeeded without clamd")
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		clamd, err := NewClamd(newFakeClamd(t, "tcp", &fakeClamd{hang: true}), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		if _, err := clamd.Scan(ctx, strings.NewReader("x")); err == nil {
+			t.Fatal("scan succeeded without a reply")
+		}
+		if time.Since(started) > 500*time.Millisecond {
+			t.Fatal("scan did not stop with the context")
+		}
+	})
+
+	t.Run("Empty Address", func(t *testing.T) {
+		if _, err := NewClamd("unix://", time.Second); err == nil {
+			t.Fatal("empty socket path accepted")
+		}
+	})
+}
+
+func TestParseScanReply(t *testing.T) {
+	tests := []struct {
+		reply    string
+		infected bool
+		threat   string
+		err      bool
+	}{
+		{"stream: OK", false, "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", true, "Win.Test.EICAR_HDB-1", false},
+		{"stream: Can't allocate memory ERROR", false, "", true},
+		{"", false, "", true},
+	}
+	for _, test := range tests {
+		result, err := parseScanReply(test.reply)
+		if (err != nil) != test.err || result.Infected != test.infected || result.Threat != test.threat {
+			t.Errorf("parseScanReply(%q) = %+v, %v", test.reply, result, err)
+		}
+	}
+}
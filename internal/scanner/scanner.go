@@ -0,0 +1,41 @@
+// Package scanner checks uploaded files for malware before they can be
+// downloaded
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrTooLarge is returned when a file exceeds what the scanner accepts; scanning
+// it again does not help until the scanner's limit is raised
+var ErrTooLarge = errors.New("file exceeds the scanner's size limit")
+
+// Result is the verdict on a file
+type Result struct {
+	Infected bool
+	Threat   string // Signature that matched, set when infected
+}
+
+// Scanner checks the content of a file
+type Scanner interface {
+	// Name identifies the scanner in logs
+	Name() string
+
+	// Scan reads the file and returns the verdict. An error means the file was
+	// not scanned and is neither clean nor infected.
+	Scan(ctx context.Context, file io.Reader) (Result, error)
+}
+
+// Noop finds every file clean without reading it, for deployments without a
+// scanner
+type Noop struct{}
+
+// Name returns "none"
+func (Noop) Name() string { return "none" }
+
+// Scan returns a clean result
+func (Noop) Scan(ctx context.Context, file io.Reader) (Result, error) {
+	return Result{}, nil
+}
@@ -0,0 +1,105 @@
+// Package scheduler runs maintenance tasks on cron schedules.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// Task is scheduled work; it should stop when ctx is cancelled
+type Task func(ctx context.Context) error
+
+// Scheduler runs registered tasks on their cron schedules. Every replica runs a
+// scheduler, but each run of a task is claimed through the scheduled_tasks table,
+// so only one replica executes it.
+type Scheduler struct {
+	cron   *cron.Cron
+	repo   repository.ScheduledTaskRepository
+	holder string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a scheduler; schedules are evaluated in UTC
+func New(repo repository.ScheduledTaskRepository) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	host, _ := os.Hostname()
+	return &Scheduler{
+		cron:   cron.New(cron.WithLocation(time.UTC)),
+		repo:   repo,
+		holder: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register schedules a task with a standard five-field cron expression or a
+// descriptor such as "@daily". A run is cut off after timeout.
+func (s *Scheduler) Register(name, spec string, timeout time.Duration, task Task) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", name, err)
+	}
+	s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.run(name, timeout, task, time.Now().UTC())
+	}))
+	return nil
+}
+
+// Run starts the schedules and blocks until ctx is cancelled. Running tasks are
+// then cancelled and waited for.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.cron.Start()
+	<-ctx.Done()
+
+	stopped := s.cron.Stop()
+	s.cancel()
+	<-stopped.Done()
+}
+
+// run executes the run due at now if this replica wins its claim
+func (s *Scheduler) run(name string, timeout time.Duration, task Task, now time.Time) {
+	// Cron fires on whole minutes, so replicas agree on the run's key even if
+	// their clocks differ slightly
+	runAt := now.Truncate(time.Minute)
+
+	claimed, err := s.repo.Claim(name, s.holder, runAt, now, now.Add(timeout+time.Minute))
+	if err != nil {
+		log.Printf("scheduler: claim %s: %v", name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	started := time.Now()
+	err = s.execute(timeout, task)
+
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		log.Printf("scheduler: %s failed after %s: %v", name, time.Since(started).Round(time.Millisecond), err)
+	}
+	if err := s.repo.Finish(name, s.holder, time.Now().UTC(), lastError); err != nil {
+		log.Printf("scheduler: finish %s: %v", name, err)
+	}
+}
+
+// execute runs the task with its timeout, turning panics into errors
+func (s *Scheduler) execute(timeout time.Duration, task Task) (err error) {
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
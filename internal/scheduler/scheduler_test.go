@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// newReplicas creates schedulers sharing one database, as replicas do
+func newReplicas(t *testing.T, n int) ([]*Scheduler, *gorm.DB) {
+	t.Helper()
+	db := testdb.Open(t)
+	repo := repository.NewScheduledTaskRepository(db)
+	replicas := make([]*Scheduler, n)
+	for i := range replicas {
+		replicas[i] = New(repo)
+		replicas[i].holder = fmt.Sprintf("replica-%d", i)
+	}
+	return replicas, db
+}
+
+func TestRunIsClaimedOncePerTick(t *testing.T) {
+	replicas, _ := newReplicas(t, 2)
+
+	var runs atomic.Int32
+	task := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	tick := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i, s := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Replica clocks differ by a few seconds within the same minute
+			s.run("prune", time.Minute, task, tick.Add(time.Duration(i)*time.Second))
+		}()
+	}
+	wg.Wait()
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("expected one run of the tick, got %d", n)
+	}
+
+	// A late replica does not repeat a finished run either
+	replicas[1].run("prune", time.Minute, task, tick.Add(30*time.Second))
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("expected the finished tick not to run again, got %d runs", n)
+	}
+
+	// The next tick runs again, on whichever replica claims it
+	replicas[1].run("prune", time.Minute, task, tick.Add(time.Minute))
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("expected the next tick to run, got %d runs", n)
+	}
+}
+
+func TestRunSkipsWhileLocked(t *testing.T) {
+	replicas, _ := newReplicas(t, 2)
+	tick := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+
+	// The first replica is still running the previous tick when the next is due
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		replicas[0].run("digest", time.Hour, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}, tick)
+	}()
+	<-started
+
+	ran := false
+	replicas[1].run("digest", time.Hour, func(ctx context.Context) error {
+		ran = true
+		return nil
+	}, tick.Add(time.Minute))
+	close(release)
+	<-done
+
+	if ran {
+		t.Fatal("expected the next tick to be skipped while the task is locked")
+	}
+}
+
+func TestRunRecordsFailures(t *testing.T) {
+	replicas, db := newReplicas(t, 1)
+	s := replicas[0]
+	tick := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+
+	s.run("expire", time.Minute, func(ctx context.Context) error {
+		return errors.New("database is gone")
+	}, tick)
+
+	var task models.ScheduledTask
+	if err := db.First(&task, "name = ?", "expire").Error; err != nil {
+		t.Fatal(err)
+	}
+	if task.LastError != "database is gone" {
+		t.Fatalf("expected the error to be recorded, got %q", task.LastError)
+	}
+	if task.LockedBy != "" || task.LockedUntil != nil || task.LastFinishedAt == nil {
+		t.Fatalf("expected the task to be released, got %+v", task)
+	}
+
+	// Panics are recorded like errors and do not take the scheduler down
+	s.run("expire", time.Minute, func(ctx context.Context) error {
+		panic("boom")
+	}, tick.Add(time.Minute))
+	if err := db.First(&task, "name = ?", "expire").Error; err != nil {
+		t.Fatal(err)
+	}
+	if task.LastError != "panic: boom" {
+		t.Fatalf("expected the panic to be recorded, got %q", task.LastError)
+	}
+}
+
+func TestRunCutsOffAtTimeout(t *testing.T) {
+	replicas, _ := newReplicas(t, 1)
+	tick := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+
+	var err error
+	replicas[0].run("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		err = ctx.Err()
+		return err
+	}, tick)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the task to be cancelled at its timeout, got %v", err)
+	}
+}
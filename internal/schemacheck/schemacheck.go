@@ -0,0 +1,333 @@
+// Package schemacheck detects drift between the GORM models and the schema the
+// SQL migrations create. The migrations are applied to a scratch database, which
+// is then introspected and compared with the model definitions.
+package schemacheck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"github.com/you/pawtrack/internal/migrator"
+	"github.com/you/pawtrack/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Drift is a difference between a model and its table
+type Drift struct {
+	Table   string
+	Object  string // Column or index, empty for the table itself
+	Problem string
+}
+
+func (d Drift) String() string {
+	if d.Object == "" {
+		return d.Table + ": " + d.Problem
+	}
+	return d.Table + "." + d.Object + ": " + d.Problem
+}
+
+// Run applies all migrations of dbType to a scratch database and compares the
+// result with the models. SQLite uses a temporary file; Postgres uses a temporary
+// schema in the database at databaseURL, which is dropped afterwards.
+func Run(dbType, databaseURL string) ([]Drift, error) {
+	switch dbType {
+	case dialect.SQLite:
+		return runSQLite()
+	case dialect.Postgres:
+		return runPostgres(databaseURL)
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", dbType)
+	}
+}
+
+func runSQLite() ([]Drift, error) {
+	dir, err := os.MkdirTemp("", "pawtrack-schemacheck-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scratch.db")
+
+	if err := migrate(dialect.SQLite, "sqlite3://"+path+"?_fk=1"); err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?_fk=1"), quiet())
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB(db)
+	return Check(db, models.All())
+}
+
+func runPostgres(databaseURL string) ([]Drift, error) {
+	if databaseURL == "" {
+		return nil, fmt.Errorf("a Postgres database URL is required")
+	}
+	admin, err := gorm.Open(postgres.Open(databaseURL), quiet())
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB(admin)
+
+	scratch := fmt.Sprintf("pawtrack_schemacheck_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + scratch).Error; err != nil {
+		return nil, err
+	}
+	defer admin.Exec("DROP SCHEMA " + scratch + " CASCADE")
+
+	scratchURL := withParam(databaseURL, "search_path", scratch)
+	if err := migrate(dialect.Postgres, scratchURL); err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(postgres.Open(scratchURL), quiet())
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB(db)
+	return Check(db, models.All())
+}
+
+// Check compares the tables of an already migrated database with the models
+func Check(db *gorm.DB, values []interface{}) ([]Drift, error) {
+	var drifts []Drift
+	for _, value := range values {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(value); err != nil {
+			return nil, err
+		}
+		found, err := checkTable(db, stmt.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", stmt.Schema.Table, err)
+		}
+		drifts = append(drifts, found...)
+	}
+	return drifts, nil
+}
+
+func checkTable(db *gorm.DB, sch *schema.Schema) ([]Drift, error) {
+	table := sch.Table
+	if !db.Migrator().HasTable(table) {
+		return []Drift{{Table: table, Problem: "table is missing"}}, nil
+	}
+
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, c := range columnTypes {
+		columns[c.Name()] = c
+	}
+
+	var drifts []Drift
+	for _, name := range sch.DBNames {
+		field := sch.FieldsByDBName[name]
+		if field.IgnoreMigration {
+			continue
+		}
+		column, ok := columns[name]
+		if !ok {
+			drifts = append(drifts, Drift{Table: table, Object: name, Problem: "column is missing"})
+			continue
+		}
+		drifts = append(drifts, checkColumn(table, field, column)...)
+	}
+
+	// Columns the model does not know about break inserts if they need a value
+	for _, column := range columnTypes {
+		if _, ok := sch.FieldsByDBName[column.Name()]; ok {
+			continue
+		}
+		nullable, _ := column.Nullable()
+		_, hasDefault := column.DefaultValue()
+		if !nullable && !hasDefault {
+			drifts = append(drifts, Drift{Table: table, Object: column.Name(),
+				Problem: "column is NOT NULL without a default but not in the model"})
+		}
+	}
+
+	indexes, err := listIndexes(db, table)
+	if err != nil {
+		return nil, err
+	}
+	declared := modelIndexes(sch)
+	for _, want := range declared {
+		if !hasIndex(indexes, want) {
+			drifts = append(drifts, Drift{Table: table, Object: want.Name, Problem: want.describe() + " is missing"})
+		}
+	}
+
+	// Unique indexes change behavior (conflicts, upserts), so the model must declare them
+	primary := strings.Join(sch.PrimaryFieldDBNames, ",")
+	for _, got := range indexes {
+		if got.Unique && strings.Join(got.Columns, ",") != primary && !hasIndex(declared, got) {
+			drifts = append(drifts, Drift{Table: table, Object: got.Name, Problem: got.describe() + " is not declared in the model"})
+		}
+	}
+	return drifts, nil
+}
+
+// checkColumn compares the type, length and nullability of a column
+func checkColumn(table string, field *schema.Field, column gorm.ColumnType) []Drift {
+	var drifts []Drift
+	add := func(format string, args ...interface{}) {
+		drifts = append(drifts, Drift{Table: table, Object: field.DBName, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	want, got := typeFamily(string(field.DataType)), typeFamily(column.DatabaseTypeName())
+	if want != got {
+		add("type is %s in the model, %s (%s) in the database", want, got, column.DatabaseTypeName())
+		return drifts
+	}
+	if length, ok := column.Length(); ok && length > 0 && field.Size > 0 && want == "text" && int64(field.Size) != length {
+		add("length is %d in the model, %d in the database", field.Size, length)
+	}
+	if nullable, ok := column.Nullable(); ok && nullable && field.NotNull && !field.PrimaryKey {
+		add("NOT NULL in the model, nullable in the database")
+	}
+	return drifts
+}
+
+// index is an index of a table, from the model or the database
+type index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+func (i index) describe() string {
+	kind := "index"
+	if i.Unique {
+		kind = "unique index"
+	}
+	return kind + " on (" + strings.Join(i.Columns, ", ") + ")"
+}
+
+// modelIndexes returns the indexes declared with index/uniqueIndex/unique tags
+func modelIndexes(sch *schema.Schema) []index {
+	var indexes []index
+	for _, idx := range sch.ParseIndexes() {
+		want := index{Name: idx.Name, Unique: idx.Class == "UNIQUE"}
+		for _, option := range idx.Fields {
+			want.Columns = append(want.Columns, option.DBName)
+		}
+		indexes = append(indexes, want)
+	}
+	for _, field := range sch.Fields {
+		if field.Unique && !field.PrimaryKey && field.DBName != "" {
+			indexes = append(indexes, index{Name: field.DBName, Columns: []string{field.DBName}, Unique: true})
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes
+}
+
+// hasIndex reports whether an index on the same columns exists; indexes are
+// matched by columns since migrations and GORM name them differently
+func hasIndex(indexes []index, want index) bool {
+	for _, got := range indexes {
+		if strings.Join(got.Columns, ",") == strings.Join(want.Columns, ",") && (got.Unique || !want.Unique) {
+			return true
+		}
+	}
+	return false
+}
+
+// listIndexes returns all indexes of a table, including those backing primary
+// keys and UNIQUE constraints
+func listIndexes(db *gorm.DB, table string) ([]index, error) {
+	var rows []struct {
+		Name    string
+		Unique  bool
+		Columns string
+	}
+	var err error
+	if dialect.Of(db).Name() == dialect.SQLite {
+		err = db.Raw(`SELECT il.name AS name, il."unique" AS "unique",
+				(SELECT group_concat(name, ',') FROM (SELECT name FROM pragma_index_info(il.name) ORDER BY seqno)) AS columns
+			FROM pragma_index_list(?) AS il`, table).Scan(&rows).Error
+	} else {
+		err = db.Raw(`SELECT ci.relname AS name, i.indisunique AS "unique",
+				array_to_string(ARRAY(
+					SELECT a.attname FROM unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+					JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+					ORDER BY k.ord), ',') AS columns
+			FROM pg_index i
+			JOIN pg_class ct ON ct.oid = i.indrelid
+			JOIN pg_class ci ON ci.oid = i.indexrelid
+			JOIN pg_namespace n ON n.oid = ct.relnamespace
+			WHERE n.nspname = current_schema() AND ct.relname = ?`, table).Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]index, 0, len(rows))
+	for _, row := range rows {
+		indexes = append(indexes, index{Name: row.Name, Unique: row.Unique, Columns: strings.Split(row.Columns, ",")})
+	}
+	return indexes, nil
+}
+
+// typeFamily maps model and database types to a comparable family, since the
+// same column is e.g. "uint" in GORM, "int4" in Postgres and "INTEGER" in SQLite
+func typeFamily(t string) string {
+	t = strings.ToLower(t)
+	switch {
+	case strings.Contains(t, "int"), strings.Contains(t, "serial"):
+		return "integer"
+	case strings.Contains(t, "bool"):
+		return "boolean"
+	case strings.Contains(t, "char"), strings.Contains(t, "text"), strings.Contains(t, "string"), strings.Contains(t, "clob"):
+		return "text"
+	case strings.Contains(t, "time"), strings.Contains(t, "date"):
+		return "timestamp"
+	case strings.Contains(t, "real"), strings.Contains(t, "float"), strings.Contains(t, "double"), strings.Contains(t, "numeric"), strings.Contains(t, "decimal"):
+		return "float"
+	case strings.Contains(t, "blob"), strings.Contains(t, "bytea"), strings.Contains(t, "bytes"):
+		return "binary"
+	}
+	return t
+}
+
+func migrate(dbType, databaseURL string) error {
+	mg, err := migrator.New(dbType, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+	mg.Quiet()
+	return mg.Up()
+}
+
+// withParam adds a query parameter to a URL or a key=value DSN
+func withParam(dsn, key, value string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " " + key + "=" + value
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + key + "=" + value
+	}
+	return dsn + "?" + key + "=" + value
+}
+
+func quiet() *gorm.Config {
+	return &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,83 @@
+package schemacheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"github.com/you/pawtrack/internal/migrator"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSQLiteMigrationsMatchModels(t *testing.T) {
+	RequireNoDrift(t, dialect.SQLite, "")
+}
+
+// Set SCHEMA_CHECK_DATABASE_URL to a Postgres database to check its migrations;
+// the check runs in a temporary schema
+func TestPostgresMigrationsMatchModels(t *testing.T) {
+	url := os.Getenv("SCHEMA_CHECK_DATABASE_URL")
+	if url == "" {
+		t.Skip("SCHEMA_CHECK_DATABASE_URL is not set")
+	}
+	RequireNoDrift(t, dialect.Postgres, url)
+}
+
+// driftModel disagrees with the migrated events table in every checked way
+type driftModel struct {
+	ID       uint
+	Type     string `gorm:"size:50;not null;uniqueIndex"`
+	Note     int
+	At       string `gorm:"size:100"`
+	Missing  string `gorm:"index"`
+	ClientID *string
+}
+
+func (driftModel) TableName() string { return "events" }
+
+func TestCheckReportsDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drift.db")
+	mg, err := migrator.New(dialect.SQLite, "sqlite3://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mg.Quiet()
+	if err := mg.Up(); err != nil {
+		t.Fatal(err)
+	}
+	mg.Close()
+
+	db, err := gorm.Open(sqlite.Open(path), quiet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+
+	drifts, err := Check(db, []interface{}{&driftModel{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]bool)
+	for _, drift := range drifts {
+		got[drift.String()] = true
+	}
+	want := []string{
+		"events.note: type is integer in the model, text (VARCHAR) in the database",
+		"events.at: type is text in the model, timestamp (DATETIME) in the database",
+		"events.missing: column is missing",
+		"events.idx_events_missing: index on (missing) is missing",
+		"events.idx_events_type: unique index on (type) is missing",
+		"events.idx_events_client_id: unique index on (client_id) is not declared in the model",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing drift %q, got %v", w, drifts)
+		}
+	}
+	if len(drifts) != len(want) {
+		t.Errorf("got %d drifts, want %d: %v", len(drifts), len(want), drifts)
+	}
+}
@@ -0,0 +1,16 @@
+package schemacheck
+
+import "testing"
+
+// RequireNoDrift fails the test if the migrations of dbType do not match the models
+func RequireNoDrift(t testing.TB, dbType, databaseURL string) {
+	t.Helper()
+
+	drifts, err := Run(dbType, databaseURL)
+	if err != nil {
+		t.Fatalf("schema check: %v", err)
+	}
+	for _, drift := range drifts {
+		t.Errorf("schema drift: %s", drift)
+	}
+}
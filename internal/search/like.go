@@ -0,0 +1,100 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxLikeHighlight is the length in characters of highlights built by likeEngine
+const maxLikeHighlight = 200
+
+// likeEngine is the fallback without a full-text index: every word must occur in
+// one of the fields. Results are not ranked and the table is scanned.
+type likeEngine struct {
+	db *gorm.DB
+}
+
+func (e *likeEngine) Name() string { return "like" }
+
+func (e *likeEngine) Match(query *gorm.DB, doc Document, terms string, or ...clause.Expression) *gorm.DB {
+	words := make([]string, 0, len(strings.Fields(terms)))
+	var vars []interface{}
+	for _, word := range strings.Fields(terms) {
+		conditions := make([]string, 0, len(doc.Fields))
+		for _, field := range doc.Fields {
+			conditions = append(conditions, "?")
+			vars = append(vars, Contains(doc.Table+"."+field, word))
+		}
+		words = append(words, "("+strings.Join(conditions, " OR ")+")")
+	}
+	condition, vars := orAlternatives(strings.Join(words, " AND "), vars, or)
+	return query.Where(condition, vars...)
+}
+
+func (e *likeEngine) Rank(doc Document, terms string) clause.Expression { return nil }
+
+func (e *likeEngine) Highlight(doc Document, terms string, ids []uint) (map[uint]string, error) {
+	if len(ids) == 0 {
+		return map[uint]string{}, nil
+	}
+
+	var rows []highlightRow
+	err := e.db.Table(doc.Table).
+		Select("id, COALESCE("+doc.Highlight+", '') AS highlight").
+		Where("id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(terms)
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, regexp.QuoteMeta(word))
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	for i := range rows {
+		rows[i].Highlight = markMatches(rows[i].Highlight, re)
+	}
+	return collectHighlights(rows), nil
+}
+
+// markMatches marks the matches of re in a window of text around the first one
+func markMatches(text string, re *regexp.Regexp) string {
+	if utf8.RuneCountInString(text) > maxLikeHighlight {
+		start := 0
+		if loc := re.FindStringIndex(text); loc != nil {
+			// Start a few words before the first match
+			start = loc[0] - maxLikeHighlight/4
+			for start > 0 && !utf8.RuneStart(text[start]) {
+				start--
+			}
+			if start < 0 {
+				start = 0
+			}
+		}
+		window := []rune(text[start:])
+		truncated := len(window) > maxLikeHighlight
+		if truncated {
+			window = window[:maxLikeHighlight]
+		}
+		text = string(window)
+		if start > 0 {
+			text = "…" + text
+		}
+		if truncated {
+			text += "…"
+		}
+	}
+	return re.ReplaceAllString(text, markStart+"$0"+markEnd)
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// textSearchConfig is the Postgres text search configuration of the search_vector
+// columns; "russian" stems Russian words and English (ASCII) ones
+const textSearchConfig = "russian"
+
+// headlineOptions limit highlights to a few fragments around the matches
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markEnd + ", MaxFragments=3, MaxWords=20, MinWords=8, FragmentDelimiter=\" … \""
+
+// postgresEngine searches the generated search_vector columns (GIN indexed)
+type postgresEngine struct {
+	db *gorm.DB
+}
+
+func (e *postgresEngine) Name() string { return "postgres" }
+
+// Match uses websearch_to_tsquery, which accepts any user input: words, "quoted
+// phrases", OR and -excluded words
+func (e *postgresEngine) Match(query *gorm.DB, doc Document, terms string, or ...clause.Expression) *gorm.DB {
+	condition, vars := orAlternatives(doc.Table+".search_vector @@ websearch_to_tsquery('"+textSearchConfig+"', ?)", []interface{}{terms}, or)
+	return query.Where(condition, vars...)
+}
+
+func (e *postgresEngine) Rank(doc Document, terms string) clause.Expression {
+	return clause.Expr{
+		SQL:  "ts_rank(" + doc.Table + ".search_vector, websearch_to_tsquery('" + textSearchConfig + "', ?)) DESC",
+		Vars: []interface{}{terms},
+	}
+}
+
+func (e *postgresEngine) Highlight(doc Document, terms string, ids []uint) (map[uint]string, error) {
+	if len(ids) == 0 {
+		return map[uint]string{}, nil
+	}
+
+	var rows []highlightRow
+	err := e.db.Table(doc.Table).
+		Select("id, ts_headline('"+textSearchConfig+"', COALESCE("+doc.Highlight+", ''), websearch_to_tsquery('"+textSearchConfig+"', ?), ?) AS highlight", terms, headlineOptions).
+		Where("id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return collectHighlights(rows), nil
+}
@@ -0,0 +1,105 @@
+// Package search provides ranked full-text search over events and consultant notes.
+//
+// Postgres uses generated tsvector columns with GIN indexes (see migrations), SQLite
+// uses FTS5 tables kept in sync by triggers when built with the sqlite_fts5 tag, and
+// falls back to LIKE otherwise.
+package search
+
+import (
+	"html"
+	"strings"
+
+	"github.com/you/pawtrack/internal/dialect"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Document describes a searchable table
+type Document struct {
+	Table     string   // Searched table, e.g. "events"
+	Fields    []string // Indexed columns, most important first
+	Highlight string   // Column returned with matches marked
+}
+
+// Searchable documents
+var (
+	Events = Document{Table: "events", Fields: []string{"note"}, Highlight: "note"}
+	Notes  = Document{Table: "consultant_notes", Fields: []string{"title", "content"}, Highlight: "content"}
+)
+
+// Engine applies full-text search to queries of a document's table
+type Engine interface {
+	// Name identifies the engine, e.g. "postgres", "fts5" or "like"
+	Name() string
+	// Match restricts the query to rows matching the terms, or any of the
+	// alternative conditions, e.g. a match on a related table
+	Match(query *gorm.DB, doc Document, terms string, or ...clause.Expression) *gorm.DB
+	// Rank returns an ORDER BY expression putting the best matches first, or nil
+	// if the engine cannot rank. The query must have been passed through Match.
+	Rank(doc Document, terms string) clause.Expression
+	// Highlight returns the highlighted column of the given rows, HTML-escaped,
+	// with matches wrapped in <mark>
+	Highlight(doc Document, terms string, ids []uint) (map[uint]string, error)
+}
+
+// New returns the engine for the database's dialect
+func New(db *gorm.DB) (Engine, error) {
+	switch db.Dialector.Name() {
+	case dialect.Postgres:
+		return &postgresEngine{db: db}, nil
+	case dialect.SQLite:
+		return newSQLiteEngine(db)
+	default:
+		return &likeEngine{db: db}, nil
+	}
+}
+
+// Contains is a case-insensitive substring match of the text on a column, for use
+// as an alternative condition of Match
+func Contains(column, text string) clause.Expression {
+	return clause.Expr{
+		SQL:  "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '\\'",
+		Vars: []interface{}{"%" + escapeLike(text) + "%"},
+	}
+}
+
+// orAlternatives appends the alternative conditions of Match to a condition
+func orAlternatives(condition string, vars []interface{}, or []clause.Expression) (string, []interface{}) {
+	if len(or) == 0 {
+		return condition, vars
+	}
+	for _, alternative := range or {
+		condition += " OR ?"
+		vars = append(vars, alternative)
+	}
+	return "(" + condition + ")", vars
+}
+
+// Engines mark matches with control characters, so that the text can be escaped
+// before the markers are turned into tags
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// finishHighlight escapes the text and turns match markers into <mark> tags
+func finishHighlight(marked string) string {
+	escaped := html.EscapeString(marked)
+	escaped = strings.ReplaceAll(escaped, markStart, "<mark>")
+	return strings.ReplaceAll(escaped, markEnd, "</mark>")
+}
+
+// highlightRow is a row of a highlight query
+type highlightRow struct {
+	ID        uint
+	Highlight string
+}
+
+// collectHighlights maps highlight rows by ID
+func collectHighlights(rows []highlightRow) map[uint]string {
+	highlights := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		highlights[row.ID] = finishHighlight(row.Highlight)
+	}
+	return highlights
+}
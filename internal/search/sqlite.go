@@ -0,0 +1,19 @@
+//go:build !sqlite_fts5 && !fts5
+
+package search
+
+import "gorm.io/gorm"
+
+// newSQLiteEngine falls back to LIKE in builds without FTS5. Triggers left by an
+// FTS5 build are dropped, since writes would fail on the missing module; an FTS5
+// build rebuilds its index when it finds them missing.
+func newSQLiteEngine(db *gorm.DB) (Engine, error) {
+	for _, doc := range []Document{Events, Notes} {
+		for _, suffix := range []string{"_ai", "_ad", "_au"} {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + doc.Table + "_fts" + suffix).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &likeEngine{db: db}, nil
+}
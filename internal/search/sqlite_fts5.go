@@ -0,0 +1,120 @@
+//go:build sqlite_fts5 || fts5
+
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ftsEngine searches FTS5 tables named <table>_fts. They are external content
+// tables over the document's table, kept in sync by triggers.
+type ftsEngine struct {
+	db *gorm.DB
+}
+
+// newSQLiteEngine creates the FTS5 tables and triggers if missing. FTS5 depends on
+// the build, so this happens at startup rather than in migrations.
+func newSQLiteEngine(db *gorm.DB) (Engine, error) {
+	for _, doc := range []Document{Events, Notes} {
+		if err := setupFTS(db, doc); err != nil {
+			return nil, fmt.Errorf("set up full-text search for %s: %w", doc.Table, err)
+		}
+	}
+	return &ftsEngine{db: db}, nil
+}
+
+// setupFTS creates the index of a document. When the triggers are missing, the
+// table was written without them (new index, or a build without FTS5 dropped them),
+// so the index is rebuilt from the content table.
+func setupFTS(db *gorm.DB, doc Document) error {
+	fts := doc.Table + "_fts"
+	columns := strings.Join(doc.Fields, ", ")
+	newValues := "new." + strings.Join(doc.Fields, ", new.")
+	oldValues := "old." + strings.Join(doc.Fields, ", old.")
+
+	var triggers int64
+	err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ?",
+		[]string{fts + "_ai", fts + "_ad", fts + "_au"}).Scan(&triggers).Error
+	if err != nil || triggers == 3 {
+		return err
+	}
+
+	statements := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content='%s', content_rowid='id', tokenize='porter unicode61 remove_diacritics 2')", fts, columns, doc.Table),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_ai AFTER INSERT ON %s BEGIN INSERT INTO %s(rowid, %s) VALUES (new.id, %s); END", fts, doc.Table, fts, columns, newValues),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_ad AFTER DELETE ON %s BEGIN INSERT INTO %s(%s, rowid, %s) VALUES ('delete', old.id, %s); END", fts, doc.Table, fts, fts, columns, oldValues),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_au AFTER UPDATE OF %s ON %s BEGIN INSERT INTO %s(%s, rowid, %s) VALUES ('delete', old.id, %s); INSERT INTO %s(rowid, %s) VALUES (new.id, %s); END", fts, columns, doc.Table, fts, fts, columns, oldValues, fts, columns, newValues),
+		fmt.Sprintf("INSERT INTO %s(%s) VALUES ('rebuild')", fts, fts),
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (e *ftsEngine) Name() string { return "fts5" }
+
+// Match joins the rows matching in the index. With alternatives the join is an
+// outer one, since FTS5 cannot evaluate MATCH as part of an OR across tables.
+func (e *ftsEngine) Match(query *gorm.DB, doc Document, terms string, or ...clause.Expression) *gorm.DB {
+	fts := doc.Table + "_fts"
+	join := "JOIN"
+	if len(or) > 0 {
+		join = "LEFT JOIN"
+	}
+	query = query.Joins(join+" (SELECT rowid AS id, rank FROM "+fts+" WHERE "+fts+" MATCH ?) "+fts+" ON "+fts+".id = "+doc.Table+".id", ftsQuery(terms))
+	if len(or) == 0 {
+		return query
+	}
+	condition, vars := orAlternatives(fts+".id IS NOT NULL", nil, or)
+	return query.Where(condition, vars...)
+}
+
+// Rank orders by FTS5's bm25 rank, where lower is better. Rows matched only by an
+// alternative have no rank and come after the index matches.
+func (e *ftsEngine) Rank(doc Document, terms string) clause.Expression {
+	return clause.Expr{SQL: "COALESCE(" + doc.Table + "_fts.rank, 0)"}
+}
+
+func (e *ftsEngine) Highlight(doc Document, terms string, ids []uint) (map[uint]string, error) {
+	if len(ids) == 0 {
+		return map[uint]string{}, nil
+	}
+
+	column := 0
+	for i, field := range doc.Fields {
+		if field == doc.Highlight {
+			column = i
+		}
+	}
+
+	fts := doc.Table + "_fts"
+	var rows []highlightRow
+	err := e.db.Table(fts).
+		Select(fmt.Sprintf("rowid AS id, snippet(%s, %d, ?, ?, '…', 24) AS highlight", fts, column), markStart, markEnd).
+		Where(fts+" MATCH ? AND rowid IN ?", ftsQuery(terms), ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return collectHighlights(rows), nil
+}
+
+// ftsQuery turns user input into an FTS5 query: every word must match, as a
+// prefix, and FTS5 syntax characters are taken literally
+func ftsQuery(terms string) string {
+	words := strings.Fields(terms)
+	parts := make([]string, 0, len(words))
+	for _, word := range words {
+		parts = append(parts, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(parts, " ")
+}
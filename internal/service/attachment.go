@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/permissions"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// ErrAttachmentForbidden is returned when the user can see the owner of the
+// attachments but may not change or remove them
+var ErrAttachmentForbidden = errors.New("forbidden")
+
+// AttachmentService interface for attachment business logic
+type AttachmentService interface {
+	GetAttachment(id uint, userID uint, role models.UserRole) (*models.Attachment, error)
+	AddToEvent(eventID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error)
+	AddToComment(commentID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error)
+	AddToNote(noteID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error)
+	DeleteAttachment(id uint, userID uint, role models.UserRole) (*models.Attachment, error)
+}
+
+// attachmentService implementation of the attachment service
+type attachmentService struct {
+	repo        repository.AttachmentRepository
+	eventRepo   repository.EventRepository
+	commentRepo repository.EventCommentRepository
+	noteRepo    repository.ConsultantNoteRepository
+	dogRepo     repository.DogRepository
+	permRepo    repository.PermissionRepository
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(
+	repo repository.AttachmentRepository,
+	eventRepo repository.EventRepository,
+	commentRepo repository.EventCommentRepository,
+	noteRepo repository.ConsultantNoteRepository,
+	dogRepo repository.DogRepository,
+	permRepo repository.PermissionRepository,
+) AttachmentService {
+	return &attachmentService{
+		repo:        repo,
+		eventRepo:   eventRepo,
+		commentRepo: commentRepo,
+		noteRepo:    noteRepo,
+		dogRepo:     dogRepo,
+		permRepo:    permRepo,
+	}
+}
+
+// GetAttachment returns an attachment if the user can see its owner: the event
+// of an event or comment attachment, or the note
+func (s *attachmentService) GetAttachment(id uint, userID uint, role models.UserRole) (*models.Attachment, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(attachment, userID, role, false); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// AddToEvent attaches files to an event the user can see
+func (s *attachmentService) AddToEvent(eventID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error) {
+	if err := s.checkEventAccess(eventID, userID, role); err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		attachments[i].EventID = &eventID
+	}
+	if err := s.repo.Create(attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// AddToComment attaches files to a comment of the user
+func (s *attachmentService) AddToComment(commentID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error) {
+	if err := s.checkCommentAccess(commentID, userID, role, true); err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		attachments[i].CommentID = &commentID
+	}
+	if err := s.repo.Create(attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// AddToNote attaches files to a note of the consultant
+func (s *attachmentService) AddToNote(noteID uint, attachments []models.Attachment, userID uint, role models.UserRole) ([]models.Attachment, error) {
+	if err := s.checkNoteAccess(noteID, userID, role); err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		attachments[i].NoteID = &noteID
+	}
+	if err := s.repo.Create(attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment removes an attachment if the user may delete its event, or
+// may change its comment or note. The deleted attachment is returned so that the
+// caller can remove the file.
+func (s *attachmentService) DeleteAttachment(id uint, userID uint, role models.UserRole) (*models.Attachment, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(attachment, userID, role, true); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Delete(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// authorize checks access to the owner of an attachment. Comment attachments
+// are visible with the event and changed by the comment's author; event
+// attachments are removed by those who may delete the event.
+func (s *attachmentService) authorize(attachment *models.Attachment, userID uint, role models.UserRole, modify bool) error {
+	switch {
+	case attachment.EventID != nil && modify:
+		return s.checkEventDelete(*attachment.EventID, userID, role)
+	case attachment.EventID != nil:
+		return s.checkEventAccess(*attachment.EventID, userID, role)
+	case attachment.CommentID != nil:
+		return s.checkCommentAccess(*attachment.CommentID, userID, role, modify)
+	case attachment.NoteID != nil:
+		return s.checkNoteAccess(*attachment.NoteID, userID, role)
+	default:
+		return errors.New("unauthorized")
+	}
+}
+
+// checkEventAccess verifies that the user can see the event's dog
+func (s *attachmentService) checkEventAccess(eventID uint, userID uint, role models.UserRole) error {
+	_, err := s.eventDog(eventID, userID, role)
+	return err
+}
+
+// checkEventDelete verifies that the user can see the event and may delete it:
+// with EVENTS_DELETE_ALL, or EVENTS_DELETE_OWN for events of the user's dogs
+func (s *attachmentService) checkEventDelete(eventID uint, userID uint, role models.UserRole) error {
+	dog, err := s.eventDog(eventID, userID, role)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleAdmin {
+		return nil
+	}
+
+	granted, err := s.permRepo.HasPermission(userID, permissions.EVENTS_DELETE_ALL)
+	if err != nil {
+		return err
+	}
+	if !granted && dog.OwnerID == userID {
+		if granted, err = s.permRepo.HasPermission(userID, permissions.EVENTS_DELETE_OWN); err != nil {
+			return err
+		}
+	}
+	if !granted {
+		return ErrAttachmentForbidden
+	}
+	return nil
+}
+
+// eventDog returns the event's dog if the user can see it. Events without a dog
+// are only visible to admins, for whom nil is returned.
+func (s *attachmentService) eventDog(eventID uint, userID uint, role models.UserRole) (*models.Dog, error) {
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.DogID == nil {
+		if role != models.RoleAdmin {
+			return nil, errors.New("unauthorized")
+		}
+		return nil, nil
+	}
+
+	dog, err := s.dogRepo.GetByID(*event.DogID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDogAccess(s.dogRepo, dog, userID, role); err != nil {
+		return nil, err
+	}
+	return dog, nil
+}
+
+// checkCommentAccess verifies that the user can see the comment's event and,
+// to modify, that the user wrote the comment
+func (s *attachmentService) checkCommentAccess(commentID uint, userID uint, role models.UserRole, modify bool) error {
+	comment, err := s.commentRepo.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEventAccess(comment.EventID, userID, role); err != nil {
+		return err
+	}
+	if modify && role != models.RoleAdmin && comment.UserID != userID {
+		return ErrAttachmentForbidden
+	}
+	return nil
+}
+
+// checkNoteAccess verifies that the note is the consultant's own, as notes are
+// only visible to their author and admins
+func (s *attachmentService) checkNoteAccess(noteID uint, userID uint, role models.UserRole) error {
+	note, err := s.noteRepo.GetByID(noteID)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleAdmin && note.ConsultantID != userID {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
 	"github.com/you/pawtrack/internal/models"
 	"github.com/you/pawtrack/internal/permissions"
 	"github.com/you/pawtrack/internal/repository"
@@ -21,16 +22,18 @@ type ConsultantService interface {
 }
 
 type consultantService struct {
-	repo     repository.ConsultantRepository
-	dogRepo  repository.DogRepository
-	permRepo repository.PermissionRepository
+	repo      repository.ConsultantRepository
+	dogRepo   repository.DogRepository
+	permRepo  repository.PermissionRepository
+	publisher events.Publisher
 }
 
-func NewConsultantService(repo repository.ConsultantRepository, dogRepo repository.DogRepository, permRepo repository.PermissionRepository) ConsultantService {
+func NewConsultantService(repo repository.ConsultantRepository, dogRepo repository.DogRepository, permRepo repository.PermissionRepository, publisher events.Publisher) ConsultantService {
 	return &consultantService{
-		repo:     repo,
-		dogRepo:  dogRepo,
-		permRepo: permRepo,
+		repo:      repo,
+		dogRepo:   dogRepo,
+		permRepo:  permRepo,
+		publisher: publisher,
 	}
 }
 
@@ -126,8 +129,8 @@ func (s *consultantService) AcceptInvite(token string, consultantID uint) error
 	}
 
 	if time.Now().After(invite.ExpiresAt) {
-		invite.Status = models.InviteRejected // Or expired
-		s.repo.UpdateInviteStatus(invite)
+		invite.Status = models.InviteExpired
+		s.repo.UpdateInviteStatus(invite, nil)
 		return errors.New("invite expired")
 	}
 
@@ -153,7 +156,9 @@ func (s *consultantService) AcceptInvite(token string, consultantID uint) error
 	s.permRepo.GrantPermissions(consultantID, permissions.ConsultantAssignedPermissions)
 
 	invite.Status = models.InviteAccepted
-	return s.repo.UpdateInviteStatus(invite)
+	return s.repo.UpdateInviteStatus(invite, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		return []events.Event{&events.InviteAccepted{Invite: invite}}, nil
+	}))
 }
 
 func (s *consultantService) toDTO(p *models.ConsultantProfile) *dto.ConsultantProfileResponse {
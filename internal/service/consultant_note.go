@@ -5,8 +5,10 @@ import (
 	"math"
 
 	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
 	"github.com/you/pawtrack/internal/models"
 	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
 )
 
 type ConsultantNoteService interface {
@@ -18,14 +20,16 @@ type ConsultantNoteService interface {
 }
 
 type consultantNoteService struct {
-	noteRepo repository.ConsultantNoteRepository
-	dogRepo  repository.DogRepository
+	noteRepo  repository.ConsultantNoteRepository
+	dogRepo   repository.DogRepository
+	publisher events.Publisher
 }
 
-func NewConsultantNoteService(noteRepo repository.ConsultantNoteRepository, dogRepo repository.DogRepository) ConsultantNoteService {
+func NewConsultantNoteService(noteRepo repository.ConsultantNoteRepository, dogRepo repository.DogRepository, publisher events.Publisher) ConsultantNoteService {
 	return &consultantNoteService{
-		noteRepo: noteRepo,
-		dogRepo:  dogRepo,
+		noteRepo:  noteRepo,
+		dogRepo:   dogRepo,
+		publisher: publisher,
 	}
 }
 
@@ -44,9 +48,17 @@ func (s *consultantNoteService) CreateNote(req *dto.CreateNoteRequest, consultan
 		DogID:        req.DogID,
 		Title:        req.Title,
 		Content:      req.Content,
+		ClientID:     req.ClientID,
 	}
 
-	err = s.noteRepo.Create(note)
+	err = s.noteRepo.Create(note, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		// Reload with dog and owner for the subscribers
+		created, err := s.noteRepo.WithTx(tx).GetByID(note.ID)
+		if err != nil {
+			return nil, err
+		}
+		return []events.Event{&events.NoteCreated{Note: newNoteResponse(created)}}, nil
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +99,9 @@ func (s *consultantNoteService) UpdateNote(id uint, req *dto.UpdateNoteRequest,
 		note.Content = req.Content
 	}
 
-	err = s.noteRepo.Update(note)
+	err = s.noteRepo.Update(note, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		return []events.Event{&events.NoteUpdated{Note: newNoteResponse(note)}}, nil
+	}))
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +120,9 @@ func (s *consultantNoteService) DeleteNote(id uint, userID uint, role models.Use
 		return errors.New("unauthorized")
 	}
 
-	return s.noteRepo.Delete(id)
+	return s.noteRepo.Delete(id, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		return []events.Event{&events.NoteDeleted{ID: id, Dog: note.DogID, ConsultantID: note.ConsultantID}}, nil
+	}))
 }
 
 func (s *consultantNoteService) ListNotes(filters *dto.NoteFilterParams, userID uint, role models.UserRole) (*dto.NoteListResponse, error) {
@@ -141,12 +157,20 @@ func (s *consultantNoteService) ListNotes(filters *dto.NoteFilterParams, userID
 }
 
 func (s *consultantNoteService) toDTO(note *models.ConsultantNote) *dto.NoteResponse {
+	return newNoteResponse(note)
+}
+
+// newNoteResponse maps a note with preloaded dog and owner to its DTO
+func newNoteResponse(note *models.ConsultantNote) *dto.NoteResponse {
 	resp := &dto.NoteResponse{
 		ID:           note.ID,
 		ConsultantID: note.ConsultantID,
 		DogID:        note.DogID,
 		Title:        note.Title,
 		Content:      note.Content,
+		ClientID:     note.ClientID,
+		Attachments:  note.Attachments,
+		Highlight:    note.Highlight,
 		CreatedAt:    note.CreatedAt,
 		UpdatedAt:    note.UpdatedAt,
 	}
@@ -129,6 +129,12 @@ func (s *dogService) DeleteDog(id uint, userID uint, role models.UserRole) error
 }
 
 func (s *dogService) checkAccess(dog *models.Dog, userID uint, role models.UserRole) error {
+	return checkDogAccess(s.repo, dog, userID, role)
+}
+
+// checkDogAccess verifies that the user may view the dog: admins see all dogs,
+// owners their own, consultants the dogs they were granted access to
+func checkDogAccess(repo repository.DogRepository, dog *models.Dog, userID uint, role models.UserRole) error {
 	if role == models.RoleAdmin {
 		return nil
 	}
@@ -139,7 +145,7 @@ func (s *dogService) checkAccess(dog *models.Dog, userID uint, role models.UserR
 		return nil
 	}
 	if role == models.RoleConsultant {
-		hasAccess, err := s.repo.HasConsultantAccess(userID, dog.ID)
+		hasAccess, err := repo.HasConsultantAccess(userID, dog.ID)
 		if err != nil {
 			return err
 		}
@@ -1,17 +1,23 @@
 package service
 
 import (
+	"errors"
 	"math"
+	"net/http"
 	"time"
 
 	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
 	"github.com/you/pawtrack/internal/models"
 	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
 )
 
 // EventService interface for event business logic
 type EventService interface {
 	CreateEvent(req *dto.CreateEventRequest) (*models.Event, error)
+	CreateEventForUser(req *dto.CreateEventRequest, userID uint, role models.UserRole) (*models.Event, error)
+	CreateEventsBatch(items []dto.BatchEventItem, atomic bool, userID uint, role models.UserRole) ([]dto.BatchEventResult, error)
 	ListEvents(filters *dto.EventFilterParams) (*dto.EventListResponse, error)
 	GetEvent(id uint) (*models.Event, error)
 	DeleteEvent(id uint) error
@@ -19,35 +25,139 @@ type EventService interface {
 
 // eventService implementation of the event service
 type eventService struct {
-	repo repository.EventRepository
+	repo      repository.EventRepository
+	dogRepo   repository.DogRepository
+	publisher events.Publisher
 }
 
+// ErrBatchRejected is returned when an atomic batch has at least one failed item
+var ErrBatchRejected = errors.New("batch rejected")
+
 // NewEventService creates a new event service
-func NewEventService(repo repository.EventRepository) EventService {
-	return &eventService{repo: repo}
+func NewEventService(repo repository.EventRepository, dogRepo repository.DogRepository, publisher events.Publisher) EventService {
+	return &eventService{
+		repo:      repo,
+		dogRepo:   dogRepo,
+		publisher: publisher,
+	}
 }
 
 // CreateEvent creates a new event
 func (s *eventService) CreateEvent(req *dto.CreateEventRequest) (*models.Event, error) {
-	when := time.Now().UTC()
-	if req.At != nil {
-		when = req.At.UTC()
+	event := newEvent(req)
+
+	err := s.repo.Create(event, s.publishCreated(event))
+	if err != nil {
+		return nil, err
 	}
 
-	event := &models.Event{
-		DogID: req.DogID,
-		Type:  req.Type,
-		Note:  req.Note,
-		At:    when,
-		AttachmentURL: req.AttachmentURL,
+	return event, nil
+}
+
+// CreateEventForUser creates an event after checking the user's access to its dog
+func (s *eventService) CreateEventForUser(req *dto.CreateEventRequest, userID uint, role models.UserRole) (*models.Event, error) {
+	if err := s.authorizeEvent(req, userID, role, nil); err != nil {
+		return nil, err
 	}
+	return s.CreateEvent(req)
+}
 
-	err := s.repo.Create(event)
-	if err != nil {
+// CreateEventsBatch authorizes every item like CreateEventForUser and inserts the accepted
+// ones in a single transaction. In atomic mode nothing is inserted if any item
+// fails and ErrBatchRejected is returned along with the per-item results.
+func (s *eventService) CreateEventsBatch(items []dto.BatchEventItem, atomic bool, userID uint, role models.UserRole) ([]dto.BatchEventResult, error) {
+	results := make([]dto.BatchEventResult, len(items))
+	events := make([]*models.Event, 0, len(items))
+	accepted := make([]int, 0, len(items))
+
+	// Dog access is checked once per dog, batches usually target one or two dogs
+	dogAccess := make(map[uint]error)
+
+	for i := range items {
+		results[i].Index = items[i].Index
+		if err := s.authorizeEvent(&items[i].Event, userID, role, dogAccess); err != nil {
+			if isNotFoundOrUnauthorized(err) {
+				results[i].Status = http.StatusNotFound
+				results[i].Error = "dog not found"
+				continue
+			}
+			return nil, err
+		}
+		events = append(events, newEvent(&items[i].Event))
+		accepted = append(accepted, i)
+	}
+
+	if atomic && len(accepted) != len(items) {
+		for i := range results {
+			if results[i].Status == 0 {
+				results[i].Status = http.StatusFailedDependency
+				results[i].Error = "not created: another item in the batch failed"
+			}
+		}
+		return results, ErrBatchRejected
+	}
+
+	if err := s.repo.CreateBatch(events, s.publishCreated(events...)); err != nil {
 		return nil, err
 	}
 
-	return event, nil
+	for n, i := range accepted {
+		results[i].Status = http.StatusCreated
+		results[i].Event = events[n]
+	}
+
+	return results, nil
+}
+
+// authorizeEvent checks that the user may create an event for the requested dog.
+// Events without a dog are allowed, as before. The optional cache keeps the
+// access decision per dog across a batch.
+func (s *eventService) authorizeEvent(req *dto.CreateEventRequest, userID uint, role models.UserRole, cache map[uint]error) error {
+	if req.DogID == nil {
+		return nil
+	}
+	if cache != nil {
+		if err, ok := cache[*req.DogID]; ok {
+			return err
+		}
+	}
+
+	err := func() error {
+		dog, err := s.dogRepo.GetByID(*req.DogID)
+		if err != nil {
+			return err
+		}
+		return checkDogAccess(s.dogRepo, dog, userID, role)
+	}()
+
+	if cache != nil && (err == nil || isNotFoundOrUnauthorized(err)) {
+		cache[*req.DogID] = err
+	}
+	return err
+}
+
+// newEvent builds an event model from a create request
+func newEvent(req *dto.CreateEventRequest) *models.Event {
+	when := time.Now().UTC()
+	if req.At != nil {
+		when = req.At.UTC()
+	}
+
+	return &models.Event{
+		DogID:           req.DogID,
+		Type:            req.Type,
+		Note:            req.Note,
+		At:              when,
+		DurationMinutes: req.DurationMinutes,
+		AmountGrams:     req.AmountGrams,
+		Attachments:     req.Attachments,
+		ClientID:        req.ClientID,
+	}
+}
+
+// isNotFoundOrUnauthorized reports errors that are surfaced as 404 for dog resources
+func isNotFoundOrUnauthorized(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || err.Error() == "unauthorized"
 }
 
 // ListEvents returns a list of events with filtering and pagination
@@ -83,5 +193,31 @@ func (s *eventService) GetEvent(id uint) (*models.Event, error) {
 
 // DeleteEvent deletes an event
 func (s *eventService) DeleteEvent(id uint) error {
-	return s.repo.Delete(id)
+	event, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(id, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		if event.DogID == nil {
+			return nil, nil
+		}
+		return []events.Event{&events.EventDeleted{ID: id, Dog: *event.DogID}}, nil
+	}))
+}
+
+// publishCreated returns a hook notifying subscribers of the created events' dogs
+func (s *eventService) publishCreated(created ...*models.Event) repository.TxHook {
+	return s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		var published []events.Event
+		for _, event := range created {
+			if event.DogID != nil {
+				published = append(published, &events.EventCreated{Event: event})
+			}
+		}
+		return published, nil
+	})
 }
@@ -4,8 +4,10 @@ import (
 	"errors"
 
 	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
 	"github.com/you/pawtrack/internal/models"
 	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
 )
 
 type EventCommentService interface {
@@ -20,17 +22,20 @@ type eventCommentService struct {
 	commentRepo repository.EventCommentRepository
 	eventRepo   repository.EventRepository
 	dogRepo     repository.DogRepository
+	publisher   events.Publisher
 }
 
 func NewEventCommentService(
 	commentRepo repository.EventCommentRepository,
 	eventRepo repository.EventRepository,
 	dogRepo repository.DogRepository,
+	publisher events.Publisher,
 ) EventCommentService {
 	return &eventCommentService{
 		commentRepo: commentRepo,
 		eventRepo:   eventRepo,
 		dogRepo:     dogRepo,
+		publisher:   publisher,
 	}
 }
 
@@ -86,13 +91,25 @@ func (s *eventCommentService) CreateComment(req *dto.CreateCommentRequest, userI
 	}
 
 	comment := &models.EventComment{
-		EventID: req.EventID,
-		UserID:  userID,
-		Content: req.Content,
-		AttachmentURL: req.AttachmentURL,
+		EventID:     req.EventID,
+		UserID:      userID,
+		Content:     req.Content,
+		Attachments: req.Attachments,
+		ClientID:    req.ClientID,
 	}
 
-	if err := s.commentRepo.Create(comment); err != nil {
+	err := s.commentRepo.Create(comment, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		// Reload with author and event for the subscribers
+		created, err := s.commentRepo.WithTx(tx).GetByID(comment.ID)
+		if err != nil {
+			return nil, err
+		}
+		if dogID, ok := commentDogID(created); ok {
+			return []events.Event{&events.CommentPosted{Comment: newCommentResponse(created), Dog: dogID}}, nil
+		}
+		return nil, nil
+	}))
+	if err != nil {
 		return nil, err
 	}
 
@@ -126,7 +143,13 @@ func (s *eventCommentService) UpdateComment(id uint, req *dto.UpdateCommentReque
 
 	comment.Content = req.Content
 
-	if err := s.commentRepo.Update(comment); err != nil {
+	err = s.commentRepo.Update(comment, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		if dogID, ok := commentDogID(comment); ok {
+			return []events.Event{&events.CommentUpdated{Comment: newCommentResponse(comment), Dog: dogID}}, nil
+		}
+		return nil, nil
+	}))
+	if err != nil {
 		return nil, err
 	}
 
@@ -144,7 +167,20 @@ func (s *eventCommentService) DeleteComment(id uint, userID uint, role models.Us
 		return errors.New("only comment author can delete")
 	}
 
-	return s.commentRepo.Delete(id)
+	return s.commentRepo.Delete(id, s.publisher.PublishTx(func(tx *gorm.DB) ([]events.Event, error) {
+		if dogID, ok := commentDogID(comment); ok {
+			return []events.Event{&events.CommentDeleted{ID: id, Dog: dogID}}, nil
+		}
+		return nil, nil
+	}))
+}
+
+// commentDogID returns the dog of the commented event; the comment must have its event loaded
+func commentDogID(comment *models.EventComment) (uint, bool) {
+	if comment.Event == nil || comment.Event.DogID == nil {
+		return 0, false
+	}
+	return *comment.Event.DogID, true
 }
 
 func (s *eventCommentService) ListComments(eventID uint, userID uint, role models.UserRole) (*dto.CommentListResponse, error) {
@@ -170,13 +206,21 @@ func (s *eventCommentService) ListComments(eventID uint, userID uint, role model
 }
 
 func (s *eventCommentService) toDTO(comment *models.EventComment) *dto.CommentResponse {
+	return newCommentResponse(comment)
+}
+
+// newCommentResponse maps a comment with preloaded author to its DTO
+func newCommentResponse(comment *models.EventComment) *dto.CommentResponse {
 	resp := &dto.CommentResponse{
-		ID:        comment.ID,
-		EventID:   comment.EventID,
-		UserID:    comment.UserID,
-		Content:   comment.Content,
-		CreatedAt: comment.CreatedAt,
-		UpdatedAt: comment.UpdatedAt,
+		ID:            comment.ID,
+		EventID:       comment.EventID,
+		UserID:        comment.UserID,
+		Content:       comment.Content,
+		AttachmentURL: comment.AttachmentURL,
+		Attachments:   comment.Attachments,
+		ClientID:      comment.ClientID,
+		CreatedAt:     comment.CreatedAt,
+		UpdatedAt:     comment.UpdatedAt,
 	}
 
 	if comment.User != nil {
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrIdempotencyInProgress is returned while the original request is still being processed
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService interface for idempotent request handling
+type IdempotencyService interface {
+	// Begin reserves the key for a request. If the key was already used for the same
+	// request, the stored record is returned and the caller should replay it.
+	Begin(userID uint, key, method, path, fingerprint string) (record *models.IdempotencyKey, replay bool, err error)
+
+	// Complete stores the response of the request that reserved the key
+	Complete(record *models.IdempotencyKey, statusCode int, body []byte, contentType string) error
+
+	// Release frees the key so the client can retry, e.g. after a server error
+	Release(record *models.IdempotencyKey) error
+}
+
+// idempotencyService implementation of the idempotency service
+type idempotencyService struct {
+	repo  repository.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewIdempotencyService creates a new idempotency service keeping responses for
+// ttl. A key in flight for longer than lease is considered abandoned, e.g. by a
+// server that died mid-request, and the next request with it takes it over.
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl, lease time.Duration) IdempotencyService {
+	return &idempotencyService{
+		repo:  repo,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// Begin reserves the key or returns the stored response for a retry
+func (s *idempotencyService) Begin(userID uint, key, method, path, fingerprint string) (*models.IdempotencyKey, bool, error) {
+	// Two attempts: the second one runs if an expired record or abandoned
+	// reservation was removed, or the concurrent owner of the key released it
+	// in between
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now().UTC()
+		record := &models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(s.lease), // Extended to ttl on completion
+		}
+
+		reserved, err := s.repo.Reserve(record)
+		if err != nil {
+			return nil, false, err
+		}
+		if reserved {
+			return record, false, nil
+		}
+
+		existing, err := s.repo.Get(userID, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		if existing.ExpiresAt.Before(now) {
+			if err := s.repo.DeleteIfExpired(existing.ID, now); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		if existing.Fingerprint != fingerprint {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode == 0 {
+			return nil, false, ErrIdempotencyInProgress
+		}
+		return existing, true, nil
+	}
+
+	return nil, false, ErrIdempotencyInProgress
+}
+
+// Complete stores the response of the request that reserved the key
+func (s *idempotencyService) Complete(record *models.IdempotencyKey, statusCode int, body []byte, contentType string) error {
+	return s.repo.Complete(record.ID, statusCode, body, contentType, time.Now().UTC().Add(s.ttl))
+}
+
+// Release frees the key so the client can retry
+func (s *idempotencyService) Release(record *models.IdempotencyKey) error {
+	return s.repo.Delete(record.ID)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/testdb"
+)
+
+func TestIdempotencyTakesOverAbandonedKeys(t *testing.T) {
+	db := testdb.Open(t)
+	user := createUser(t, db, models.RoleOwner)
+	s := NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, time.Minute)
+
+	record, replay, err := s.Begin(user.ID, "key", "POST", "/api/v1/events", "fingerprint")
+	if err != nil || replay {
+		t.Fatalf("expected the key to be reserved, got replay=%v err=%v", replay, err)
+	}
+	if _, _, err := s.Begin(user.ID, "key", "POST", "/api/v1/events", "fingerprint"); err != ErrIdempotencyInProgress {
+		t.Fatalf("expected %v while in flight, got %v", ErrIdempotencyInProgress, err)
+	}
+
+	// The server died before completing: once the lease runs out the key is taken over
+	if err := db.Model(record).UpdateColumn("expires_at", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	retry, replay, err := s.Begin(user.ID, "key", "POST", "/api/v1/events", "fingerprint")
+	if err != nil || replay || retry.ID == record.ID {
+		t.Fatalf("expected the abandoned key to be reserved again, got replay=%v err=%v", replay, err)
+	}
+
+	// Completing keeps the response for the whole ttl, not just the lease
+	if err := s.Complete(retry, 201, []byte("{}"), "application/json"); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.IdempotencyKey
+	if err := db.First(&stored, retry.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(stored.ExpiresAt) < 59*time.Minute {
+		t.Fatalf("expected the response to be kept for the ttl, expires at %v", stored.ExpiresAt)
+	}
+	if _, replay, err := s.Begin(user.ID, "key", "POST", "/api/v1/events", "fingerprint"); err != nil || !replay {
+		t.Fatalf("expected a replay, got replay=%v err=%v", replay, err)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/you/pawtrack/internal/imaging"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/storage"
+)
+
+// ImageService interface for storing photos. Images are stored without their
+// metadata, so that EXIF GPS coordinates of owners' homes do not leak, and
+// with thumbnails for lists.
+type ImageService interface {
+	// Supported reports whether files of a content type, detected from their
+	// first bytes, are processed
+	Supported(contentType string) bool
+	// Store removes the metadata of an image and stores it with its thumbnails;
+	// an SVG is stored as PNG, renamed accordingly. Returns
+	// imaging.ErrInvalidImage or imaging.ErrImageTooLarge for images that
+	// cannot be processed.
+	Store(data []byte, filename, contentType string) (*models.Attachment, error)
+}
+
+// imageService implementation of the image service
+type imageService struct {
+	storage storage.FileStorage
+	slots   chan struct{}
+}
+
+// NewImageService creates a new image service. Decoding takes 4 bytes a pixel,
+// so at most workers images are processed at a time.
+func NewImageService(storage storage.FileStorage, workers int) ImageService {
+	if workers < 1 {
+		workers = 1
+	}
+	return &imageService{
+		storage: storage,
+		slots:   make(chan struct{}, workers),
+	}
+}
+
+// Supported reports whether images of the content type are processed
+func (s *imageService) Supported(contentType string) bool {
+	return imaging.Supported(contentType)
+}
+
+// Store processes an image and stores the result and its thumbnails. Nothing
+// is left in storage on failure.
+func (s *imageService) Store(data []byte, filename, contentType string) (*models.Attachment, error) {
+	s.slots <- struct{}{}
+	result, err := imaging.Process(data, contentType)
+	<-s.slots
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "image/svg+xml" {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".png"
+	}
+
+	key, err := s.storage.Upload(bytes.NewReader(result.Data), filename, result.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(result.Data)
+	attachment := &models.Attachment{
+		Key:         key,
+		Backend:     s.storage.Name(),
+		Filename:    filename,
+		ContentType: result.ContentType,
+		Size:        int64(len(result.Data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+
+	for _, thumb := range result.Thumbnails {
+		key, err := s.storage.Upload(bytes.NewReader(thumb.Data), thumbnailName(thumb), thumb.ContentType)
+		if err != nil {
+			s.remove(attachment.Keys())
+			return nil, err
+		}
+		attachment.Thumbnails = append(attachment.Thumbnails, models.Thumbnail{
+			Size:        thumb.Size,
+			Key:         key,
+			ContentType: thumb.ContentType,
+			Width:       thumb.Width,
+			Height:      thumb.Height,
+		})
+	}
+	return attachment, nil
+}
+
+// remove deletes stored files; failures only leave orphans, so they are logged
+func (s *imageService) remove(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			log.Printf("images: failed to delete %s: %v", key, err)
+		}
+	}
+}
+
+// thumbnailName names a thumbnail file for storage, which keys files by extension
+func thumbnailName(thumb imaging.Thumbnail) string {
+	if thumb.ContentType == "image/png" {
+		return "thumbnail-" + thumb.Size + ".png"
+	}
+	return "thumbnail-" + thumb.Size + ".jpg"
+}
+
+// isImageError reports whether err rejects an image rather than failing to store it
+func isImageError(err error) bool {
+	return errors.Is(err, imaging.ErrInvalidImage) || errors.Is(err, imaging.ErrImageTooLarge)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/jobs"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// ErrJobNotDead is returned when retrying a job that is not dead-lettered
+var ErrJobNotDead = errors.New("only dead jobs can be retried")
+
+// JobService lets admins inspect and retry background jobs
+type JobService interface {
+	ListJobs(params *dto.JobListParams) (*dto.JobListResponse, error)
+	GetJob(id uint) (*dto.JobResponse, error)
+	RetryJob(id uint) (*dto.JobResponse, error)
+}
+
+// jobService implementation of the job service
+type jobService struct {
+	repo  repository.JobRepository
+	queue *jobs.Queue
+}
+
+// NewJobService creates a new job service
+func NewJobService(repo repository.JobRepository, queue *jobs.Queue) JobService {
+	return &jobService{repo: repo, queue: queue}
+}
+
+// ListJobs returns a page of jobs, newest first
+func (s *jobService) ListJobs(params *dto.JobListParams) (*dto.JobListResponse, error) {
+	list, total, err := s.repo.List(&repository.JobFilter{
+		Status: params.Status,
+		Type:   params.Type,
+		Offset: (params.Page - 1) * params.PageSize,
+		Limit:  params.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.JobListResponse{
+		Jobs:       make([]dto.JobResponse, 0, len(list)),
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalCount: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.PageSize))),
+	}
+	for i := range list {
+		resp.Jobs = append(resp.Jobs, *newJobResponse(&list[i]))
+	}
+	return resp, nil
+}
+
+// GetJob returns a job by ID
+func (s *jobService) GetJob(id uint) (*dto.JobResponse, error) {
+	job, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return newJobResponse(job), nil
+}
+
+// RetryJob puts a dead job back in the queue with fresh attempts
+func (s *jobService) RetryJob(id uint) (*dto.JobResponse, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	retried, err := s.repo.Retry(id, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !retried {
+		return nil, ErrJobNotDead
+	}
+	s.queue.Notify()
+
+	return s.GetJob(id)
+}
+
+// newJobResponse maps a job to its response with the payload inlined
+func newJobResponse(job *models.Job) *dto.JobResponse {
+	return &dto.JobResponse{
+		Job:     *job,
+		Payload: json.RawMessage(job.Payload),
+	}
+}
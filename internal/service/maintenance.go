@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// MaintenanceOptions configures the maintenance tasks
+type MaintenanceOptions struct {
+	JobRetention time.Duration // How long succeeded jobs are kept
+}
+
+// MaintenanceService holds the tasks run by the scheduler
+type MaintenanceService interface {
+	// ExpireInvites marks pending invites past their expiry as expired
+	ExpireInvites(ctx context.Context) error
+	// PruneRecords removes expired idempotency keys and old succeeded jobs
+	PruneRecords(ctx context.Context) error
+	// SendDailyDigests publishes a digest of yesterday's activity for every active dog
+	SendDailyDigests(ctx context.Context) error
+}
+
+// maintenanceService implementation of the maintenance service
+type maintenanceService struct {
+	consultantRepo  repository.ConsultantRepository
+	idempotencyRepo repository.IdempotencyRepository
+	jobRepo         repository.JobRepository
+	statsRepo       repository.StatsRepository
+	publisher       events.Publisher
+	opts            MaintenanceOptions
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(
+	consultantRepo repository.ConsultantRepository,
+	idempotencyRepo repository.IdempotencyRepository,
+	jobRepo repository.JobRepository,
+	statsRepo repository.StatsRepository,
+	publisher events.Publisher,
+	opts MaintenanceOptions,
+) MaintenanceService {
+	if opts.JobRetention <= 0 {
+		opts.JobRetention = 7 * 24 * time.Hour
+	}
+	return &maintenanceService{
+		consultantRepo:  consultantRepo,
+		idempotencyRepo: idempotencyRepo,
+		jobRepo:         jobRepo,
+		statsRepo:       statsRepo,
+		publisher:       publisher,
+		opts:            opts,
+	}
+}
+
+// ExpireInvites marks pending invites past their expiry as expired
+func (s *maintenanceService) ExpireInvites(ctx context.Context) error {
+	expired, err := s.consultantRepo.ExpireInvites(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("maintenance: expired %d invites", expired)
+	}
+	return nil
+}
+
+// PruneRecords removes expired idempotency keys and old succeeded jobs. Sessions
+// need no cleanup: authentication uses stateless JWTs.
+func (s *maintenanceService) PruneRecords(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	keys, err := s.idempotencyRepo.DeleteExpired(now)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	jobs, err := s.jobRepo.DeleteSucceeded(now.Add(-s.opts.JobRetention))
+	if err != nil {
+		return err
+	}
+
+	if keys > 0 || jobs > 0 {
+		log.Printf("maintenance: pruned %d idempotency keys and %d jobs", keys, jobs)
+	}
+	return nil
+}
+
+// SendDailyDigests publishes a digest of the previous UTC day for every dog with
+// events or comments in it; subscribers such as webhooks deliver them
+func (s *maintenanceService) SendDailyDigests(ctx context.Context) error {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -1)
+
+	eventCounts, err := s.statsRepo.EventCountsByDog(from, to)
+	if err != nil {
+		return err
+	}
+	commentCounts, err := s.statsRepo.CommentCountsByDog(from, to)
+	if err != nil {
+		return err
+	}
+
+	digests := make(map[uint]*dto.DailyDigest)
+	var order []uint
+	digest := func(dogID uint) *dto.DailyDigest {
+		d, ok := digests[dogID]
+		if !ok {
+			d = &dto.DailyDigest{DogID: dogID, Date: from.Format("2006-01-02"), Events: map[string]int64{}}
+			digests[dogID] = d
+			order = append(order, dogID)
+		}
+		return d
+	}
+	for _, row := range eventCounts {
+		d := digest(row.DogID)
+		d.Events[row.Type] = row.Count
+		d.TotalEvents += row.Count
+	}
+	for _, row := range commentCounts {
+		digest(row.DogID).Comments = row.Count
+	}
+
+	for _, dogID := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.publisher.Publish(&events.DailyDigest{Digest: digests[dogID]})
+	}
+	if len(order) > 0 {
+		log.Printf("maintenance: published %d daily digests for %s", len(order), from.Format("2006-01-02"))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// newMaintenance creates the maintenance service over a test database and
+// returns the digests it publishes
+func newMaintenance(t *testing.T, db *gorm.DB) (MaintenanceService, *[]*dto.DailyDigest) {
+	t.Helper()
+	bus := events.NewBus()
+	digests := &[]*dto.DailyDigest{}
+	bus.Subscribe(func(event events.Event) {
+		*digests = append(*digests, event.(*events.DailyDigest).Digest)
+	}, events.TypeDailyDigest)
+
+	s := NewMaintenanceService(
+		repository.NewConsultantRepository(db),
+		repository.NewIdempotencyRepository(db),
+		repository.NewJobRepository(db),
+		repository.NewStatsRepository(db),
+		bus,
+		MaintenanceOptions{JobRetention: 24 * time.Hour},
+	)
+	return s, digests
+}
+
+// createUser creates a user with the given role
+func createUser(t *testing.T, db *gorm.DB, role models.UserRole) *models.User {
+	t.Helper()
+	user := &models.User{Name: string(role), Email: fmt.Sprintf("%s-%d@example.com", role, time.Now().UnixNano()), PasswordHash: "x", Role: role}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createDog creates a dog of the owner
+func createDog(t *testing.T, db *gorm.DB, owner *models.User, name string) *models.Dog {
+	t.Helper()
+	dog := &models.Dog{OwnerID: owner.ID, Name: name}
+	if err := db.Create(dog).Error; err != nil {
+		t.Fatal(err)
+	}
+	return dog
+}
+
+func TestMaintenanceExpireInvites(t *testing.T) {
+	db := testdb.Open(t)
+	s, _ := newMaintenance(t, db)
+
+	owner := createUser(t, db, models.RoleOwner)
+	consultant := createUser(t, db, models.RoleConsultant)
+	dog := createDog(t, db, owner, "Rex")
+
+	now := time.Now().UTC()
+	invites := []models.Invite{
+		{Token: "overdue", Status: models.InvitePending, ExpiresAt: now.Add(-time.Hour)},
+		{Token: "open", Status: models.InvitePending, ExpiresAt: now.Add(time.Hour)},
+		{Token: "accepted", Status: models.InviteAccepted, ExpiresAt: now.Add(-time.Hour)},
+	}
+	for i := range invites {
+		invites[i].OwnerID, invites[i].ConsultantID, invites[i].DogID = owner.ID, consultant.ID, dog.ID
+		if err := db.Create(&invites[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.ExpireInvites(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]models.InviteStatus{
+		"overdue":  models.InviteExpired,
+		"open":     models.InvitePending,
+		"accepted": models.InviteAccepted,
+	}
+	var got []models.Invite
+	if err := db.Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, invite := range got {
+		if invite.Status != want[invite.Token] {
+			t.Errorf("invite %s: expected %s, got %s", invite.Token, want[invite.Token], invite.Status)
+		}
+	}
+}
+
+func TestMaintenancePruneRecords(t *testing.T) {
+	db := testdb.Open(t)
+	s, _ := newMaintenance(t, db)
+	user := createUser(t, db, models.RoleOwner)
+
+	now := time.Now().UTC()
+	keys := []models.IdempotencyKey{
+		{Key: "expired", ExpiresAt: now.Add(-time.Minute)},
+		{Key: "live", ExpiresAt: now.Add(time.Hour)},
+	}
+	for i := range keys {
+		keys[i].UserID, keys[i].Method, keys[i].Path, keys[i].Fingerprint = user.ID, "POST", "/api/v1/events", "x"
+		if err := db.Create(&keys[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	jobs := []models.Job{
+		{Type: "old-succeeded", Status: models.JobSucceeded, FinishedAt: &old},
+		{Type: "recent-succeeded", Status: models.JobSucceeded, FinishedAt: &recent},
+		{Type: "old-dead", Status: models.JobDead, FinishedAt: &old},
+		{Type: "pending", Status: models.JobPending},
+	}
+	for i := range jobs {
+		jobs[i].Payload, jobs[i].MaxAttempts, jobs[i].RunAt = "{}", 1, old
+		if err := db.Create(&jobs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.PruneRecords(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var keptKeys []string
+	if err := db.Model(&models.IdempotencyKey{}).Order("key").Pluck("key", &keptKeys).Error; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keptKeys) != "[live]" {
+		t.Errorf("expected only the live key to be kept, got %v", keptKeys)
+	}
+
+	// Failed jobs are kept for inspection and retry whatever their age
+	var keptJobs []string
+	if err := db.Model(&models.Job{}).Order("type").Pluck("type", &keptJobs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keptJobs) != "[old-dead pending recent-succeeded]" {
+		t.Errorf("unexpected jobs kept: %v", keptJobs)
+	}
+}
+
+func TestMaintenanceSendDailyDigests(t *testing.T) {
+	db := testdb.Open(t)
+	s, digests := newMaintenance(t, db)
+
+	owner := createUser(t, db, models.RoleOwner)
+	active := createDog(t, db, owner, "Active")
+	quiet := createDog(t, db, owner, "Quiet")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-12 * time.Hour)
+	for _, event := range []models.Event{
+		{DogID: &active.ID, Type: "walk", At: yesterday},
+		{DogID: &active.ID, Type: "walk", At: yesterday.Add(time.Hour)},
+		{DogID: &active.ID, Type: "feed", At: yesterday},
+		// Outside the digested day
+		{DogID: &quiet.ID, Type: "walk", At: today.Add(time.Minute)},
+		{DogID: &quiet.ID, Type: "walk", At: today.Add(-25 * time.Hour)},
+	} {
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+		if *event.DogID == active.ID && event.Type == "feed" {
+			comment := models.EventComment{EventID: event.ID, UserID: owner.ID, Content: "Ate well", CreatedAt: yesterday}
+			if err := db.Create(&comment).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := s.SendDailyDigests(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*digests) != 1 {
+		t.Fatalf("expected one digest, got %d", len(*digests))
+	}
+	digest := (*digests)[0]
+	if digest.DogID != active.ID || digest.Date != yesterday.Format("2006-01-02") {
+		t.Fatalf("unexpected digest %+v", digest)
+	}
+	if digest.TotalEvents != 3 || digest.Events["walk"] != 2 || digest.Events["feed"] != 1 || digest.Comments != 1 {
+		t.Fatalf("unexpected counts %+v", digest)
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/storage"
+)
+
+// ObjectStoreOptions configures the reference counting of stored files
+type ObjectStoreOptions struct {
+	Grace time.Duration // Age before an unreferenced or unrecorded file is collected, covering uploads whose rows are not written yet
+}
+
+// ObjectStore is a FileStorage keeping a record of every stored file with the
+// number of rows referring to it. A file with the content of an existing one
+// is not kept twice: Upload returns the existing key. Delete removes a file
+// only once nothing refers to it; files released by cascading deletes are
+// collected by Sweep.
+type ObjectStore interface {
+	storage.FileStorage
+
+	// Sweep deletes the files no row has referred to for the grace period
+	Sweep(ctx context.Context) error
+
+	// Reconcile compares the backend with the database: it corrects reference
+	// counts, deletes files without a record and reports recorded files that
+	// are missing. With dryRun nothing is changed.
+	Reconcile(ctx context.Context, dryRun bool) (*StorageReport, error)
+}
+
+// StorageReport is the result of ObjectStore.Reconcile
+type StorageReport struct {
+	Files       int      // Files in the backend
+	Bytes       int64    // Their total size
+	Untracked   int64    // Referenced keys that had no record
+	Recounted   int64    // Records whose reference count was wrong
+	Released    int      // Unreferenced files deleted with their record
+	Orphans     int      // Files without a record, deleted
+	OrphanBytes int64    // Their total size
+	Missing     []string // Keys of referenced files that are not in the backend
+}
+
+// objectStore implementation of the object store
+type objectStore struct {
+	repo    repository.StoredObjectRepository
+	storage storage.FileStorage
+	opts    ObjectStoreOptions
+}
+
+// NewObjectStore creates an object store over a storage backend
+func NewObjectStore(repo repository.StoredObjectRepository, storage storage.FileStorage, opts ObjectStoreOptions) ObjectStore {
+	if opts.Grace <= 0 {
+		opts.Grace = time.Hour
+	}
+	return &objectStore{
+		repo:    repo,
+		storage: storage,
+		opts:    opts,
+	}
+}
+
+// Name returns the name of the backend
+func (s *objectStore) Name() string {
+	return s.storage.Name()
+}
+
+// Upload stores the file while hashing it. If the backend already holds a
+// referenced file with the same content, the new copy is deleted and the key
+// of the existing one returned.
+func (s *objectStore) Upload(file io.Reader, filename string, contentType string) (string, error) {
+	hash := sha256.New()
+	counter := &countingWriter{}
+	key, err := s.storage.Upload(io.TeeReader(file, io.MultiWriter(hash, counter)), filename, contentType)
+	if err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	existing, err := s.repo.Claim(s.storage.Name(), sum, counter.n, time.Now().UTC())
+	if err != nil {
+		log.Printf("storage: looking up %s: %v", sum, err)
+	}
+	if existing != "" {
+		s.remove(key)
+		return existing, nil
+	}
+
+	object := &models.StoredObject{Key: key, Backend: s.storage.Name(), SHA256: &sum, Size: counter.n}
+	if err := s.repo.Create(object); err != nil {
+		s.remove(key)
+		return "", fmt.Errorf("failed to record file: %w", err)
+	}
+	return key, nil
+}
+
+// Open reads a stored file
+func (s *objectStore) Open(key string) (io.ReadCloser, error) {
+	return s.storage.Open(key)
+}
+
+// Delete removes the file if nothing refers to it any more; a file still
+// referenced, or claimed by an upload of the same content, is kept
+func (s *objectStore) Delete(key string) error {
+	released, err := s.repo.Release(s.storage.Name(), key, time.Now().UTC().Add(-s.opts.Grace))
+	if err != nil {
+		return fmt.Errorf("failed to release file: %w", err)
+	}
+	if !released {
+		return nil
+	}
+	return s.storage.Delete(key)
+}
+
+// GetSignedURL returns a signed URL of the backend
+func (s *objectStore) GetSignedURL(key string, expiryDuration time.Duration, download storage.Download) (string, error) {
+	return s.storage.GetSignedURL(key, expiryDuration, download)
+}
+
+// List lists the files of the backend
+func (s *objectStore) List(fn func(file storage.FileInfo) error) error {
+	return s.storage.List(fn)
+}
+
+// sweepBatchSize is the number of unreferenced files released at a time
+const sweepBatchSize = 100
+
+// Sweep releases unreferenced files older than the grace period
+func (s *objectStore) Sweep(ctx context.Context) error {
+	released, err := s.sweep(ctx, false)
+	if released > 0 {
+		log.Printf("maintenance: deleted %d unreferenced files", released)
+	}
+	return err
+}
+
+// sweep deletes, or with dryRun counts, the releasable files and returns their
+// number. A record is deleted before its file, so a failure leaves a file
+// without a record, which Reconcile collects.
+func (s *objectStore) sweep(ctx context.Context, dryRun bool) (int, error) {
+	cutoff := time.Now().UTC().Add(-s.opts.Grace)
+	released := 0
+	var afterID uint
+	for {
+		objects, err := s.repo.ListReleasable(s.storage.Name(), cutoff, afterID, sweepBatchSize)
+		if err != nil {
+			return released, err
+		}
+		for _, object := range objects {
+			if err := ctx.Err(); err != nil {
+				return released, err
+			}
+			afterID = object.ID
+			if !dryRun {
+				ok, err := s.repo.Release(object.Backend, object.Key, cutoff)
+				if err != nil {
+					return released, err
+				}
+				if !ok {
+					continue
+				}
+				s.remove(object.Key)
+			}
+			released++
+		}
+		if len(objects) < sweepBatchSize {
+			return released, nil
+		}
+	}
+}
+
+// Reconcile records referenced keys without a record, corrects reference
+// counts, releases unreferenced files and then walks the backend. Files
+// neither recorded nor referenced are deleted once older than the grace period,
+// so that uploads in progress are left alone.
+func (s *objectStore) Reconcile(ctx context.Context, dryRun bool) (*StorageReport, error) {
+	report := &StorageReport{}
+	backend := s.storage.Name()
+	var err error
+	if dryRun {
+		if report.Untracked, err = s.repo.CountUntracked(); err != nil {
+			return nil, err
+		}
+		if report.Recounted, err = s.repo.CountMiscounted(backend); err != nil {
+			return nil, err
+		}
+	} else {
+		if report.Untracked, err = s.repo.TrackUntracked(); err != nil {
+			return nil, err
+		}
+		if report.Recounted, err = s.repo.Recount(backend); err != nil {
+			return nil, err
+		}
+	}
+	if report.Released, err = s.sweep(ctx, dryRun); err != nil {
+		return nil, err
+	}
+
+	known, err := s.repo.KnownKeys()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-s.opts.Grace)
+	listed := make(map[string]bool)
+	err = s.storage.List(func(file storage.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		listed[file.Key] = true
+		report.Files++
+		report.Bytes += file.Size
+		if known[file.Key] || !file.ModTime.Before(cutoff) {
+			return nil
+		}
+		report.Orphans++
+		report.OrphanBytes += file.Size
+		if dryRun {
+			return nil
+		}
+		return s.storage.Delete(file.Key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	referenced, err := s.repo.ListReferenced(backend)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range referenced {
+		// Files recorded after the listing started are not in it
+		if !listed[object.Key] && object.CreatedAt.Before(cutoff) {
+			report.Missing = append(report.Missing, object.Key)
+		}
+	}
+	return report, nil
+}
+
+// remove deletes a file from the backend; failures only leave an orphan, so they are logged
+func (s *objectStore) remove(key string) {
+	if err := s.storage.Delete(key); err != nil {
+		log.Printf("storage: failed to delete %s: %v", key, err)
+	}
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/you/pawtrack/internal/jobs"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/scanner"
+	"github.com/you/pawtrack/internal/storage"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AttachmentScanJob is the background job type scanning one attachment
+const AttachmentScanJob = "attachments.scan"
+
+// unqueuedGrace is how long a quarantined attachment may go without a scan job
+// before RequeueUnscanned queues one; it leaves Submit time to scan it inline
+const unqueuedGrace = 10 * time.Minute
+
+// attachmentScanPayload is the payload of an AttachmentScanJob
+type attachmentScanPayload struct {
+	AttachmentID uint `json:"attachment_id"`
+}
+
+// ScanOptions configures malware scanning of attachments
+type ScanOptions struct {
+	InlineMaxSize int64         // Files up to this size are scanned before the upload is answered, larger ones in the background
+	InlineBudget  time.Duration // Time limit of all scans before the upload is answered, the rest go to the background
+	Timeout       time.Duration // Time limit of one scan
+	MaxAttempts   int           // Attempts of a background scan before the job is dead and the file stays quarantined
+	Backoff       time.Duration // Delay before the first retry, doubled for each following one
+}
+
+// ScanService checks new attachments for malware. Attachments are created
+// quarantined and become downloadable once the scanner finds them clean.
+type ScanService interface {
+	// Submit scans attachments that were just created. Small files are scanned
+	// right away, within the inline budget, and their status is updated in place;
+	// large ones, those past the budget and those the scanner could not take are
+	// left quarantined and scanned in the background.
+	Submit(ctx context.Context, attachments []models.Attachment)
+
+	// RequeueUnscanned queues a scan for quarantined attachments that have no
+	// scan job, e.g. because queueing failed or the server stopped before it
+	RequeueUnscanned(ctx context.Context) error
+}
+
+// scanService implementation of the scan service
+type scanService struct {
+	repo    repository.AttachmentRepository
+	storage storage.FileStorage
+	scanner scanner.Scanner
+	queue   *jobs.Queue
+	opts    ScanOptions
+}
+
+// NewScanService creates a new scan service and registers its scan job with the queue
+func NewScanService(repo repository.AttachmentRepository, storage storage.FileStorage, scanner scanner.Scanner, queue *jobs.Queue, opts ScanOptions) ScanService {
+	if opts.InlineMaxSize <= 0 {
+		opts.InlineMaxSize = utils.MaxFileSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Minute
+	}
+	if opts.InlineBudget <= 0 {
+		opts.InlineBudget = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 30 * time.Second
+	}
+
+	s := &scanService{
+		repo:    repo,
+		storage: storage,
+		scanner: scanner,
+		queue:   queue,
+		opts:    opts,
+	}
+	jobs.Register(queue, AttachmentScanJob, jobs.TypeOptions{
+		MaxAttempts: opts.MaxAttempts,
+		Backoff:     opts.Backoff,
+		Timeout:     2 * opts.Timeout,
+	}, s.scanJob)
+	return s
+}
+
+// Submit scans small attachments in the request and queues the rest
+func (s *scanService) Submit(ctx context.Context, attachments []models.Attachment) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.InlineBudget)
+	defer cancel()
+
+	for i := range attachments {
+		attachment := &attachments[i]
+		if attachment.ScanStatus != models.ScanQuarantine {
+			continue
+		}
+		if attachment.Size <= s.opts.InlineMaxSize && ctx.Err() == nil {
+			err := s.scan(ctx, attachment)
+			if err == nil {
+				continue
+			}
+			log.Printf("scan: attachment %d: %v, retrying in the background", attachment.ID, err)
+		}
+		if _, err := s.queue.Enqueue(AttachmentScanJob, attachmentScanPayload{AttachmentID: attachment.ID}); err != nil {
+			log.Printf("scan: queueing attachment %d: %v", attachment.ID, err)
+		}
+	}
+}
+
+// RequeueUnscanned queues scan jobs for quarantined attachments left without one
+func (s *scanService) RequeueUnscanned(ctx context.Context) error {
+	const batchSize = 100
+	before := time.Now().UTC().Add(-unqueuedGrace)
+	for ctx.Err() == nil {
+		attachments, err := s.repo.ListUnqueued(AttachmentScanJob, before, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, attachment := range attachments {
+			if _, err := s.queue.Enqueue(AttachmentScanJob, attachmentScanPayload{AttachmentID: attachment.ID}); err != nil {
+				return err
+			}
+		}
+		if len(attachments) > 0 {
+			log.Printf("scan: queued %d attachments left without a scan", len(attachments))
+		}
+		if len(attachments) < batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// scanJob scans a quarantined attachment; attachments removed or scanned
+// meanwhile are skipped
+func (s *scanService) scanJob(ctx context.Context, job *models.Job, payload attachmentScanPayload) error {
+	attachment, err := s.repo.GetByID(payload.AttachmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if attachment.ScanStatus != models.ScanQuarantine {
+		return nil
+	}
+
+	err = s.scan(ctx, attachment)
+	if errors.Is(err, scanner.ErrTooLarge) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// scan runs the scanner over the stored file and records the verdict
+func (s *scanService) scan(ctx context.Context, attachment *models.Attachment) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	file := &storedFile{storage: s.storage, key: attachment.Key}
+	defer file.Close()
+	result, err := s.scanner.Scan(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	scannedAt := time.Now().UTC()
+	status, threat := models.ScanClean, ""
+	if result.Infected {
+		status, threat = models.ScanInfected, result.Threat
+		log.Printf("scan: attachment %d (%s) is infected: %s", attachment.ID, attachment.Filename, threat)
+	}
+	update := *attachment
+	update.ScanStatus, update.ScanThreat, update.ScannedAt = status, threat, &scannedAt
+	if err := s.repo.SetScanResult(&update); err != nil {
+		return err
+	}
+	attachment.ScanStatus, attachment.ScanThreat, attachment.ScannedAt = status, threat, &scannedAt
+	return nil
+}
+
+// storedFile reads a stored file, opened on the first read so that a scanner
+// which does not look at the content costs no download
+type storedFile struct {
+	storage storage.FileStorage
+	key     string
+	file    io.ReadCloser
+}
+
+func (f *storedFile) Read(p []byte) (int, error) {
+	if f.file == nil {
+		file, err := f.storage.Open(f.key)
+		if err != nil {
+			return 0, err
+		}
+		f.file = file
+	}
+	return f.file.Read(p)
+}
+
+func (f *storedFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Close()
+}
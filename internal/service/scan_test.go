@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/jobs"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/scanner"
+	"github.com/you/pawtrack/internal/storage"
+	"github.com/you/pawtrack/internal/testdb"
+)
+
+// slowScanner finds files clean after a delay, unless the context ends first
+type slowScanner struct {
+	delay time.Duration
+}
+
+func (s slowScanner) Name() string { return "slow" }
+
+func (s slowScanner) Scan(ctx context.Context, file io.Reader) (scanner.Result, error) {
+	select {
+	case <-time.After(s.delay):
+		return scanner.Result{}, nil
+	case <-ctx.Done():
+		return scanner.Result{}, ctx.Err()
+	}
+}
+
+func TestScanSubmitStaysWithinInlineBudget(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repository.NewAttachmentRepository(db)
+	queue := jobs.NewQueue(repository.NewJobRepository(db), jobs.Options{})
+	scans := NewScanService(repo, storage.NewLocalStorage(t.TempDir(), "", []byte("key")), slowScanner{delay: 100 * time.Millisecond}, queue, ScanOptions{
+		InlineBudget: 150 * time.Millisecond,
+		Timeout:      time.Second,
+	})
+
+	event := models.Event{Type: "walk", At: time.Now().UTC()}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	attachments := make([]models.Attachment, 3)
+	for i := range attachments {
+		attachments[i] = models.Attachment{EventID: &event.ID, Key: "file", Backend: "local", Filename: "file.pdf", ContentType: "application/pdf", Size: 1}
+	}
+	if err := repo.Create(attachments); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	scans.Submit(context.Background(), attachments)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Submit took %v, longer than the budget", elapsed)
+	}
+
+	// The first scan fits the budget, the second is cut off and the third not started
+	want := []models.ScanStatus{models.ScanClean, models.ScanQuarantine, models.ScanQuarantine}
+	for i, status := range want {
+		if attachments[i].ScanStatus != status {
+			t.Errorf("attachment %d: expected %s, got %s", i, status, attachments[i].ScanStatus)
+		}
+	}
+
+	var queued int64
+	if err := db.Model(&models.Job{}).Where("type = ?", AttachmentScanJob).Count(&queued).Error; err != nil {
+		t.Fatal(err)
+	}
+	if queued != 2 {
+		t.Fatalf("expected 2 scan jobs, got %d", queued)
+	}
+}
+
+func TestScanRequeueUnscanned(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repository.NewAttachmentRepository(db)
+	queue := jobs.NewQueue(repository.NewJobRepository(db), jobs.Options{})
+	scans := NewScanService(repo, storage.NewLocalStorage(t.TempDir(), "", []byte("key")), slowScanner{}, queue, ScanOptions{})
+
+	event := models.Event{Type: "walk", At: time.Now().UTC()}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	attachments := make([]models.Attachment, 4)
+	for i := range attachments {
+		attachments[i] = models.Attachment{EventID: &event.ID, Key: "file", Backend: "local", Filename: "file.pdf", ContentType: "application/pdf", Size: 1}
+	}
+	attachments[2].ScanStatus = models.ScanClean
+	if err := repo.Create(attachments); err != nil {
+		t.Fatal(err)
+	}
+	// All but the last were uploaded long enough ago for their scan to be queued
+	old := time.Now().UTC().Add(-time.Hour)
+	for _, attachment := range attachments[:3] {
+		if err := db.Model(&attachment).UpdateColumn("created_at", old).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The second one has its job already
+	if _, err := queue.Enqueue(AttachmentScanJob, attachmentScanPayload{AttachmentID: attachments[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 0; run < 2; run++ {
+		if err := scans.RequeueUnscanned(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var payloads []string
+	if err := db.Model(&models.Job{}).Where("type = ?", AttachmentScanJob).Order("id").Pluck("payload", &payloads).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`[{"attachment_id":%d} {"attachment_id":%d}]`, attachments[1].ID, attachments[0].ID)
+	if fmt.Sprint(payloads) != want {
+		t.Fatalf("expected jobs %s, got %v", want, payloads)
+	}
+}
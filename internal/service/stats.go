@@ -0,0 +1,114 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// StatsService interface for aggregated dog activity
+type StatsService interface {
+	GetDogStats(dogID uint, params *dto.DogStatsParams, userID uint, role models.UserRole) (*dto.DogStatsResponse, error)
+}
+
+// statsService implementation of the stats service
+type statsService struct {
+	repo    repository.StatsRepository
+	dogRepo repository.DogRepository
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(repo repository.StatsRepository, dogRepo repository.DogRepository) StatsService {
+	return &statsService{
+		repo:    repo,
+		dogRepo: dogRepo,
+	}
+}
+
+// GetDogStats returns event counts, totals and streaks for a dog with RBAC check
+func (s *statsService) GetDogStats(dogID uint, params *dto.DogStatsParams, userID uint, role models.UserRole) (*dto.DogStatsResponse, error) {
+	dog, err := s.dogRepo.GetByID(dogID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDogAccess(s.dogRepo, dog, userID, role); err != nil {
+		return nil, err
+	}
+
+	groupBy := params.GroupBy
+	if groupBy == "" {
+		groupBy = dto.StatsGroupDay
+	}
+	from, to := statsRange(groupBy, params.From, params.To)
+
+	var types []string
+	if params.Types != "" {
+		types = strings.Split(params.Types, ",")
+	}
+
+	counts, err := s.repo.CountByPeriod(dogID, groupBy, from, to, types)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.TotalsByType(dogID, from, to, types)
+	if err != nil {
+		return nil, err
+	}
+	streaks, err := s.repo.LongestStreaks(dogID, from, to, types)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows arrive ordered by period, fold them into one bucket per period
+	buckets := []dto.StatsBucket{}
+	for _, row := range counts {
+		if len(buckets) == 0 || buckets[len(buckets)-1].Period != row.Period {
+			buckets = append(buckets, dto.StatsBucket{Period: row.Period, Counts: map[string]int64{}})
+		}
+		bucket := &buckets[len(buckets)-1]
+		bucket.Counts[row.Type] = row.Count
+		bucket.Total += row.Count
+	}
+
+	if totals == nil {
+		totals = []dto.StatsTypeTotals{}
+	}
+	if streaks == nil {
+		streaks = []dto.StatsStreak{}
+	}
+
+	return &dto.DogStatsResponse{
+		DogID:   dogID,
+		GroupBy: groupBy,
+		From:    from,
+		To:      to,
+		Buckets: buckets,
+		Totals:  totals,
+		Streaks: streaks,
+	}, nil
+}
+
+// statsRange resolves the requested range, defaulting to 30 days, 12 weeks or
+// 12 months back from now depending on the grouping
+func statsRange(groupBy string, from, to *time.Time) (time.Time, time.Time) {
+	end := time.Now().UTC()
+	if to != nil {
+		end = to.UTC()
+	}
+
+	if from != nil {
+		return from.UTC(), end
+	}
+
+	switch groupBy {
+	case dto.StatsGroupWeek:
+		return end.AddDate(0, 0, -12*7), end
+	case dto.StatsGroupMonth:
+		return end.AddDate(0, -12, 0), end
+	default:
+		return end.AddDate(0, 0, -30), end
+	}
+}
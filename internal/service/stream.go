@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/realtime"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// StreamService interface for real-time dog feeds
+type StreamService interface {
+	// Subscribe authorizes the user for the dog and subscribes to its messages,
+	// see realtime.Hub.Subscribe for backlog and complete
+	Subscribe(dogID uint, lastEventID uint64, userID uint, role models.UserRole) (sub *realtime.Subscription, backlog []realtime.Message, complete bool, err error)
+	// CheckAccess returns an error if the user may not follow the dog's feed,
+	// for rechecking open subscriptions
+	CheckAccess(dogID uint, userID uint, role models.UserRole) error
+}
+
+// streamService implementation of the stream service
+type streamService struct {
+	hub     *realtime.Hub
+	dogRepo repository.DogRepository
+}
+
+// NewStreamService creates a new stream service
+func NewStreamService(hub *realtime.Hub, dogRepo repository.DogRepository) StreamService {
+	return &streamService{
+		hub:     hub,
+		dogRepo: dogRepo,
+	}
+}
+
+// Subscribe checks dog access like GET /dogs/:id and subscribes to the dog's feed
+func (s *streamService) Subscribe(dogID uint, lastEventID uint64, userID uint, role models.UserRole) (*realtime.Subscription, []realtime.Message, bool, error) {
+	if err := s.CheckAccess(dogID, userID, role); err != nil {
+		return nil, nil, false, err
+	}
+
+	sub, backlog, complete := s.hub.Subscribe(dogID, lastEventID)
+
+	visible := backlog[:0]
+	for i := range backlog {
+		if backlog[i].VisibleTo(userID, role) {
+			visible = append(visible, backlog[i])
+		}
+	}
+	return sub, visible, complete, nil
+}
+
+// CheckAccess checks dog access like GET /dogs/:id
+func (s *streamService) CheckAccess(dogID uint, userID uint, role models.UserRole) error {
+	dog, err := s.dogRepo.GetByID(dogID)
+	if err != nil {
+		return err
+	}
+	return checkDogAccess(s.dogRepo, dog, userID, role)
+}
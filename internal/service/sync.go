@@ -0,0 +1,528 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSyncToken is returned when a sync token cannot be decoded
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// syncSafetyWindow is subtracted from the checkpoint handed to the client so that
+// rows written by transactions still in flight during a pull are sent next time.
+// Delivery is at-least-once: clients upsert records by id.
+const syncSafetyWindow = 5 * time.Second
+
+// SyncService interface for offline-first delta sync
+type SyncService interface {
+	Pull(token string, limit int, userID uint, role models.UserRole) (*dto.SyncPullResponse, error)
+	Push(req *dto.SyncPushRequest, userID uint, role models.UserRole) (*dto.SyncPushResponse, error)
+}
+
+// syncService implementation of the sync service
+type syncService struct {
+	repo           repository.SyncRepository
+	dogRepo        repository.DogRepository
+	eventService   EventService
+	commentService EventCommentService
+	noteService    ConsultantNoteService
+}
+
+// NewSyncService creates a new sync service. Pushed records are created through
+// the regular services so they get the same authorization.
+func NewSyncService(
+	repo repository.SyncRepository,
+	dogRepo repository.DogRepository,
+	eventService EventService,
+	commentService EventCommentService,
+	noteService ConsultantNoteService,
+) SyncService {
+	return &syncService{
+		repo:           repo,
+		dogRepo:        dogRepo,
+		eventService:   eventService,
+		commentService: commentService,
+		noteService:    noteService,
+	}
+}
+
+// Kinds of pulled records, in the order pages go through them
+const (
+	syncDogs = iota
+	syncEvents
+	syncComments
+	syncNotes
+	syncTombstones
+	syncKinds
+)
+
+// syncToken is what a pull continues from: a checkpoint, or a position within a
+// paged pull together with the checkpoint to hand out after its last page
+type syncToken struct {
+	Since      *time.Time // nil for a full snapshot
+	Checkpoint time.Time  // Set while paging
+	Kind       int
+	After      *repository.SyncCursor
+}
+
+// Pull returns a page of everything visible to the user that changed since the
+// token. While more pages are left the returned token continues this pull.
+func (s *syncService) Pull(token string, limit int, userID uint, role models.UserRole) (*dto.SyncPullResponse, error) {
+	state := &syncToken{}
+	if token != "" {
+		var err error
+		if state, err = decodeSyncToken(token); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = dto.DefaultSyncPullLimit
+	}
+
+	// Taken before reading the first page so nothing written during the pull is skipped
+	now := time.Now().UTC()
+	if state.Checkpoint.IsZero() {
+		state.Checkpoint = now.Add(-syncSafetyWindow)
+	}
+
+	resp := &dto.SyncPullResponse{
+		Full:       state.Since == nil,
+		Dogs:       []models.Dog{},
+		Events:     []models.Event{},
+		Comments:   []dto.CommentResponse{},
+		Notes:      []dto.NoteResponse{},
+		Tombstones: []models.Tombstone{},
+		ServerTime: now,
+	}
+
+	var next *syncToken
+	remaining := limit
+	for kind := state.Kind; kind < syncKinds; kind++ {
+		if remaining == 0 {
+			next = &syncToken{Since: state.Since, Checkpoint: state.Checkpoint, Kind: kind}
+			break
+		}
+		scope := &repository.SyncScope{UserID: userID, Role: role, Since: state.Since, Limit: remaining + 1}
+		if kind == state.Kind {
+			scope.After = state.After
+		}
+		count, last, more, err := s.pullKind(resp, kind, scope, remaining)
+		if err != nil {
+			return nil, err
+		}
+		if more {
+			next = &syncToken{Since: state.Since, Checkpoint: state.Checkpoint, Kind: kind, After: last}
+			break
+		}
+		remaining -= count
+	}
+
+	if next != nil {
+		resp.HasMore = true
+		resp.NextSince = encodeSyncToken(next)
+	} else {
+		resp.NextSince = encodeSyncToken(&syncToken{Since: &state.Checkpoint})
+	}
+	resp.Token = resp.NextSince
+
+	return resp, nil
+}
+
+// pullKind reads up to limit records of one kind into the response. It returns
+// how many were added, the position of the last one and whether more are left;
+// the scope's limit must be one above limit to tell.
+func (s *syncService) pullKind(resp *dto.SyncPullResponse, kind int, scope *repository.SyncScope, limit int) (int, *repository.SyncCursor, bool, error) {
+	switch kind {
+	case syncDogs:
+		dogs, err := s.repo.ChangedDogs(scope)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		dogs, more := takePage(dogs, limit)
+		resp.Dogs = append(resp.Dogs, dogs...)
+		if len(dogs) == 0 {
+			return 0, nil, more, nil
+		}
+		last := dogs[len(dogs)-1]
+		return len(dogs), &repository.SyncCursor{At: last.UpdatedAt, ID: last.ID}, more, nil
+	case syncEvents:
+		events, err := s.repo.ChangedEvents(scope)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		events, more := takePage(events, limit)
+		resp.Events = append(resp.Events, events...)
+		if len(events) == 0 {
+			return 0, nil, more, nil
+		}
+		last := events[len(events)-1]
+		return len(events), &repository.SyncCursor{At: last.UpdatedAt, ID: last.ID}, more, nil
+	case syncComments:
+		comments, err := s.repo.ChangedComments(scope)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		comments, more := takePage(comments, limit)
+		for i := range comments {
+			resp.Comments = append(resp.Comments, *newCommentResponse(&comments[i]))
+		}
+		if len(comments) == 0 {
+			return 0, nil, more, nil
+		}
+		last := comments[len(comments)-1]
+		return len(comments), &repository.SyncCursor{At: last.UpdatedAt, ID: last.ID}, more, nil
+	case syncNotes:
+		notes, err := s.repo.ChangedNotes(scope)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		notes, more := takePage(notes, limit)
+		for i := range notes {
+			resp.Notes = append(resp.Notes, *newNoteResponse(&notes[i]))
+		}
+		if len(notes) == 0 {
+			return 0, nil, more, nil
+		}
+		last := notes[len(notes)-1]
+		return len(notes), &repository.SyncCursor{At: last.UpdatedAt, ID: last.ID}, more, nil
+	default:
+		tombstones, err := s.repo.Tombstones(scope)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		tombstones, more := takePage(tombstones, limit)
+		resp.Tombstones = append(resp.Tombstones, tombstones...)
+		if len(tombstones) == 0 {
+			return 0, nil, more, nil
+		}
+		last := tombstones[len(tombstones)-1]
+		return len(tombstones), &repository.SyncCursor{At: last.DeletedAt, ID: last.ID}, more, nil
+	}
+}
+
+// takePage trims records read with one extra to the limit and reports whether
+// there were more
+func takePage[T any](records []T, limit int) ([]T, bool) {
+	if len(records) > limit {
+		return records[:limit], true
+	}
+	return records, false
+}
+
+// Push creates records made offline. Records already pushed by the caller are
+// reported as existing, so a push can be retried safely.
+func (s *syncService) Push(req *dto.SyncPushRequest, userID uint, role models.UserRole) (*dto.SyncPushResponse, error) {
+	resp := &dto.SyncPushResponse{
+		Events:   make([]dto.SyncPushResult, 0, len(req.Events)),
+		Comments: make([]dto.SyncPushResult, 0, len(req.Comments)),
+		Notes:    make([]dto.SyncPushResult, 0, len(req.Notes)),
+	}
+
+	for i := range req.Events {
+		result, err := s.pushEvent(&req.Events[i], userID, role)
+		if err != nil {
+			return nil, err
+		}
+		resp.Events = append(resp.Events, result)
+	}
+	for i := range req.Comments {
+		result, err := s.pushComment(&req.Comments[i], userID, role)
+		if err != nil {
+			return nil, err
+		}
+		resp.Comments = append(resp.Comments, result)
+	}
+	for i := range req.Notes {
+		result, err := s.pushNote(&req.Notes[i], userID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Notes = append(resp.Notes, result)
+	}
+
+	return resp, nil
+}
+
+// pushEvent creates one offline event
+func (s *syncService) pushEvent(item *dto.SyncEventPush, userID uint, role models.UserRole) (dto.SyncPushResult, error) {
+	result := dto.SyncPushResult{ClientID: item.ClientID}
+
+	existing := func() (bool, error) {
+		event, err := s.repo.GetEventByClientID(item.ClientID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		sameDog := (event.DogID == nil && item.DogID == nil) ||
+			(event.DogID != nil && item.DogID != nil && *event.DogID == *item.DogID)
+		if sameDog && s.canSeeDog(event.DogID, userID, role) {
+			result.Status = dto.SyncStatusExists
+			result.ID = event.ID
+		} else {
+			setConflict(&result, "client_id is already used by another record")
+		}
+		return true, nil
+	}
+
+	if found, err := existing(); found || err != nil {
+		return result, err
+	}
+
+	req := item.CreateEventRequest
+	req.ClientID = &item.ClientID
+	event, err := s.eventService.CreateEventForUser(&req, userID, role)
+	if err != nil {
+		if isNotFoundOrUnauthorized(err) {
+			return s.rejectMissingParent(result, models.TombstoneDog, *item.DogID, "dog")
+		}
+		// A concurrent push of the same record may have won the unique index
+		if found, lookupErr := existing(); found || lookupErr != nil {
+			return result, lookupErr
+		}
+		return result, err
+	}
+
+	result.Status = dto.SyncStatusCreated
+	result.ID = event.ID
+	return result, nil
+}
+
+// pushComment creates one offline comment
+func (s *syncService) pushComment(item *dto.SyncCommentPush, userID uint, role models.UserRole) (dto.SyncPushResult, error) {
+	result := dto.SyncPushResult{ClientID: item.ClientID}
+
+	existing := func() (bool, error) {
+		comment, err := s.repo.GetCommentByClientID(item.ClientID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if comment.UserID == userID {
+			result.Status = dto.SyncStatusExists
+			result.ID = comment.ID
+		} else {
+			setConflict(&result, "client_id is already used by another record")
+		}
+		return true, nil
+	}
+
+	if found, err := existing(); found || err != nil {
+		return result, err
+	}
+
+	eventID, resolved, err := s.resolveCommentEvent(item, &result)
+	if err != nil || !resolved {
+		return result, err
+	}
+
+	comment, err := s.commentService.CreateComment(&dto.CreateCommentRequest{
+		EventID:  eventID,
+		Content:  item.Content,
+		ClientID: &item.ClientID,
+	}, userID, role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.rejectMissingParent(result, models.TombstoneEvent, eventID, "event")
+		}
+		if isCommentAccessError(err) {
+			setRejected(&result, "no access to the event")
+			return result, nil
+		}
+		if found, lookupErr := existing(); found || lookupErr != nil {
+			return result, lookupErr
+		}
+		return result, err
+	}
+
+	result.Status = dto.SyncStatusCreated
+	result.ID = comment.ID
+	return result, nil
+}
+
+// resolveCommentEvent finds the server ID of the commented event
+func (s *syncService) resolveCommentEvent(item *dto.SyncCommentPush, result *dto.SyncPushResult) (uint, bool, error) {
+	if item.EventID != nil {
+		return *item.EventID, true, nil
+	}
+
+	event, err := s.repo.GetEventByClientID(*item.EventClientID)
+	if err == nil {
+		return event.ID, true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, err
+	}
+
+	deleted, err := s.repo.IsDeletedByClientID(models.TombstoneEvent, *item.EventClientID)
+	if err != nil {
+		return 0, false, err
+	}
+	if deleted {
+		setConflict(result, "event was deleted")
+	} else {
+		setRejected(result, "event not found")
+	}
+	return 0, false, nil
+}
+
+// pushNote creates one offline consultant note
+func (s *syncService) pushNote(item *dto.SyncNotePush, userID uint) (dto.SyncPushResult, error) {
+	result := dto.SyncPushResult{ClientID: item.ClientID}
+
+	existing := func() (bool, error) {
+		note, err := s.repo.GetNoteByClientID(item.ClientID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if note.ConsultantID == userID {
+			result.Status = dto.SyncStatusExists
+			result.ID = note.ID
+		} else {
+			setConflict(&result, "client_id is already used by another record")
+		}
+		return true, nil
+	}
+
+	if found, err := existing(); found || err != nil {
+		return result, err
+	}
+
+	req := item.CreateNoteRequest
+	req.ClientID = &item.ClientID
+	note, err := s.noteService.CreateNote(&req, userID)
+	if err != nil {
+		if err.Error() == "consultant does not have access to this dog" {
+			return s.rejectMissingParent(result, models.TombstoneDog, item.DogID, "dog")
+		}
+		if found, lookupErr := existing(); found || lookupErr != nil {
+			return result, lookupErr
+		}
+		return result, err
+	}
+
+	result.Status = dto.SyncStatusCreated
+	result.ID = note.ID
+	return result, nil
+}
+
+// rejectMissingParent reports a conflict if the parent record was deleted on the
+// server after the client went offline, and a rejection otherwise
+func (s *syncService) rejectMissingParent(result dto.SyncPushResult, entityType string, id uint, name string) (dto.SyncPushResult, error) {
+	deleted, err := s.repo.IsDeleted(entityType, id)
+	if err != nil {
+		return result, err
+	}
+	if deleted {
+		setConflict(&result, name+" was deleted")
+	} else {
+		setRejected(&result, name+" not found")
+	}
+	return result, nil
+}
+
+// canSeeDog reports whether the user can access the dog; events without a dog are visible
+func (s *syncService) canSeeDog(dogID *uint, userID uint, role models.UserRole) bool {
+	if dogID == nil {
+		return true
+	}
+	dog, err := s.dogRepo.GetByID(*dogID)
+	if err != nil {
+		return false
+	}
+	return checkDogAccess(s.dogRepo, dog, userID, role) == nil
+}
+
+// isCommentAccessError reports access errors returned by EventCommentService
+func isCommentAccessError(err error) bool {
+	msg := err.Error()
+	return msg == "not authorized" || msg == "event has no associated dog"
+}
+
+func setConflict(result *dto.SyncPushResult, reason string) {
+	result.Status = dto.SyncStatusConflict
+	result.Reason = reason
+}
+
+func setRejected(result *dto.SyncPushResult, reason string) {
+	result.Status = dto.SyncStatusRejected
+	result.Reason = reason
+}
+
+// encodeSyncToken builds an opaque token. A checkpoint is its time in
+// nanoseconds; a position within a paged pull is prefixed with "p" and lists the
+// checkpoint read since (0 for a snapshot), the next checkpoint, the record kind
+// and the last record sent.
+func encodeSyncToken(token *syncToken) string {
+	if token.Checkpoint.IsZero() {
+		return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(token.Since.UnixNano(), 10)))
+	}
+	var since int64
+	if token.Since != nil {
+		since = token.Since.UnixNano()
+	}
+	var after int64
+	var afterID uint
+	if token.After != nil {
+		after, afterID = token.After.At.UnixNano(), token.After.ID
+	}
+	raw := fmt.Sprintf("p:%d:%d:%d:%d:%d", since, token.Checkpoint.UnixNano(), token.Kind, after, afterID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeSyncToken parses a token produced by encodeSyncToken
+func decodeSyncToken(token string) (*syncToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidSyncToken
+	}
+
+	fields := strings.Split(string(raw), ":")
+	if len(fields) == 1 {
+		nanos, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil || nanos <= 0 {
+			return nil, ErrInvalidSyncToken
+		}
+		since := time.Unix(0, nanos).UTC()
+		return &syncToken{Since: &since}, nil
+	}
+	if len(fields) != 6 || fields[0] != "p" {
+		return nil, ErrInvalidSyncToken
+	}
+
+	var values [5]int64
+	for i := range values {
+		if values[i], err = strconv.ParseInt(fields[i+1], 10, 64); err != nil || values[i] < 0 {
+			return nil, ErrInvalidSyncToken
+		}
+	}
+	since, checkpoint, kind, after, afterID := values[0], values[1], values[2], values[3], values[4]
+	if checkpoint <= 0 || kind >= syncKinds {
+		return nil, ErrInvalidSyncToken
+	}
+
+	state := &syncToken{Checkpoint: time.Unix(0, checkpoint).UTC(), Kind: int(kind)}
+	if since > 0 {
+		sinceAt := time.Unix(0, since).UTC()
+		state.Since = &sinceAt
+	}
+	if afterID > 0 {
+		state.After = &repository.SyncCursor{At: time.Unix(0, after).UTC(), ID: uint(afterID)}
+	}
+	return state, nil
+}
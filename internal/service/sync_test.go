@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/testdb"
+)
+
+func TestSyncPullPages(t *testing.T) {
+	db := testdb.Open(t)
+	owner := models.User{Name: "Owner", Email: "owner@example.com", PasswordHash: "x", Role: models.RoleOwner}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	dog := models.Dog{OwnerID: owner.ID, Name: "Rex"}
+	if err := db.Create(&dog).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Same change time for all, so pages only advance by the id tiebreaker, and
+	// before the checkpoint's safety window
+	changed := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		event := models.Event{DogID: &dog.ID, Type: "walk", At: changed}
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&models.Event{}).Where("1 = 1").UpdateColumn("updated_at", changed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&dog).UpdateColumn("updated_at", changed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := &syncService{repo: repository.NewSyncRepository(db)}
+	seen := map[uint]int{}
+	dogs, pages := 0, 0
+	token := ""
+	for {
+		resp, err := s.Pull(token, 2, owner.ID, models.RoleOwner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		if !resp.Full {
+			t.Fatalf("page %d of a snapshot is not marked full", pages)
+		}
+		if n := len(resp.Dogs) + len(resp.Events); n > 2 {
+			t.Fatalf("page %d has %d records, limit is 2", pages, n)
+		}
+		dogs += len(resp.Dogs)
+		for _, event := range resp.Events {
+			seen[event.ID]++
+		}
+		token = resp.NextSince
+		if resp.Token != resp.NextSince {
+			t.Fatalf("token %q differs from next_since %q", resp.Token, resp.NextSince)
+		}
+		if !resp.HasMore {
+			break
+		}
+		if pages > 10 {
+			t.Fatal("pull does not finish")
+		}
+	}
+
+	if dogs != 1 || len(seen) != 5 {
+		t.Fatalf("expected 1 dog and 5 events, got %d dogs and %d events", dogs, len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("event %d sent %d times", id, n)
+		}
+	}
+
+	// The last page hands out a checkpoint: nothing changed since
+	resp, err := s.Pull(token, 2, owner.ID, models.RoleOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Full || resp.HasMore || len(resp.Dogs)+len(resp.Events) != 0 {
+		t.Fatalf("expected an empty delta, got full=%v has_more=%v with %d dogs and %d events", resp.Full, resp.HasMore, len(resp.Dogs), len(resp.Events))
+	}
+}
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	since := time.Unix(0, 1746086400123456789).UTC()
+	tokens := []*syncToken{
+		{Since: &since},
+		{Checkpoint: since, Kind: syncEvents, After: &repository.SyncCursor{At: since, ID: 42}},
+		{Since: &since, Checkpoint: since.Add(time.Hour), Kind: syncTombstones},
+	}
+	for _, want := range tokens {
+		got, err := decodeSyncToken(encodeSyncToken(want))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encodeSyncToken(got) != encodeSyncToken(want) {
+			t.Errorf("token %+v decoded as %+v", want, got)
+		}
+	}
+
+	for _, token := range []string{"garbage", "cDoxOjI6Mzo0", "cDowOjA6MDowOjA"} {
+		if _, err := decodeSyncToken(token); err != ErrInvalidSyncToken {
+			t.Errorf("%q: expected %v, got %v", token, ErrInvalidSyncToken, err)
+		}
+	}
+}
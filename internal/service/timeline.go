@@ -0,0 +1,195 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TimelineService interface for the merged dog timeline
+type TimelineService interface {
+	GetDogTimeline(dogID uint, params *dto.TimelineParams, userID uint, role models.UserRole) (*dto.TimelineResponse, error)
+}
+
+// timelineService implementation of the timeline service
+type timelineService struct {
+	repo    repository.TimelineRepository
+	dogRepo repository.DogRepository
+}
+
+// NewTimelineService creates a new timeline service
+func NewTimelineService(repo repository.TimelineRepository, dogRepo repository.DogRepository) TimelineService {
+	return &timelineService{
+		repo:    repo,
+		dogRepo: dogRepo,
+	}
+}
+
+// GetDogTimeline returns events, comments and visible consultant notes of a dog
+// as one chronologically ordered, cursor-paginated feed
+func (s *timelineService) GetDogTimeline(dogID uint, params *dto.TimelineParams, userID uint, role models.UserRole) (*dto.TimelineResponse, error) {
+	dog, err := s.dogRepo.GetByID(dogID)
+	if err != nil {
+		return nil, err
+	}
+	// Same visibility as event listing: admin all, owner own dogs, consultant assigned dogs
+	if err := checkDogAccess(s.dogRepo, dog, userID, role); err != nil {
+		return nil, err
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := &repository.TimelineQuery{
+		DogID: dogID,
+		Limit: limit + 1, // fetch one extra to detect the next page
+	}
+
+	// Notes are private to their author; admins see all of them
+	switch role {
+	case models.RoleAdmin:
+		all := uint(0)
+		query.NoteAuthorID = &all
+	case models.RoleConsultant:
+		query.NoteAuthorID = &userID
+	}
+
+	if params.Cursor != "" {
+		after, err := decodeTimelineCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	entries, err := s.repo.List(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.TimelineResponse{Items: []dto.TimelineItem{}}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		resp.HasMore = true
+		resp.NextCursor = encodeTimelineCursor(&entries[len(entries)-1])
+	}
+
+	items, err := s.hydrate(entries)
+	if err != nil {
+		return nil, err
+	}
+	resp.Items = items
+
+	return resp, nil
+}
+
+// hydrate loads the full records behind timeline entries, preserving order
+func (s *timelineService) hydrate(entries []repository.TimelineEntry) ([]dto.TimelineItem, error) {
+	var eventIDs, commentIDs, noteIDs []uint
+	for _, entry := range entries {
+		switch entry.Kind {
+		case dto.TimelineItemEvent:
+			eventIDs = append(eventIDs, entry.ID)
+		case dto.TimelineItemComment:
+			commentIDs = append(commentIDs, entry.ID)
+		case dto.TimelineItemNote:
+			noteIDs = append(noteIDs, entry.ID)
+		}
+	}
+
+	events, err := s.repo.LoadEvents(eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.repo.LoadComments(commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.repo.LoadNotes(noteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsByID := make(map[uint]*models.Event, len(events))
+	for i := range events {
+		eventsByID[events[i].ID] = &events[i]
+	}
+	commentsByID := make(map[uint]*models.EventComment, len(comments))
+	for i := range comments {
+		commentsByID[comments[i].ID] = &comments[i]
+	}
+	notesByID := make(map[uint]*models.ConsultantNote, len(notes))
+	for i := range notes {
+		notesByID[notes[i].ID] = &notes[i]
+	}
+
+	items := make([]dto.TimelineItem, 0, len(entries))
+	for _, entry := range entries {
+		item := dto.TimelineItem{Type: entry.Kind, ID: entry.ID, At: entry.OccurredAt}
+		switch entry.Kind {
+		case dto.TimelineItemEvent:
+			item.Event = eventsByID[entry.ID]
+		case dto.TimelineItemComment:
+			if comment, ok := commentsByID[entry.ID]; ok {
+				item.Comment = newCommentResponse(comment)
+			}
+		case dto.TimelineItemNote:
+			if note, ok := notesByID[entry.ID]; ok {
+				item.Note = newNoteResponse(note)
+			}
+		}
+		// Skip items deleted between listing and hydration
+		if item.Event == nil && item.Comment == nil && item.Note == nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// encodeTimelineCursor builds an opaque cursor pointing at the given entry
+func encodeTimelineCursor(entry *repository.TimelineEntry) string {
+	raw := fmt.Sprintf("%d|%s|%d", entry.OccurredAt.UnixNano(), entry.Kind, entry.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTimelineCursor parses a cursor produced by encodeTimelineCursor
+func decodeTimelineCursor(cursor string) (*repository.TimelineEntry, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &repository.TimelineEntry{
+		Kind:       parts[1],
+		ID:         uint(id),
+		OccurredAt: time.Unix(0, nanos).UTC(),
+	}, nil
+}
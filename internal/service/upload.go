@@ -0,0 +1,465 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/storage"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Errors returned by the upload service
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadOffset   = errors.New("upload offset does not match")
+	ErrUploadTooLarge = errors.New("upload exceeds the maximum size")
+)
+
+// UploadOptions configures resumable uploads
+type UploadOptions struct {
+	MaxSize int64         // Largest accepted upload in bytes
+	Expiry  time.Duration // How long an upload is kept after its last chunk, complete or not
+}
+
+// UploadService interface for resumable (tus) uploads. Chunks are stored through
+// FileStorage as they arrive and joined into one file once all bytes are in.
+type UploadService interface {
+	// MaxSize returns the largest accepted upload
+	MaxSize() int64
+	// CreateUpload starts an upload of length bytes
+	CreateUpload(userID uint, filename, contentType string, length int64) (*models.Upload, error)
+	// GetUpload returns an unexpired upload of the user
+	GetUpload(id string, userID uint) (*models.Upload, error)
+	// WriteChunk stores body at offset, which must be the upload's offset, and
+	// completes the upload when all bytes are in
+	WriteChunk(id string, userID uint, offset int64, body io.Reader) (*models.Upload, error)
+	// DeleteUpload terminates an upload and removes its files
+	DeleteUpload(id string, userID uint) error
+	// Attachments describes completed uploads of the user as attachments; creating
+	// them through AttachmentService consumes the uploads
+	Attachments(ids []string, userID uint) ([]models.Attachment, error)
+	// PurgeExpired removes expired uploads and their files
+	PurgeExpired(ctx context.Context) error
+}
+
+// uploadService implementation of the upload service
+type uploadService struct {
+	repo    repository.UploadRepository
+	storage storage.FileStorage
+	images  ImageService
+	opts    UploadOptions
+}
+
+// NewUploadService creates a new upload service. Completed images are stored
+// through the image service.
+func NewUploadService(repo repository.UploadRepository, storage storage.FileStorage, images ImageService, opts UploadOptions) UploadService {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 30
+	}
+	if opts.Expiry <= 0 {
+		opts.Expiry = 24 * time.Hour
+	}
+	return &uploadService{
+		repo:    repo,
+		storage: storage,
+		images:  images,
+		opts:    opts,
+	}
+}
+
+// MaxSize returns the largest accepted upload
+func (s *uploadService) MaxSize() int64 {
+	return s.opts.MaxSize
+}
+
+// CreateUpload starts an upload. The type is checked by extension now and by
+// content once the upload completes.
+func (s *uploadService) CreateUpload(userID uint, filename, contentType string, length int64) (*models.Upload, error) {
+	if length <= 0 {
+		return nil, utils.ErrFileEmpty
+	}
+	if length > s.opts.MaxSize {
+		return nil, ErrUploadTooLarge
+	}
+	if !utils.AllowedExtensions[strings.ToLower(filepath.Ext(filename))] {
+		return nil, utils.ErrFileNotAllowed
+	}
+
+	upload := &models.Upload{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Length:      length,
+		Backend:     s.storage.Name(),
+		ExpiresAt:   time.Now().Add(s.opts.Expiry),
+	}
+	if err := s.repo.Create(upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// GetUpload returns an unexpired upload of the user, stored in the current backend
+func (s *uploadService) GetUpload(id string, userID uint) (*models.Upload, error) {
+	upload, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upload.UserID != userID || upload.Backend != s.storage.Name() || time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteChunk stores body at offset. Bytes received before the request broke
+// are kept, so the client resumes from the upload's new offset. A final chunk
+// that completed the offset but failed to join can be retried with an empty body.
+func (s *uploadService) WriteChunk(id string, userID uint, offset int64, body io.Reader) (*models.Upload, error) {
+	upload, err := s.GetUpload(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrUploadOffset
+	}
+
+	if upload.Offset < upload.Length {
+		if err := s.storeChunk(upload, body); err != nil {
+			return upload, err
+		}
+	}
+	if upload.Offset == upload.Length && !upload.Complete() {
+		if err := s.complete(upload); err != nil {
+			return upload, err
+		}
+	}
+	return upload, nil
+}
+
+// storeChunk stores the bytes of body up to the upload's length as a chunk
+func (s *uploadService) storeChunk(upload *models.Upload, body io.Reader) error {
+	received := &partialReader{r: io.LimitReader(body, upload.Length-upload.Offset)}
+	key, err := s.storage.Upload(received, upload.Filename, upload.ContentType)
+	if err != nil {
+		return err
+	}
+	if received.n == 0 {
+		s.removeFile(key)
+		return nil
+	}
+
+	upload.ExpiresAt = time.Now().Add(s.opts.Expiry)
+	added, err := s.repo.AddChunk(upload, &models.UploadChunk{Offset: upload.Offset, Size: received.n, Key: key})
+	if err != nil || !added {
+		// A concurrent request wrote at this offset first
+		s.removeFile(key)
+		if err == nil {
+			err = ErrUploadOffset
+		}
+		return err
+	}
+	return nil
+}
+
+// complete validates the received file by its content and joins the chunks;
+// images are stored without their metadata and documents checked whole
+// instead. An upload of a type that is not allowed is discarded.
+func (s *uploadService) complete(upload *models.Upload) error {
+	chunks := &chunkReader{storage: s.storage, chunks: upload.Chunks}
+	defer chunks.Close()
+
+	src := bufio.NewReaderSize(chunks, utils.SniffLen)
+	head, err := src.Peek(utils.SniffLen)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if err := utils.ValidateFileHead(upload.Filename, head); err != nil {
+		s.discard(upload)
+		return err
+	}
+
+	var stored *models.Attachment
+	contentType := utils.DetectFileType(upload.Filename, head)
+	switch {
+	case s.images.Supported(contentType):
+		stored, err = s.storeImage(upload, src, contentType)
+	case utils.IsDocument(contentType):
+		stored, err = s.storeDocument(upload, src, contentType)
+	default:
+		stored, err = s.join(upload, src, contentType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Files of the chunks are removed, unless one is kept as the file
+	chunkKeys := make(map[string]bool, len(upload.Chunks))
+	for _, chunk := range upload.Chunks {
+		chunkKeys[chunk.Key] = true
+	}
+
+	now := time.Now()
+	received := *upload
+	upload.Key, upload.SHA256, upload.Size = stored.Key, stored.SHA256, stored.Size
+	upload.Filename, upload.ContentType = stored.Filename, stored.ContentType
+	upload.Thumbnails = stored.Thumbnails
+	upload.CompletedAt = &now
+	if err := s.repo.Complete(upload); err != nil {
+		for _, key := range stored.Keys() {
+			if !chunkKeys[key] {
+				s.removeFile(key)
+			}
+		}
+		*upload = received
+		return err
+	}
+
+	for key := range chunkKeys {
+		if key != stored.Key {
+			s.removeFile(key)
+		}
+	}
+	upload.Chunks = nil
+	return nil
+}
+
+// join stores the chunks as one file. A file received in one chunk is kept as
+// is, otherwise the chunks are copied into one.
+func (s *uploadService) join(upload *models.Upload, src io.Reader, contentType string) (*models.Attachment, error) {
+	hash := sha256.New()
+	stored := &models.Attachment{Filename: upload.Filename, ContentType: contentType, Size: upload.Length}
+	var err error
+	if len(upload.Chunks) == 1 {
+		stored.Key = upload.Chunks[0].Key
+		_, err = io.Copy(hash, src)
+	} else {
+		stored.Key, err = s.storage.Upload(io.TeeReader(src, hash), upload.Filename, contentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return stored, nil
+}
+
+// storeImage stores an uploaded image without its metadata, with thumbnails.
+// Images are decoded in memory, so they are limited to utils.MaxFileSize like
+// images sent in forms.
+func (s *uploadService) storeImage(upload *models.Upload, src io.Reader, contentType string) (*models.Attachment, error) {
+	data, err := s.readWhole(upload, src)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.images.Store(data, upload.Filename, contentType)
+	if err != nil {
+		if isImageError(err) {
+			s.discard(upload)
+		}
+		return nil, err
+	}
+	return stored, nil
+}
+
+// storeDocument checks the structure of an uploaded document and joins it,
+// with the same limit as images
+func (s *uploadService) storeDocument(upload *models.Upload, src io.Reader, contentType string) (*models.Attachment, error) {
+	data, err := s.readWhole(upload, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateDocument(contentType, data); err != nil {
+		s.discard(upload)
+		return nil, err
+	}
+	return s.join(upload, bytes.NewReader(data), contentType)
+}
+
+// readWhole reads a received file into memory, discarding an upload over
+// utils.MaxFileSize
+func (s *uploadService) readWhole(upload *models.Upload, src io.Reader) ([]byte, error) {
+	if upload.Length > utils.MaxFileSize {
+		s.discard(upload)
+		return nil, utils.ErrFileTooLarge
+	}
+	return io.ReadAll(src)
+}
+
+// DeleteUpload terminates an upload and removes its files
+func (s *uploadService) DeleteUpload(id string, userID uint) error {
+	upload, err := s.GetUpload(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.discard(upload)
+}
+
+// Attachments describes completed uploads of the user as attachments, in the
+// order of ids
+func (s *uploadService) Attachments(ids []string, userID uint) ([]models.Attachment, error) {
+	uploads, err := s.repo.ListCompleted(ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Upload, len(uploads))
+	for _, upload := range uploads {
+		if upload.Backend == s.storage.Name() && time.Now().Before(upload.ExpiresAt) {
+			byID[upload.ID] = upload
+		}
+	}
+
+	attachments := make([]models.Attachment, 0, len(ids))
+	for _, id := range ids {
+		upload, ok := byID[id]
+		if !ok {
+			return nil, ErrUploadNotFound
+		}
+		delete(byID, id) // An upload is attached once
+		attachments = append(attachments, models.Attachment{
+			Key:         upload.Key,
+			Backend:     upload.Backend,
+			Filename:    upload.Filename,
+			ContentType: upload.ContentType,
+			Size:        upload.Size,
+			SHA256:      upload.SHA256,
+			UploadID:    upload.ID,
+			Thumbnails:  upload.Thumbnails,
+		})
+	}
+	return attachments, nil
+}
+
+// purgeBatchSize is the number of expired uploads removed at a time
+const purgeBatchSize = 100
+
+// PurgeExpired removes uploads that were neither finished nor attached in time
+func (s *uploadService) PurgeExpired(ctx context.Context) error {
+	purged := 0
+	for {
+		uploads, err := s.repo.ListExpired(time.Now(), purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range uploads {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if uploads[i].Backend != s.storage.Name() {
+				// Files of another backend cannot be reached, only the record goes
+				log.Printf("uploads: dropping expired upload %s of backend %s", uploads[i].ID, uploads[i].Backend)
+				uploads[i].Chunks, uploads[i].Thumbnails, uploads[i].Key = nil, nil, ""
+			}
+			if err := s.discard(&uploads[i]); err != nil {
+				return err
+			}
+			purged++
+		}
+		if len(uploads) < purgeBatchSize {
+			break
+		}
+	}
+	if purged > 0 {
+		log.Printf("maintenance: purged %d expired uploads", purged)
+	}
+	return nil
+}
+
+// discard removes an upload and the files stored for it
+func (s *uploadService) discard(upload *models.Upload) error {
+	if err := s.repo.Delete(upload.ID); err != nil {
+		return err
+	}
+	for _, chunk := range upload.Chunks {
+		s.removeFile(chunk.Key)
+	}
+	for _, thumbnail := range upload.Thumbnails {
+		s.removeFile(thumbnail.Key)
+	}
+	if upload.Key != "" {
+		s.removeFile(upload.Key)
+	}
+	return nil
+}
+
+// removeFile deletes a stored file; failures only leave an orphan, so they are logged
+func (s *uploadService) removeFile(key string) {
+	if err := s.storage.Delete(key); err != nil {
+		log.Printf("uploads: failed to delete %s: %v", key, err)
+	}
+}
+
+// partialReader ends the stream at the first read error instead of failing it,
+// so that storage keeps the bytes received before a client disconnected
+type partialReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (p *partialReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if err != nil && err != io.EOF {
+		p.err = err
+		err = io.EOF
+	}
+	return n, err
+}
+
+// chunkReader reads the chunks of an upload one after the other, opening each
+// when it is reached
+type chunkReader struct {
+	storage storage.FileStorage
+	chunks  []models.UploadChunk
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			file, err := r.storage.Open(r.chunks[0].Key)
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.chunks = file, r.chunks[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close closes the chunk being read
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
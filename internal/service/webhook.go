@@ -0,0 +1,546 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/you/pawtrack/internal/dto"
+	"github.com/you/pawtrack/internal/events"
+	"github.com/you/pawtrack/internal/jobs"
+	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Webhook errors
+var (
+	ErrUnknownWebhookEventType = errors.New("unknown webhook event type")
+	ErrInvalidWebhookURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrWebhookInactive         = errors.New("webhook endpoint is inactive")
+	ErrWebhookAddressForbidden = errors.New("webhook url must not point to a loopback, private or link-local address")
+)
+
+// Signature headers sent with every delivery
+const (
+	WebhookSignatureHeader = "X-Pawtrack-Signature" // "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>"
+	WebhookTimestampHeader = "X-Pawtrack-Timestamp" // Unix seconds, part of the signed content
+	WebhookEventHeader     = "X-Pawtrack-Event"
+	WebhookDeliveryHeader  = "X-Pawtrack-Delivery"
+)
+
+// maxDrainedResponse limits how much of an endpoint's response body is read
+// so that the connection can be reused; the body itself is not kept
+const maxDrainedResponse = 4096
+
+// WebhookDeliverJob is the background job type sending one delivery
+const WebhookDeliverJob = "webhook.deliver"
+
+// webhookDeliverPayload is the payload of a WebhookDeliverJob
+type webhookDeliverPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// WebhookOptions configures delivery of webhooks
+type WebhookOptions struct {
+	MaxAttempts int           // Attempts before a delivery is marked failed
+	BaseBackoff time.Duration // Delay before the first retry, doubled for each following one
+	Timeout     time.Duration // Per-request timeout
+
+	// AllowPrivateNetworks permits endpoints on loopback, private and link-local
+	// addresses, for development and tests. Otherwise such addresses are refused
+	// at registration and, after DNS resolution, when connecting.
+	AllowPrivateNetworks bool
+}
+
+// WebhookService manages webhook endpoints and delivers domain events to them
+type WebhookService interface {
+	// HandleEvent queues deliveries of the event, for use as a bus subscriber
+	HandleEvent(event events.Event)
+
+	CreateEndpoint(req *dto.CreateWebhookRequest, userID uint) (*dto.WebhookResponse, error)
+	ListEndpoints(userID uint, role models.UserRole) ([]dto.WebhookResponse, error)
+	GetEndpoint(id uint, userID uint, role models.UserRole) (*dto.WebhookResponse, error)
+	UpdateEndpoint(id uint, req *dto.UpdateWebhookRequest, userID uint, role models.UserRole) (*dto.WebhookResponse, error)
+	DeleteEndpoint(id uint, userID uint, role models.UserRole) error
+	ListDeliveries(endpointID uint, params *dto.WebhookDeliveryParams, userID uint, role models.UserRole) (*dto.WebhookDeliveryListResponse, error)
+	Redeliver(endpointID uint, deliveryID uint, userID uint, role models.UserRole) (*dto.WebhookDeliveryResponse, error)
+}
+
+// webhookService implementation of the webhook service
+type webhookService struct {
+	repo   repository.WebhookRepository
+	queue  *jobs.Queue
+	client *http.Client
+	opts   WebhookOptions
+}
+
+// NewWebhookService creates a new webhook service and registers its delivery job with the queue
+func NewWebhookService(repo repository.WebhookRepository, queue *jobs.Queue, opts WebhookOptions) WebhookService {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 30 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	s := &webhookService{
+		repo:   repo,
+		queue:  queue,
+		client: newWebhookClient(opts),
+		opts:   opts,
+	}
+	jobs.Register(queue, WebhookDeliverJob, jobs.TypeOptions{
+		MaxAttempts: opts.MaxAttempts,
+		Backoff:     opts.BaseBackoff,
+		Timeout:     2 * opts.Timeout,
+	}, s.deliver)
+	return s
+}
+
+// CreateEndpoint registers an endpoint for the user; the response carries the
+// signing secret, which is not returned again
+func (s *webhookService) CreateEndpoint(req *dto.CreateWebhookRequest, userID uint) (*dto.WebhookResponse, error) {
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := encodeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		UserID:      userID,
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      utils.GenerateRandomString(64),
+		EventTypes:  eventTypes,
+		Active:      true,
+	}
+	if err := s.repo.CreateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+
+	resp := newWebhookResponse(endpoint)
+	resp.Secret = endpoint.Secret
+	return resp, nil
+}
+
+// ListEndpoints returns the user's endpoints; admins see all of them
+func (s *webhookService) ListEndpoints(userID uint, role models.UserRole) ([]dto.WebhookResponse, error) {
+	var owner *uint
+	if role != models.RoleAdmin {
+		owner = &userID
+	}
+
+	endpoints, err := s.repo.ListEndpoints(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.WebhookResponse, 0, len(endpoints))
+	for i := range endpoints {
+		resp = append(resp, *newWebhookResponse(&endpoints[i]))
+	}
+	return resp, nil
+}
+
+// GetEndpoint returns an endpoint of the user (any endpoint for admins)
+func (s *webhookService) GetEndpoint(id uint, userID uint, role models.UserRole) (*dto.WebhookResponse, error) {
+	endpoint, err := s.getEndpoint(id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return newWebhookResponse(endpoint), nil
+}
+
+// UpdateEndpoint changes the fields present in the request
+func (s *webhookService) UpdateEndpoint(id uint, req *dto.UpdateWebhookRequest, userID uint, role models.UserRole) (*dto.WebhookResponse, error) {
+	endpoint, err := s.getEndpoint(id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Description != nil {
+		endpoint.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		eventTypes, err := encodeWebhookEventTypes(req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.EventTypes = eventTypes
+	}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+
+	if err := s.repo.UpdateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	return newWebhookResponse(endpoint), nil
+}
+
+// DeleteEndpoint removes an endpoint and its delivery log
+func (s *webhookService) DeleteEndpoint(id uint, userID uint, role models.UserRole) error {
+	if _, err := s.getEndpoint(id, userID, role); err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(id)
+}
+
+// ListDeliveries returns a page of the endpoint's delivery log, newest first
+func (s *webhookService) ListDeliveries(endpointID uint, params *dto.WebhookDeliveryParams, userID uint, role models.UserRole) (*dto.WebhookDeliveryListResponse, error) {
+	if _, err := s.getEndpoint(endpointID, userID, role); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(endpointID, params.Status, (params.Page-1)*params.PageSize, params.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.WebhookDeliveryListResponse{
+		Deliveries: make([]dto.WebhookDeliveryResponse, 0, len(deliveries)),
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalCount: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.PageSize))),
+	}
+	for i := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, *newWebhookDeliveryResponse(&deliveries[i]))
+	}
+	return resp, nil
+}
+
+// Redeliver queues a new delivery with the same payload as an earlier one
+func (s *webhookService) Redeliver(endpointID uint, deliveryID uint, userID uint, role models.UserRole) (*dto.WebhookDeliveryResponse, error) {
+	endpoint, err := s.getEndpoint(endpointID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Active {
+		return nil, ErrWebhookInactive
+	}
+
+	original, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.EndpointID != endpoint.ID {
+		return nil, errors.New("unauthorized")
+	}
+
+	now := time.Now().UTC()
+	deliveries := []models.WebhookDelivery{{
+		EndpointID:    endpoint.ID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		RedeliveryOf:  &original.ID,
+		NextAttemptAt: &now,
+	}}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return nil, err
+	}
+	s.enqueue(deliveries)
+
+	return newWebhookDeliveryResponse(&deliveries[0]), nil
+}
+
+// HandleEvent queues a delivery of the event for every matching endpoint. Errors are
+// logged only: a failing webhook must not fail the request that caused the change.
+func (s *webhookService) HandleEvent(event events.Event) {
+	msgType, dogID := event.Type(), event.DogID()
+	endpoints, err := s.repo.MatchingEndpoints(dogID, msgType, event.Recipient())
+	if err != nil {
+		log.Printf("webhooks: find endpoints for %s: %v", msgType, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	body, err := json.Marshal(dto.WebhookPayload{
+		ID:        utils.GenerateRandomString(32),
+		Type:      msgType,
+		DogID:     dogID,
+		CreatedAt: now,
+		Data:      event.Payload(),
+	})
+	if err != nil {
+		log.Printf("webhooks: encode %s payload: %v", msgType, err)
+		return
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventType:     msgType,
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		log.Printf("webhooks: queue %s deliveries: %v", msgType, err)
+		return
+	}
+	s.enqueue(deliveries)
+}
+
+// enqueue starts a background job for each new delivery
+func (s *webhookService) enqueue(deliveries []models.WebhookDelivery) {
+	for _, delivery := range deliveries {
+		if _, err := s.queue.Enqueue(WebhookDeliverJob, webhookDeliverPayload{DeliveryID: delivery.ID}); err != nil {
+			log.Printf("webhooks: enqueue delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+// deliver is the WebhookDeliverJob handler: it sends a delivery once and records
+// the outcome. A returned error makes the queue retry the job with backoff; the
+// delivery shows when that will happen.
+func (s *webhookService) deliver(ctx context.Context, job *models.Job, payload webhookDeliverPayload) error {
+	delivery, err := s.repo.GetDelivery(payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Removed with its endpoint
+			return nil
+		}
+		return err
+	}
+	// A failed delivery is only sent again when an admin retries its dead job
+	if delivery.Status == models.WebhookDeliverySucceeded {
+		return nil
+	}
+
+	delivery.Attempts++
+	statusCode, sendErr := s.send(ctx, delivery)
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case errors.Is(sendErr, ErrWebhookInactive):
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = nil
+		sendErr = jobs.Permanent(sendErr)
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = s.queue.NextAttemptAt(job)
+		if delivery.NextAttemptAt == nil {
+			delivery.Status = models.WebhookDeliveryFailed
+		}
+	}
+
+	if err := s.repo.SaveAttempt(delivery); err != nil {
+		return fmt.Errorf("save delivery %d: %w", delivery.ID, err)
+	}
+	return sendErr
+}
+
+// send posts the signed payload and returns the status code; any non-2xx
+// response, redirects included, is an error
+func (s *webhookService) send(ctx context.Context, delivery *models.WebhookDelivery) (*int, error) {
+	endpoint, err := s.repo.GetEndpoint(delivery.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Active {
+		return nil, ErrWebhookInactive
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Pawtrack-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(endpoint.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedResponse))
+
+	statusCode := resp.StatusCode
+	if statusCode < 200 || statusCode > 299 {
+		return &statusCode, fmt.Errorf("endpoint responded with status %d", statusCode)
+	}
+	return &statusCode, nil
+}
+
+// newWebhookClient creates the client deliveries are sent with. It does not
+// follow redirects nor use a proxy, and unless private networks are allowed it
+// checks the address actually dialled, so that a host name resolving to an
+// internal address, or re-resolving to one later, is refused.
+func newWebhookClient(opts WebhookOptions) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateAddress(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressForbidden, host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   opts.Timeout,
+			ResponseHeaderTimeout: opts.Timeout,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPrivateAddress reports whether an address is not reachable from the
+// internet: loopback, private, link-local, multicast or unspecified
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// getEndpoint loads an endpoint, hiding other users' endpoints from non-admins
+func (s *webhookService) getEndpoint(id uint, userID uint, role models.UserRole) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleAdmin && endpoint.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+	return endpoint, nil
+}
+
+// signWebhook computes the hex HMAC-SHA256 of "<timestamp>.<body>"; including the
+// timestamp lets receivers reject replayed requests
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateURL accepts absolute http(s) URLs only. Unless private networks are
+// allowed, localhost and literal internal addresses are refused up front; host
+// names are checked when a delivery connects.
+func (s *webhookService) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidWebhookURL
+	}
+	if s.opts.AllowPrivateNetworks {
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddressForbidden
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateAddress(ip) {
+		return ErrWebhookAddressForbidden
+	}
+	return nil
+}
+
+// encodeWebhookEventTypes validates and deduplicates event types and stores them
+// wrapped in commas, see models.WebhookEndpoint.EventTypes
+func encodeWebhookEventTypes(eventTypes []string) (string, error) {
+	seen := make(map[string]bool, len(eventTypes))
+	var unique []string
+	for _, eventType := range eventTypes {
+		if !isWebhookEventType(eventType) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownWebhookEventType, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return "," + strings.Join(unique, ",") + ",", nil
+}
+
+// isWebhookEventType reports whether endpoints can subscribe to the type
+func isWebhookEventType(eventType string) bool {
+	for _, known := range events.Types() {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookResponse maps an endpoint to its response, without the secret
+func newWebhookResponse(endpoint *models.WebhookEndpoint) *dto.WebhookResponse {
+	return &dto.WebhookResponse{
+		ID:          endpoint.ID,
+		UserID:      endpoint.UserID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  strings.Split(strings.Trim(endpoint.EventTypes, ","), ","),
+		Active:      endpoint.Active,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
+
+// newWebhookDeliveryResponse maps a delivery to its response with the payload inlined
+func newWebhookDeliveryResponse(delivery *models.WebhookDelivery) *dto.WebhookDeliveryResponse {
+	return &dto.WebhookDeliveryResponse{
+		WebhookDelivery: *delivery,
+		Payload:         json.RawMessage(delivery.Payload),
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+	_, err := newWebhookClient(WebhookOptions{Timeout: time.Second}).Do(req)
+	if !errors.Is(err, ErrWebhookAddressForbidden) {
+		t.Fatalf("expected %v, got %v", ErrWebhookAddressForbidden, err)
+	}
+
+	resp, err := newWebhookClient(WebhookOptions{Timeout: time.Second, AllowPrivateNetworks: true}).Do(req)
+	if err != nil {
+		t.Fatalf("allowed private networks: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/target" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/target", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	resp, err := newWebhookClient(WebhookOptions{Timeout: time.Second, AllowPrivateNetworks: true}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect || followed {
+		t.Fatalf("redirect followed: status %d", resp.StatusCode)
+	}
+}
+
+func TestWebhookValidateURL(t *testing.T) {
+	s := &webhookService{}
+	for url, want := range map[string]error{
+		"https://example.com/hook":        nil,
+		"http://93.184.216.34:8080/hook":  nil,
+		"ftp://example.com/hook":          ErrInvalidWebhookURL,
+		"http://localhost:8080/hook":      ErrWebhookAddressForbidden,
+		"http://app.localhost/hook":       ErrWebhookAddressForbidden,
+		"http://127.0.0.1/hook":           ErrWebhookAddressForbidden,
+		"http://10.0.0.5/hook":            ErrWebhookAddressForbidden,
+		"http://192.168.1.1/hook":         ErrWebhookAddressForbidden,
+		"http://169.254.169.254/metadata": ErrWebhookAddressForbidden,
+		"http://[::1]/hook":               ErrWebhookAddressForbidden,
+		"http://[fd00::1]/hook":           ErrWebhookAddressForbidden,
+		"http://0.0.0.0/hook":             ErrWebhookAddressForbidden,
+	} {
+		if err := s.validateURL(url); !errors.Is(err, want) {
+			t.Errorf("%s: expected %v, got %v", url, want, err)
+		}
+	}
+
+	s.opts.AllowPrivateNetworks = true
+	if err := s.validateURL("http://127.0.0.1/hook"); err != nil {
+		t.Errorf("allowed private networks: %v", err)
+	}
+}
@@ -1,31 +1,54 @@
 package storage
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Errors returned by LocalStorage.Resolve
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrURLExpired       = errors.New("url expired")
+)
+
+// LocalStorage keeps files on disk. Files are not public: they are served from
+// /files/{key} only with a signature issued by GetSignedURL.
 type LocalStorage struct {
-	uploadDir string
-	baseURL   string
+	uploadDir  string
+	baseURL    string
+	signingKey []byte
 }
 
-func NewLocalStorage(uploadDir, baseURL string) *LocalStorage {
+func NewLocalStorage(uploadDir, baseURL string, signingKey []byte) *LocalStorage {
 	// Create upload directories if they don't exist
 	os.MkdirAll(filepath.Join(uploadDir, "events"), 0755)
 	os.MkdirAll(filepath.Join(uploadDir, "comments"), 0755)
 
 	return &LocalStorage{
-		uploadDir: uploadDir,
-		baseURL:   baseURL,
+		uploadDir:  uploadDir,
+		baseURL:    baseURL,
+		signingKey: signingKey,
 	}
 }
 
+func (s *LocalStorage) Name() string {
+	return "local"
+}
+
 func (s *LocalStorage) Upload(file io.Reader, filename string, contentType string) (string, error) {
 	// Generate unique filename
 	ext := filepath.Ext(filename)
@@ -41,28 +64,108 @@ func (s *LocalStorage) Upload(file io.Reader, filename string, contentType strin
 	if err != nil {
 		return "", fmt.Errorf("failed to create file: %w", err)
 	}
-	defer out.Close()
 
-	// Copy the uploaded file to the destination
-	if _, err := io.Copy(out, file); err != nil {
+	// Stream the upload to the destination; a failed or rejected upload
+	// (e.g. over the size limit) must not leave a partial file behind
+	_, err = io.Copy(out, file)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fullPath)
 		return "", fmt.Errorf("failed to save file: %w", err)
 	}
 
-	// Return the public URL
-	fileURL := fmt.Sprintf("%s/uploads/%s/%s", s.baseURL, subdir, uniqueFilename)
-	return fileURL, nil
+	return subdir + "/" + uniqueFilename, nil
 }
 
-func (s *LocalStorage) Delete(fileURL string) error {
-	// Extract filename from URL
-	// URL format: http://localhost:8080/uploads/events/{filename}
-	// Parse the path and delete the file
-	
-	// For now, return nil (implement deletion later if needed)
+// Open reads a stored file
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	key = cleanKey(key)
+	if key == "" {
+		return nil, errors.New("empty key")
+	}
+	file, err := os.Open(filepath.Join(s.uploadDir, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes a file; deleting a missing file is not an error
+func (s *LocalStorage) Delete(key string) error {
+	key = cleanKey(key)
+	if key == "" {
+		return errors.New("empty key")
+	}
+	if err := os.Remove(filepath.Join(s.uploadDir, filepath.FromSlash(key))); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
 	return nil
 }
 
-func (s *LocalStorage) GetSignedURL(fileURL string, expiryDuration time.Duration) (string, error) {
-	// Local storage doesn't need signed URLs - files are publicly accessible
-	return fileURL, nil
+// List walks the files under the events directory
+func (s *LocalStorage) List(fn func(file FileInfo) error) error {
+	return filepath.WalkDir(filepath.Join(s.uploadDir, "events"), func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.uploadDir, fullPath)
+		if err != nil {
+			return err
+		}
+		return fn(FileInfo{Key: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+	})
+}
+
+// GetSignedURL returns a /files URL valid for expiryDuration. The type and
+// disposition are passed with the names S3 uses for them, and signed.
+func (s *LocalStorage) GetSignedURL(key string, expiryDuration time.Duration, download Download) (string, error) {
+	key = cleanKey(key)
+	if key == "" {
+		return "", errors.New("empty key")
+	}
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expiryDuration).Unix(), 10))
+	query.Set("response-content-type", download.ContentType)
+	query.Set("response-content-disposition", download.ContentDisposition())
+	query.Set("signature", s.sign(key, query))
+	return fmt.Sprintf("%s/files/%s?%s", s.baseURL, key, query.Encode()), nil
+}
+
+// Resolve verifies a signed URL's parameters and returns the path of the file.
+// The response-content-type and response-content-disposition parameters are
+// covered by the signature.
+func (s *LocalStorage) Resolve(key string, query url.Values) (string, error) {
+	key = cleanKey(key)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(key, query))) {
+		return "", ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if time.Now().Unix() > unix {
+		return "", ErrURLExpired
+	}
+	return filepath.Join(s.uploadDir, filepath.FromSlash(key)), nil
+}
+
+// cleanKey normalizes a key, keeping it inside the upload directory
+func cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+// sign returns the hex HMAC-SHA256 of a key, its expiry and how it is served
+func (s *LocalStorage) sign(key string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + query.Get("expires") + "\n" + query.Get("response-content-type") + "\n" + query.Get("response-content-disposition")))
+	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signedQuery issues a signed URL and returns its key and query
+func signedQuery(t *testing.T, s *LocalStorage, key string, expiry time.Duration) (string, url.Values) {
+	t.Helper()
+	link, err := s.GetSignedURL(key, expiry, Download{Filename: "report.pdf", ContentType: "application/pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(u.Path, "/files/"), u.Query()
+}
+
+func TestLocalStorageResolve(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "http://localhost", []byte("signing-key"))
+
+	key, query := signedQuery(t, s, "events/report.pdf", time.Minute)
+	path, err := s.Resolve(key, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "events", "report.pdf"); path != want {
+		t.Fatalf("expected %s, got %s", want, path)
+	}
+
+	for name, tamper := range map[string]func(key string, query url.Values) string{
+		"signature": func(key string, query url.Values) string {
+			query.Set("signature", strings.Repeat("0", len(query.Get("signature"))))
+			return key
+		},
+		"key": func(key string, query url.Values) string {
+			return "events/other.pdf"
+		},
+		"expiry": func(key string, query url.Values) string {
+			query.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			return key
+		},
+		"content type": func(key string, query url.Values) string {
+			query.Set("response-content-type", "text/html")
+			return key
+		},
+		"disposition": func(key string, query url.Values) string {
+			query.Set("response-content-disposition", "inline")
+			return key
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			key, query := signedQuery(t, s, "events/report.pdf", time.Minute)
+			if _, err := s.Resolve(tamper(key, query), query); err != ErrInvalidSignature {
+				t.Fatalf("expected %v, got %v", ErrInvalidSignature, err)
+			}
+		})
+	}
+
+	t.Run("other signing key", func(t *testing.T) {
+		key, query := signedQuery(t, NewLocalStorage(dir, "http://localhost", []byte("other-key")), "events/report.pdf", time.Minute)
+		if _, err := s.Resolve(key, query); err != ErrInvalidSignature {
+			t.Fatalf("expected %v, got %v", ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		key, query := signedQuery(t, s, "events/report.pdf", -time.Minute)
+		if _, err := s.Resolve(key, query); err != ErrURLExpired {
+			t.Fatalf("expected %v, got %v", ErrURLExpired, err)
+		}
+	})
+}
+
+func TestLocalStorageKeysStayInUploadDir(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "http://localhost", []byte("signing-key"))
+
+	for _, key := range []string{"../../etc/passwd", "events/../../../etc/passwd", "/etc/passwd"} {
+		link, err := s.GetSignedURL(key, time.Minute, Download{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(u.Path, "..") {
+			t.Errorf("%s: link %s leaves the files path", key, link)
+		}
+
+		// Resolving the raw key, as a crafted request would send it, stays inside too
+		path, err := s.Resolve(key, u.Query())
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if path != filepath.Join(dir, "etc", "passwd") {
+			t.Errorf("%s: resolved outside the upload directory: %s", key, path)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -9,60 +10,240 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/google/uuid"
 )
 
+// S3Config configures S3Storage. Endpoint, path-style addressing and static
+// credentials allow MinIO and other S3-compatible stores.
+type S3Config struct {
+	Region          string
+	Bucket          string
+	Endpoint        string // Custom endpoint, e.g. http://minio:9000; empty for AWS
+	UsePathStyle    bool   // Address objects as endpoint/bucket/key instead of bucket.endpoint/key
+	AccessKeyID     string // Static credentials; the default AWS chain is used when empty
+	SecretAccessKey string
+}
+
 type S3Storage struct {
-	client *s3.Client
-	bucket string
-	region string
+	client    *s3.Client
+	presigner *s3.PresignClient
+	cfg       S3Config
 }
 
-func NewS3Storage(region, bucket string) (*S3Storage, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(cfg.Region)}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		o.UsePathStyle = cfg.UsePathStyle
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			// S3-compatible stores often reject the checksums the SDK adds by default
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
 
 	return &S3Storage{
-		client: client,
-		bucket: bucket,
-		region: region,
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+		cfg:       cfg,
 	}, nil
 }
 
+func (s *S3Storage) Name() string {
+	return "s3"
+}
+
+// s3PartSize is the part size of multipart uploads, the minimum S3 accepts for
+// all parts but the last. It bounds the memory an upload holds.
+const s3PartSize = 5 << 20
+
+// Upload streams a file to S3. The reader need not be seekable: it is read in
+// s3PartSize chunks, files that fit in one chunk are sent with a single PUT and
+// larger ones with a multipart upload.
 func (s *S3Storage) Upload(file io.Reader, filename string, contentType string) (string, error) {
-	// Generate unique filename
-	ext := filepath.Ext(filename)
-	uniqueFilename := fmt.Sprintf("events/%s%s", uuid.New().String(), ext)
-
-	// Upload to S3
-	_, err := s.client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(uniqueFilename),
-		Body:        file.(io.ReadSeeker),
+	key := newObjectKey(filename)
+
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(file, buf)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		_, err = s.client.PutObject(context.TODO(), &s3.PutObjectInput{
+			Bucket:      aws.String(s.cfg.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(buf[:n]),
+			ContentType: aws.String(contentType),
+		})
+	case err == nil:
+		err = s.uploadMultipart(key, contentType, file, buf)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	return key, nil
+}
+
+// uploadMultipart uploads first, a full part, and the rest of file as a
+// multipart upload. The upload is aborted on failure so no parts are kept.
+func (s *S3Storage) uploadMultipart(key string, contentType string, file io.Reader, first []byte) error {
+	ctx := context.TODO()
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(key),
 		ContentType: aws.String(contentType),
 	})
+	if err != nil {
+		return err
+	}
 
+	parts, err := s.uploadParts(ctx, key, created.UploadId, file, first)
+	if err == nil {
+		_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.cfg.Bucket),
+			Key:             aws.String(key),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to upload to S3: %w", err)
+		s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.cfg.Bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return err
 	}
+	return nil
+}
 
-	// Return the public URL
-	fileURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, uniqueFilename)
-	return fileURL, nil
+// uploadParts sends buf and then file in parts, reusing buf for every part
+func (s *S3Storage) uploadParts(ctx context.Context, key string, uploadID *string, file io.Reader, buf []byte) ([]types.CompletedPart, error) {
+	var parts []types.CompletedPart
+	n := len(buf)
+	for number := int32(1); ; number++ {
+		out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(s.cfg.Bucket),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(number),
+			Body:       bytes.NewReader(buf[:n]),
+		})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(number)})
+
+		if n < len(buf) {
+			return parts, nil // The last part was short
+		}
+		n, err = io.ReadFull(file, buf)
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+	}
 }
 
-func (s *S3Storage) Delete(fileURL string) error {
-	// Extract key from URL and delete from S3
-	// TODO: Implement deletion
+// Open streams an object from the bucket
+func (s *S3Storage) Open(key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from S3: %w", err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	_, err := s.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete from S3: %w", err)
+	}
+	return nil
+}
+
+// List pages through the objects under the events/ prefix
+func (s *S3Storage) List(fn func(file FileInfo) error) error {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String("events/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return fmt.Errorf("failed to list S3 objects: %w", err)
+		}
+		for _, object := range page.Contents {
+			err := fn(FileInfo{
+				Key:     aws.ToString(object.Key),
+				Size:    aws.ToInt64(object.Size),
+				ModTime: aws.ToTime(object.LastModified),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (s *S3Storage) GetSignedURL(fileURL string, expiryDuration time.Duration) (string, error) {
-	// TODO: Implement signed URL generation using presigner
-	return fileURL, nil
+// GetSignedURL returns a presigned GET URL valid for expiryDuration. The type
+// and disposition are signed response overrides, so S3 serves the file with them.
+func (s *S3Storage) GetSignedURL(key string, expiryDuration time.Duration, download Download) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket:                     aws.String(s.cfg.Bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(download.ContentDisposition()),
+	}
+	if download.ContentType != "" {
+		input.ResponseContentType = aws.String(download.ContentType)
+	}
+	req, err := s.presigner.PresignGetObject(context.TODO(), input, s3.WithPresignExpires(expiryDuration))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 download: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PresignUpload returns a presigned PUT URL for uploading a new file directly to
+// the bucket, and the key the file will have once uploaded. The upload must send
+// the same Content-Type.
+func (s *S3Storage) PresignUpload(filename string, contentType string, expiryDuration time.Duration) (uploadURL string, key string, err error) {
+	key = newObjectKey(filename)
+
+	req, err := s.presigner.PresignPutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(s.cfg.Bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}, s3.WithPresignExpires(expiryDuration))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to presign S3 upload: %w", err)
+	}
+	return req.URL, key, nil
+}
+
+// newObjectKey generates a unique key keeping the file's extension
+func newObjectKey(filename string) string {
+	return fmt.Sprintf("events/%s%s", uuid.New().String(), filepath.Ext(filename))
 }
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// fakeS3 is an in-memory stand-in for a path-style S3 endpoint such as MinIO
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject  // by "bucket/key"
+	uploads map[string]*fakeUpload // multipart uploads in progress, by upload ID
+	maxKeys int                    // Page size of object listings; 0 for 1000
+}
+
+type fakeUpload struct {
+	name        string
+	contentType string
+	parts       map[int][]byte
+}
+
+type fakeObject struct {
+	body        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: make(map[string]fakeObject), uploads: make(map[string]*fakeUpload)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Every request is either signed in the header or presigned in the query
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") && r.URL.Query().Get("X-Amz-Signature") == "" {
+		http.Error(w, "missing signature", http.StatusForbidden)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.serveMultipart(w, r, name) {
+		return
+	}
+	if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.serveList(w, r, strings.TrimSuffix(name, "/"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[name] = fakeObject{body: body, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		obj, ok := f.objects[name]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		// Presigned response overrides, as S3 applies them
+		w.Header().Set("Content-Type", obj.contentType)
+		if override := r.URL.Query().Get("response-content-type"); override != "" {
+			w.Header().Set("Content-Type", override)
+		}
+		if disposition := r.URL.Query().Get("response-content-disposition"); disposition != "" {
+			w.Header().Set("Content-Disposition", disposition)
+		}
+		w.Write(obj.body)
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
+
+// serveMultipart handles the multipart upload API, reporting whether the request was one
+func (f *fakeS3) serveMultipart(w http.ResponseWriter, r *http.Request, name string) bool {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[uploadID] = &fakeUpload{name: name, contentType: r.Header.Get("Content-Type"), parts: make(map[int][]byte)}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+	case uploadID == "":
+		return false
+	case f.uploads[uploadID] == nil:
+		http.Error(w, "NoSuchUpload", http.StatusNotFound)
+	case r.Method == http.MethodPut:
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return true
+		}
+		f.uploads[uploadID].parts[number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+	case r.Method == http.MethodPost:
+		upload := f.uploads[uploadID]
+		var body []byte
+		for number := 1; number <= len(upload.parts); number++ {
+			body = append(body, upload.parts[number]...)
+		}
+		f.objects[upload.name] = fakeObject{body: body, contentType: upload.contentType}
+		delete(f.uploads, uploadID)
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"done"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete:
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+	return true
+}
+
+// serveList answers ListObjectsV2, continuing after the key in the continuation token
+func (f *fakeS3) serveList(w http.ResponseWriter, r *http.Request, bucket string) {
+	query := r.URL.Query()
+	prefix := bucket + "/" + query.Get("prefix")
+	after := query.Get("continuation-token")
+	var keys []string
+	for name := range f.objects {
+		key := strings.TrimPrefix(name, bucket+"/")
+		if strings.HasPrefix(name, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	maxKeys := f.maxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
+	}
+	truncated := len(keys) > maxKeys
+	if truncated {
+		keys = keys[:maxKeys]
+	}
+	fmt.Fprintf(w, `<ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><IsTruncated>%t</IsTruncated>`, bucket, len(keys), truncated)
+	for _, key := range keys {
+		fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>%d</Size><LastModified>2025-11-22T10:00:00.000Z</LastModified></Contents>`, key, len(f.objects[bucket+"/"+key].body))
+	}
+	if truncated {
+		fmt.Fprintf(w, `<NextContinuationToken>%s</NextContinuationToken>`, keys[len(keys)-1])
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
+
+func (f *fakeS3) pendingUploads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.uploads)
+}
+
+func (f *fakeS3) object(name string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[name]
+	return obj, ok
+}
+
+func newTestS3Storage(t *testing.T, endpoint string) *S3Storage {
+	s, err := NewS3Storage(S3Config{
+		Region:          "us-east-1",
+		Bucket:          "pawtrack",
+		Endpoint:        endpoint,
+		UsePathStyle:    true,
+		AccessKeyID:     "test",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return s
+}
+
+func TestS3StorageUploadDownloadDelete(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3Storage(t, srv.URL)
+
+	content := []byte("%PDF-1.4 vaccination certificate")
+	key, err := s.Upload(bytes.NewReader(content), "certificate.pdf", "application/pdf")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasPrefix(key, "events/") || !strings.HasSuffix(key, ".pdf") {
+		t.Fatalf("unexpected key %q", key)
+	}
+
+	name := "pawtrack/" + key
+	obj, ok := fake.object(name)
+	if !ok {
+		t.Fatalf("object %q not stored", name)
+	}
+	if !bytes.Equal(obj.body, content) || obj.contentType != "application/pdf" {
+		t.Fatalf("stored %q (%s), want %q (application/pdf)", obj.body, obj.contentType, content)
+	}
+
+	reader, err := s.Open(key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	opened, _ := io.ReadAll(reader)
+	reader.Close()
+	if !bytes.Equal(opened, content) {
+		t.Fatalf("Open read %q, want %q", opened, content)
+	}
+
+	signedURL, err := s.GetSignedURL(key, 5*time.Minute, Download{Filename: "lab report.pdf", ContentType: "application/pdf"})
+	if err != nil {
+		t.Fatalf("GetSignedURL: %v", err)
+	}
+	if !strings.Contains(signedURL, "X-Amz-Signature=") || !strings.Contains(signedURL, "X-Amz-Expires=300") {
+		t.Fatalf("url is not presigned: %s", signedURL)
+	}
+
+	resp, err := http.Get(signedURL)
+	if err != nil {
+		t.Fatalf("GET signed url: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, content) {
+		t.Fatalf("GET signed url: %d %q", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="lab report.pdf"` {
+		t.Fatalf("Content-Disposition = %q, want the file saved as an attachment", got)
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := fake.object(name); ok {
+		t.Fatalf("object %q not deleted", name)
+	}
+}
+
+func TestS3StoragePresignUpload(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3Storage(t, srv.URL)
+
+	uploadURL, key, err := s.PresignUpload("photo.png", "image/png", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+	if !strings.Contains(uploadURL, "X-Amz-Signature=") {
+		t.Fatalf("url is not presigned: %s", uploadURL)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, uploadURL, strings.NewReader("png bytes"))
+	req.Header.Set("Content-Type", "image/png")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT presigned url: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT presigned url: %d", resp.StatusCode)
+	}
+
+	obj, ok := fake.object("pawtrack/" + key)
+	if !ok || string(obj.body) != "png bytes" {
+		t.Fatalf("presigned upload not stored at %s", key)
+	}
+}
+
+func TestS3StorageList(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	fake.maxKeys = 2
+	s := newTestS3Storage(t, srv.URL)
+
+	want := make(map[string]int64)
+	for _, content := range []string{"a", "bb", "ccc"} {
+		key, err := s.Upload(strings.NewReader(content), "note.txt", "text/plain")
+		if err != nil {
+			t.Fatalf("Upload: %v", err)
+		}
+		want[key] = int64(len(content))
+	}
+	// Objects outside the prefix of uploads belong to someone else
+	fake.objects["pawtrack/backups/db.sql"] = fakeObject{body: []byte("dump")}
+
+	got := make(map[string]int64)
+	err := s.List(func(file FileInfo) error {
+		got[file.Key] = file.Size
+		if file.ModTime.IsZero() {
+			t.Errorf("%s has no modification time", file.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("listed %v, want %v", got, want)
+	}
+	for key, size := range want {
+		if got[key] != size {
+			t.Fatalf("listed %v, want %v", got, want)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = s.List(func(file FileInfo) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("List = %v after %d calls, want to stop at the first error", err, calls)
+	}
+}
+
+// onlyReader hides every method but Read, like a request body
+type onlyReader struct {
+	io.Reader
+}
+
+func TestS3StorageStreamsLargeFiles(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3Storage(t, srv.URL)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (2*s3PartSize+1024)/16)
+	key, err := s.Upload(onlyReader{bytes.NewReader(content)}, "xray.png", "image/png")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	obj, ok := fake.object("pawtrack/" + key)
+	if !ok {
+		t.Fatalf("object %q not stored", key)
+	}
+	if !bytes.Equal(obj.body, content) || obj.contentType != "image/png" {
+		t.Fatalf("stored %d bytes (%s), want %d (image/png)", len(obj.body), obj.contentType, len(content))
+	}
+	if n := fake.pendingUploads(); n != 0 {
+		t.Fatalf("%d multipart uploads left open", n)
+	}
+}
+
+func TestS3StorageAbortsFailedUpload(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3Storage(t, srv.URL)
+
+	readErr := errors.New("client went away")
+	file := io.MultiReader(bytes.NewReader(make([]byte, s3PartSize+1)), iotest.ErrReader(readErr))
+	if _, err := s.Upload(file, "scan.pdf", "application/pdf"); !errors.Is(err, readErr) {
+		t.Fatalf("Upload error = %v, want %v", err, readErr)
+	}
+	if n := fake.pendingUploads(); n != 0 {
+		t.Fatalf("%d multipart uploads left open", n)
+	}
+}
@@ -2,17 +2,61 @@ package storage
 
 import (
 	"io"
+	"mime"
 	"time"
 )
 
-// FileStorage defines the interface for file storage operations
+// FileStorage defines the interface for file storage operations. Files are
+// addressed by opaque keys, URLs are only issued on demand.
 type FileStorage interface {
-	// Upload uploads a file and returns the URL
+	// Name identifies the backend, recorded with attachments
+	Name() string
+
+	// Upload uploads a file and returns its key
 	Upload(file io.Reader, filename string, contentType string) (string, error)
 
+	// Open reads a stored file
+	Open(key string) (io.ReadCloser, error)
+
 	// Delete removes a file from storage
-	Delete(fileURL string) error
+	Delete(key string) error
+
+	// GetSignedURL generates a temporary signed URL for private files. The
+	// file is served with the type and disposition of download.
+	GetSignedURL(key string, expiryDuration time.Duration, download Download) (string, error)
+
+	// List calls fn for every file stored by Upload, stopping at the first error.
+	// Other files sharing the directory or bucket are not listed.
+	List(fn func(file FileInfo) error) error
+}
+
+// FileInfo describes a stored file
+type FileInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Download tells browsers how to treat a file opened by a signed URL. Files are
+// saved rather than shown unless Inline is set, so that a document or SVG
+// cannot run scripts when opened.
+type Download struct {
+	Filename    string // Name to save the file as; empty to leave it to the browser
+	ContentType string
+	Inline      bool
+}
 
-	// GetSignedURL generates a temporary signed URL for private files
-	GetSignedURL(fileURL string, expiryDuration time.Duration) (string, error)
+// ContentDisposition returns the Content-Disposition header of the download
+func (d Download) ContentDisposition() string {
+	disposition := "attachment"
+	if d.Inline {
+		disposition = "inline"
+	}
+	if d.Filename == "" {
+		return disposition
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": d.Filename}); header != "" {
+		return header
+	}
+	return disposition
 }
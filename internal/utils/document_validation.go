@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// ErrInvalidDocument is returned for documents that are corrupt or only look like their type
+var ErrInvalidDocument = errors.New("document is corrupt or not of its type")
+
+// Limits of a DOCX archive, so that a small upload cannot expand into gigabytes
+const (
+	maxDocxEntries      = 10_000
+	maxDocxUncompressed = 500 * 1024 * 1024
+)
+
+// IsDocument reports whether files of a type are checked by ValidateDocument.
+// Such files are read whole, so they are limited to MaxFileSize.
+func IsDocument(contentType string) bool {
+	switch contentType {
+	case "application/pdf", "application/msword",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return true
+	}
+	return false
+}
+
+// ValidateDocument checks the structure of a PDF, DOC or DOCX file, beyond the
+// first bytes that type detection looks at
+func ValidateDocument(contentType string, data []byte) error {
+	var err error
+	switch contentType {
+	case "application/pdf":
+		err = validatePDF(data)
+	case "application/msword":
+		err = validateDOC(data)
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		err = validateDOCX(data)
+	}
+	if err != nil {
+		return ErrInvalidDocument
+	}
+	return nil
+}
+
+var (
+	pdfHeader    = regexp.MustCompile(`^%PDF-[12]\.\d`)
+	pdfStartXref = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	pdfXrefAt    = regexp.MustCompile(`^(xref|\d+\s+\d+\s+obj)\b`)
+)
+
+// validatePDF checks the header, and that the trailer points at a cross-reference
+// table or stream: readers locate every object through it
+func validatePDF(data []byte) error {
+	if !pdfHeader.Match(data) {
+		return errors.New("no PDF header")
+	}
+	// Readers look for the end of file marker in the last 1024 bytes
+	tail := data[max(0, len(data)-1024):]
+	end := bytes.LastIndex(tail, []byte("%%EOF"))
+	if end < 0 {
+		return errors.New("no end of file marker")
+	}
+	match := pdfStartXref.FindSubmatch(tail[:end+len("%%EOF")])
+	if match == nil {
+		return errors.New("no startxref")
+	}
+	offset, err := strconv.Atoi(string(match[1]))
+	if err != nil || offset >= len(data) {
+		return errors.New("startxref out of range")
+	}
+	if !pdfXrefAt.Match(data[offset:]) {
+		return errors.New("startxref does not point at a cross-reference")
+	}
+	return nil
+}
+
+// validateDOC checks the signature of an OLE compound file, the container of .doc
+func validateDOC(data []byte) error {
+	if len(data) < 512 || !bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")) {
+		return errors.New("not a compound file")
+	}
+	return nil
+}
+
+// validateDOCX checks that a DOCX is a zip archive of a Word document without
+// macros, which only .docm files may carry
+func validateDOCX(data []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if len(archive.File) > maxDocxEntries {
+		return errors.New("too many entries")
+	}
+
+	var total uint64
+	var contentTypes *zip.File
+	hasDocument := false
+	for _, file := range archive.File {
+		total += file.UncompressedSize64
+		switch file.Name {
+		case "[Content_Types].xml":
+			contentTypes = file
+		case "word/document.xml":
+			hasDocument = true
+		case "word/vbaProject.bin":
+			return errors.New("document contains macros")
+		}
+	}
+	if total > maxDocxUncompressed {
+		return errors.New("archive expands too much")
+	}
+	if contentTypes == nil || !hasDocument {
+		return errors.New("not a Word document")
+	}
+
+	r, err := contentTypes.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	types, err := io.ReadAll(io.LimitReader(r, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(types, []byte("wordprocessingml.document.main+xml")) {
+		return errors.New("not a Word document")
+	}
+	return nil
+}
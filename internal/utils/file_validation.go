@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
@@ -24,6 +25,10 @@ var AllowedMimeTypes = map[string]bool{
 	"application/msword": true,
 	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
 	"text/plain": true,
+	// Videos, usually sent as resumable uploads
+	"video/mp4":       true,
+	"video/quicktime": true,
+	"video/webm":      true,
 }
 
 // Allowed file extensions (as fallback)
@@ -38,27 +43,46 @@ var AllowedExtensions = map[string]bool{
 	".doc":  true,
 	".docx": true,
 	".txt":  true,
+	".mp4":  true,
+	".mov":  true,
+	".webm": true,
 }
 
+// Types that browsers may show inline when a file is opened. Everything else,
+// including documents and SVG, is downloaded as an attachment.
+var InlineMimeTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"video/mp4":       true,
+	"video/quicktime": true,
+	"video/webm":      true,
+}
+
+// SniffLen is the number of leading bytes ValidateFileHead needs to detect the type
+const SniffLen = 512
+
+// Errors returned by file validation
+var (
+	ErrFileTooLarge   = errors.New("file size exceeds 25MB limit")
+	ErrFileEmpty      = errors.New("file is empty")
+	ErrFileNotAllowed = errors.New("file type not allowed")
+)
+
 // ValidateFile checks if the uploaded file meets size and format requirements
 func ValidateFile(file multipart.File, header *multipart.FileHeader) error {
 	// Check file size
 	if header.Size > MaxFileSize {
-		return errors.New("file size exceeds 25MB limit")
+		return ErrFileTooLarge
 	}
 
 	if header.Size == 0 {
-		return errors.New("file is empty")
-	}
-
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if !AllowedExtensions[ext] {
-		return errors.New("file type not allowed")
+		return ErrFileEmpty
 	}
 
 	// Detect MIME type from content
-	buffer := make([]byte, 512)
+	buffer := make([]byte, SniffLen)
 	n, err := file.Read(buffer)
 	if err != nil {
 		return errors.New("failed to read file")
@@ -69,7 +93,37 @@ func ValidateFile(file multipart.File, header *multipart.FileHeader) error {
 		return errors.New("failed to reset file pointer")
 	}
 
-	contentType := http.DetectContentType(buffer[:n])
+	return ValidateFileHead(header.Filename, buffer[:n])
+}
+
+// ValidateFileHead checks the extension and the type detected from the first
+// SniffLen bytes of a file (fewer for short files). Streamed uploads validate
+// with it before copying, and enforce MaxFileSize while copying.
+func ValidateFileHead(filename string, head []byte) error {
+	if len(head) == 0 {
+		return ErrFileEmpty
+	}
+
+	// Check file extension
+	if !AllowedExtensions[strings.ToLower(filepath.Ext(filename))] {
+		return ErrFileNotAllowed
+	}
+
+	// Check if MIME type is allowed
+	mediaType, _, _ := mime.ParseMediaType(DetectFileType(filename, head))
+	if !AllowedMimeTypes[mediaType] {
+		return ErrFileNotAllowed
+	}
+
+	return nil
+}
+
+// DetectFileType returns the type of a file from its first bytes, or its
+// extension for types that cannot be told apart by content. Files are stored
+// and served with this type rather than the one declared by the client.
+func DetectFileType(filename string, head []byte) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	contentType := http.DetectContentType(head)
 
 	// Special handling for some types
 	if ext == ".svg" {
@@ -78,12 +132,9 @@ func ValidateFile(file multipart.File, header *multipart.FileHeader) error {
 		contentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
 	} else if ext == ".doc" {
 		contentType = "application/msword"
+	} else if ext == ".mov" && len(head) >= 12 && string(head[4:12]) == "ftypqt  " {
+		// QuickTime is not detected by http.DetectContentType
+		contentType = "video/quicktime"
 	}
-
-	// Check if MIME type is allowed
-	if !AllowedMimeTypes[contentType] {
-		return errors.New("file type not allowed")
-	}
-
-	return nil
+	return contentType
 }
@@ -2,30 +2,33 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/you/pawtrack/internal/events"
 	"github.com/you/pawtrack/internal/handler"
+	"github.com/you/pawtrack/internal/jobs"
 	"github.com/you/pawtrack/internal/middleware"
+	"github.com/you/pawtrack/internal/migrator"
 	"github.com/you/pawtrack/internal/models"
+	"github.com/you/pawtrack/internal/realtime"
 	"github.com/you/pawtrack/internal/repository"
+	"github.com/you/pawtrack/internal/scanner"
+	"github.com/you/pawtrack/internal/scheduler"
+	"github.com/you/pawtrack/internal/search"
 	"github.com/you/pawtrack/internal/service"
 	"github.com/you/pawtrack/internal/storage"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
-	// migrate
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-
 	// swagger
 	_ "github.com/you/pawtrack/docs" // docs is generated by Swag CLI
 )
@@ -40,6 +43,11 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 // @security BearerAuth          // <-- глобальное требование
 func main() {
+	// Subcommands, e.g. "pawtrack migrate status"
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// ENV with defaults
 	addr := getenv("ADDR", ":8080")
 
@@ -63,27 +71,152 @@ func main() {
 		}
 	}
 
+	// Full-text search: tsvector on Postgres, FTS5 on SQLite builds with the sqlite_fts5 tag
+	searchEngine, err := search.New(db)
+	if err != nil {
+		log.Fatalf("search: %v", err)
+	}
+	log.Printf("full-text search: %s", searchEngine.Name())
+
 	// Initialize layers
 	// Repositories
-	eventRepo := repository.NewEventRepository(db)
+	eventRepo := repository.NewEventRepository(db, searchEngine)
 	dogRepo := repository.NewDogRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	consultantRepo := repository.NewConsultantRepository(db)
-	consultantNoteRepo := repository.NewConsultantNoteRepository(db)
+	consultantNoteRepo := repository.NewConsultantNoteRepository(db, searchEngine)
 	eventCommentRepo := repository.NewEventCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
 	permissionRepo := repository.NewPermissionRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	timelineRepo := repository.NewTimelineRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	syncRepo := repository.NewSyncRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	scheduledTaskRepo := repository.NewScheduledTaskRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	storedObjectRepo := repository.NewStoredObjectRepository(db)
 
 	// Initialize permission middleware
 	middleware.InitPermissionMiddleware(permissionRepo)
 
+	// Storage; files are reference counted, so identical uploads share one file
+	backend, err := newFileStorage()
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	var fileHandler *handler.FileHandler
+	if localStorage, ok := backend.(*storage.LocalStorage); ok {
+		fileHandler = handler.NewFileHandler(localStorage)
+	}
+	fileStorage := service.NewObjectStore(storedObjectRepo, backend, objectStoreOptions())
+
+	// Malware scanning of attachments; without a scanner files are clean once stored
+	scanTimeout := time.Duration(getenvInt("SCAN_TIMEOUT_SECONDS", 60)) * time.Second
+	var fileScanner scanner.Scanner = scanner.Noop{}
+	switch scannerType := getenv("SCANNER", "none"); scannerType {
+	case "none":
+	case "clamd":
+		clamd, err := scanner.NewClamd(getenv("CLAMD_ADDRESS", "tcp://localhost:3310"), scanTimeout)
+		if err != nil {
+			log.Fatalf("failed to initialize clamd scanner: %v", err)
+		}
+		// Uploads stay quarantined until clamd is back, so an outage is not fatal
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := clamd.Ping(pingCtx); err != nil {
+			log.Printf("clamd is unreachable: %v", err)
+		}
+		cancelPing()
+		fileScanner = clamd
+	default:
+		log.Fatalf("unknown SCANNER %q", scannerType)
+	}
+	log.Printf("malware scanner: %s", fileScanner.Name())
+
 	// Services
 	authService := service.NewAuthService(userRepo, permissionRepo)
-	eventService := service.NewEventService(eventRepo)
+	// Domain events published by the services below; side effects subscribe to the bus
+	bus := events.NewBus()
+	var publisher events.Publisher = bus
+	var outbox *events.Outbox
+	if getenv("EVENTS_OUTBOX", "false") == "true" {
+		outbox = events.NewOutbox(outboxRepo, bus, time.Second)
+		publisher = outbox
+	}
+
+	hub := realtime.NewHub(getenvInt("REALTIME_BUFFER_SIZE", 256))
+	bus.Subscribe(hub.HandleEvent)
+
+	// Background jobs; services register their job types before the workers start
+	queue := jobs.NewQueue(jobRepo, jobs.Options{
+		Workers:      getenvInt("JOB_WORKERS", 4),
+		PollInterval: time.Duration(getenvInt("JOB_POLL_SECONDS", 2)) * time.Second,
+		DrainTimeout: time.Duration(getenvInt("JOB_DRAIN_SECONDS", 30)) * time.Second,
+	})
+
+	webhookService := service.NewWebhookService(webhookRepo, queue, service.WebhookOptions{
+		MaxAttempts: getenvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BaseBackoff: time.Duration(getenvInt("WEBHOOK_BACKOFF_SECONDS", 30)) * time.Second,
+		Timeout:     time.Duration(getenvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+
+		AllowPrivateNetworks: getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+	})
+	bus.Subscribe(webhookService.HandleEvent)
+
+	eventService := service.NewEventService(eventRepo, dogRepo, publisher)
 	dogService := service.NewDogService(dogRepo)
 	userService := service.NewUserService(userRepo, permissionRepo)
-	consultantService := service.NewConsultantService(consultantRepo, dogRepo, permissionRepo)
-	consultantNoteService := service.NewConsultantNoteService(consultantNoteRepo, dogRepo)
-	eventCommentService := service.NewEventCommentService(eventCommentRepo, eventRepo, dogRepo)
+	consultantService := service.NewConsultantService(consultantRepo, dogRepo, permissionRepo, publisher)
+	consultantNoteService := service.NewConsultantNoteService(consultantNoteRepo, dogRepo, publisher)
+	eventCommentService := service.NewEventCommentService(eventCommentRepo, eventRepo, dogRepo, publisher)
+	attachmentService := service.NewAttachmentService(attachmentRepo, eventRepo, eventCommentRepo, consultantNoteRepo, dogRepo, permissionRepo)
+	imageService := service.NewImageService(fileStorage, getenvInt("IMAGE_WORKERS", 2))
+	uploadService := service.NewUploadService(uploadRepo, fileStorage, imageService, service.UploadOptions{
+		MaxSize: int64(getenvInt("TUS_MAX_SIZE_MB", 1024)) << 20,
+		Expiry:  time.Duration(getenvInt("TUS_UPLOAD_TTL_HOURS", 24)) * time.Hour,
+	})
+	scanService := service.NewScanService(attachmentRepo, fileStorage, fileScanner, queue, service.ScanOptions{
+		InlineMaxSize: int64(getenvInt("SCAN_INLINE_MAX_MB", 25)) << 20,
+		InlineBudget:  time.Duration(getenvInt("SCAN_INLINE_BUDGET_SECONDS", 10)) * time.Second,
+		Timeout:       scanTimeout,
+		MaxAttempts:   getenvInt("SCAN_MAX_ATTEMPTS", 10),
+		Backoff:       time.Duration(getenvInt("SCAN_BACKOFF_SECONDS", 30)) * time.Second,
+	})
+	statsService := service.NewStatsService(statsRepo, dogRepo)
+	timelineService := service.NewTimelineService(timelineRepo, dogRepo)
+	idempotencyTTL := time.Duration(getenvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour
+	idempotencyLease := time.Duration(getenvInt("IDEMPOTENCY_LEASE_SECONDS", 300)) * time.Second
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, idempotencyTTL, idempotencyLease)
+	streamService := service.NewStreamService(hub, dogRepo)
+	syncService := service.NewSyncService(syncRepo, dogRepo, eventService, eventCommentService, consultantNoteService)
+	jobService := service.NewJobService(jobRepo, queue)
+	maintenanceService := service.NewMaintenanceService(consultantRepo, idempotencyRepo, jobRepo, statsRepo, publisher, service.MaintenanceOptions{
+		JobRetention: time.Duration(getenvInt("JOB_RETENTION_DAYS", 7)) * 24 * time.Hour,
+	})
+
+	// Maintenance tasks; a schedule set to "off" disables the task
+	sched := scheduler.New(scheduledTaskRepo)
+	for _, task := range []struct {
+		name, env, spec string
+		run             scheduler.Task
+	}{
+		{"invites.expire", "SCHEDULE_EXPIRE_INVITES", "*/5 * * * *", maintenanceService.ExpireInvites},
+		{"records.prune", "SCHEDULE_PRUNE", "0 3 * * *", maintenanceService.PruneRecords},
+		{"digest.daily", "SCHEDULE_DAILY_DIGEST", "0 6 * * *", maintenanceService.SendDailyDigests},
+		{"uploads.expire", "SCHEDULE_EXPIRE_UPLOADS", "*/15 * * * *", uploadService.PurgeExpired},
+		{"storage.sweep", "SCHEDULE_STORAGE_SWEEP", "*/15 * * * *", fileStorage.Sweep},
+		{"attachments.requeue", "SCHEDULE_REQUEUE_SCANS", "*/15 * * * *", scanService.RequeueUnscanned},
+	} {
+		spec := getenv(task.env, task.spec)
+		if spec == "off" {
+			continue
+		}
+		if err := sched.Register(task.name, spec, 10*time.Minute, task.run); err != nil {
+			log.Fatalf("invalid %s: %v", task.env, err)
+		}
+	}
 
 	// Migrate existing users to atomic permissions (run once)
 	if err := service.MigrateExistingUsers(userRepo, permissionRepo); err != nil {
@@ -91,37 +224,26 @@ func main() {
 	}
 
 
-	// Storage
-	var fileStorage storage.FileStorage
-	storageType := getenv("STORAGE_TYPE", "local")
-	if storageType == "s3" {
-		s3Storage, err := storage.NewS3Storage(
-			getenv("AWS_REGION", "us-east-1"),
-			getenv("AWS_S3_BUCKET", ""),
-		)
-		if err != nil {
-			log.Fatalf("failed to initialize S3 storage: %v", err)
-		}
-		fileStorage = s3Storage
-	} else {
-		fileStorage = storage.NewLocalStorage(
-			getenv("UPLOAD_DIR", "./uploads"),
-			getenv("BASE_URL", "http://localhost:8080"),
-		)
-	}
-
 	// Handlers
 	authHandler := handler.NewAuthHandler(authService)
-	eventHandler := handler.NewEventHandler(eventService, fileStorage)
+	eventHandler := handler.NewEventHandler(eventService, fileStorage, imageService, scanService)
 	dogHandler := handler.NewDogHandler(dogService)
 	userHandler := handler.NewUserHandler(userService)
-	healthHandler := handler.NewHealthHandler(db)
+	healthHandler := handler.NewHealthHandler(db, dbType())
 	consultantHandler := handler.NewConsultantHandler(consultantService)
 	consultantNoteHandler := handler.NewConsultantNoteHandler(consultantNoteService)
-	eventCommentHandler := handler.NewEventCommentHandler(eventCommentService, fileStorage)
+	eventCommentHandler := handler.NewEventCommentHandler(eventCommentService, fileStorage, imageService, scanService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	timelineHandler := handler.NewTimelineHandler(timelineService)
+	syncHandler := handler.NewSyncHandler(syncService)
+	streamHandler := handler.NewStreamHandler(streamService, time.Duration(getenvInt("STREAM_ACCESS_CHECK_SECONDS", 60))*time.Second)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	jobHandler := handler.NewJobHandler(jobService)
+	tusHandler := handler.NewTusHandler(uploadService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService, uploadService, imageService, scanService, fileStorage, time.Duration(getenvInt("ATTACHMENT_URL_TTL_SECONDS", 300))*time.Second)
 
 	// Router
-	r := handler.SetupRouter(eventHandler, dogHandler, userHandler, authHandler, healthHandler, consultantHandler, consultantNoteHandler, eventCommentHandler, authService)
+	r := handler.SetupRouter(eventHandler, dogHandler, userHandler, authHandler, healthHandler, consultantHandler, consultantNoteHandler, eventCommentHandler, statsHandler, timelineHandler, syncHandler, streamHandler, webhookHandler, jobHandler, attachmentHandler, tusHandler, fileHandler, authService, idempotencyService)
 
 	srv := &http.Server{Addr: addr, Handler: r}
 
@@ -133,15 +255,42 @@ func main() {
 	}()
 	log.Printf("pawtrack listening on %s", addr)
 
+	// Background workers: job queue, scheduler and outbox relay
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		queue.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		sched.Run(workersCtx)
+	}()
+	if outbox != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			outbox.Run(workersCtx)
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// Close streams first, Shutdown waits for open handlers
+	hub.Close()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("server shutdown: %v", err)
 	}
+
+	// Let work in flight finish (jobs get JOB_DRAIN_SECONDS); the rest stays queued in the database
+	stopWorkers()
+	workers.Wait()
 	log.Printf("bye")
 }
 
@@ -152,39 +301,59 @@ func getenv(key, def string) string {
 	return def
 }
 
-// runMigrations applies migrations from MIGRATIONS_DIR (default ./migrations)
+func getenvInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+		log.Printf("invalid %s=%q, using %d", key, v, def)
+	}
+	return def
+}
+
+// runMigrations applies the pending embedded migrations
 func runMigrations() error {
-	dir := getenv("MIGRATIONS_DIR", "./migrations")
-	sourceURL := fmt.Sprintf("file://%s", dir)
-	dbType := getenv("DB_TYPE", "sqlite")
+	mg, err := newMigrator()
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+	return mg.Up()
+}
 
+// newMigrator opens a migrator for the configured database
+func newMigrator() (*migrator.Migrator, error) {
 	var dbURL string
-	if dbType == "postgres" {
-		dbURL = getenv("DATABASE_URL", "")
-		if dbURL == "" {
-			host := getenv("PGHOST", "localhost")
-			port := getenv("PGPORT", "5432")
-			user := getenv("PGUSER", "pawtrack")
-			pass := getenv("PGPASSWORD", "pawtrack")
-			dbname := getenv("PGDATABASE", "pawtrack")
-			dbURL = "postgres://" + user + ":" + pass + "@" + host + ":" + port + "/" + dbname + "?sslmode=disable"
-		}
+	if dbType() == "postgres" {
+		dbURL = postgresURL()
 	} else {
 		// sqlite
 		dsn := getenv("SQLITE_DSN", "file:pawtrack.db?_busy_timeout=5000&_fk=1")
-		// migrate expects sqlite:// or sqlite3:// scheme
-		dbURL = "sqlite3://" + dsn
+		// migrate expects a sqlite3:// URL with the file path after the scheme
+		dbURL = "sqlite3://" + strings.TrimPrefix(dsn, "file:")
 	}
+	return migrator.New(dbType(), dbURL)
+}
 
-	m, err := migrate.New(sourceURL, dbURL)
-	if err != nil {
-		return err
+// postgresURL returns DATABASE_URL or builds it from the PG* variables
+func postgresURL() string {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return url
 	}
-	defer m.Close()
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return err
+	host := getenv("PGHOST", "localhost")
+	port := getenv("PGPORT", "5432")
+	user := getenv("PGUSER", "pawtrack")
+	pass := getenv("PGPASSWORD", "pawtrack")
+	dbname := getenv("PGDATABASE", "pawtrack")
+	return "postgres://" + user + ":" + pass + "@" + host + ":" + port + "/" + dbname + "?sslmode=disable"
+}
+
+// dbType returns the configured database type, "postgres" or "sqlite"
+func dbType() string {
+	if getenv("DB_TYPE", "sqlite") == "postgres" {
+		return "postgres"
 	}
-	return nil
+	return "sqlite"
 }
 
 // seedIfEmpty adds some records if the table is empty
@@ -205,22 +374,59 @@ func seedIfEmpty(db *gorm.DB) error {
 	return db.Create(&data).Error
 }
 
-func openDB() (*gorm.DB, error) {
-	dbType := getenv("DB_TYPE", "sqlite")
-	if dbType == "postgres" {
-		url := os.Getenv("DATABASE_URL")
-		if url == "" {
-			// fallback: build DSN from environment variables
-			host := getenv("PGHOST", "localhost")
-			port := getenv("PGPORT", "5432")
-			user := getenv("PGUSER", "pawtrack")
-			pass := getenv("PGPASSWORD", "pawtrack")
-			dbname := getenv("PGDATABASE", "pawtrack")
-			url = "postgres://" + user + ":" + pass + "@" + host + ":" + port + "/" + dbname + "?sslmode=disable"
+// gormConfig keeps created_at/updated_at in UTC: sync compares them with
+// checkpoints, and SQLite compares timestamps as strings
+func gormConfig() *gorm.Config {
+	return &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// newFileStorage creates the storage backend selected by STORAGE_TYPE
+func newFileStorage() (storage.FileStorage, error) {
+	if getenv("STORAGE_TYPE", "local") == "s3" {
+		s3Storage, err := storage.NewS3Storage(storage.S3Config{
+			Region:          getenv("AWS_REGION", "us-east-1"),
+			Bucket:          getenv("AWS_S3_BUCKET", ""),
+			Endpoint:        getenv("AWS_S3_ENDPOINT", ""),
+			UsePathStyle:    getenv("AWS_S3_USE_PATH_STYLE", "false") == "true",
+			AccessKeyID:     getenv("AWS_S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getenv("AWS_S3_SECRET_ACCESS_KEY", ""),
+		})
+		if err != nil {
+			return nil, err
 		}
-		return gorm.Open(postgres.Open(url), &gorm.Config{})
+		return s3Storage, nil
+	}
+
+	// Anyone knowing the key can forge download links, so there is no default,
+	// and the JWT secret is not reused for it
+	signingKey := os.Getenv("STORAGE_SIGNING_KEY")
+	if signingKey == "" {
+		return nil, errors.New("STORAGE_SIGNING_KEY is required for local storage")
+	}
+	if signingKey == os.Getenv("JWT_SECRET") {
+		return nil, errors.New("STORAGE_SIGNING_KEY must differ from JWT_SECRET")
+	}
+	return storage.NewLocalStorage(
+		getenv("UPLOAD_DIR", "./uploads"),
+		getenv("BASE_URL", "http://localhost:8080"),
+		[]byte(signingKey),
+	), nil
+}
+
+// objectStoreOptions configures the reference counting of stored files
+func objectStoreOptions() service.ObjectStoreOptions {
+	return service.ObjectStoreOptions{
+		Grace: time.Duration(getenvInt("STORAGE_GC_GRACE_MINUTES", 60)) * time.Minute,
+	}
+}
+
+func openDB() (*gorm.DB, error) {
+	if dbType() == "postgres" {
+		return gorm.Open(postgres.Open(postgresURL()), gormConfig())
 	}
 	// default sqlite
 	dsn := getenv("SQLITE_DSN", "file:pawtrack.db?_busy_timeout=5000&_fk=1")
-	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	return gorm.Open(sqlite.Open(dsn), gormConfig())
 }
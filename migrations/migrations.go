@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations into the binary, one directory per
+// database type. Both directories have the same versions.
+package migrations
+
+import "embed"
+
+// FS holds postgres/*.sql and sqlite/*.sql
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
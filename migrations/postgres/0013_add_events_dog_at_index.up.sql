@@ -0,0 +1,2 @@
+-- Composite index for per-dog timelines filtered and sorted by occurrence time
+CREATE INDEX IF NOT EXISTS idx_events_dog_id_at ON events(dog_id, at);
@@ -0,0 +1,2 @@
+ALTER TABLE events DROP COLUMN amount_grams;
+ALTER TABLE events DROP COLUMN duration_minutes;
@@ -0,0 +1,30 @@
+-- Client-generated IDs for records created offline
+ALTER TABLE events ADD COLUMN client_id VARCHAR(36);
+ALTER TABLE event_comments ADD COLUMN client_id VARCHAR(36);
+ALTER TABLE consultant_notes ADD COLUMN client_id VARCHAR(36);
+
+CREATE UNIQUE INDEX idx_events_client_id ON events(client_id);
+CREATE UNIQUE INDEX idx_event_comments_client_id ON event_comments(client_id);
+CREATE UNIQUE INDEX idx_consultant_notes_client_id ON consultant_notes(client_id);
+
+-- Delta sync reads changes by updated_at
+CREATE INDEX idx_dogs_updated_at ON dogs(updated_at);
+CREATE INDEX idx_events_updated_at ON events(updated_at);
+CREATE INDEX idx_event_comments_updated_at ON event_comments(updated_at);
+-- Already created by 0008
+CREATE INDEX IF NOT EXISTS idx_consultant_notes_updated_at ON consultant_notes(updated_at);
+
+-- Deleted records for syncing clients
+CREATE TABLE tombstones (
+    id SERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    client_id VARCHAR(36),
+    dog_id INTEGER,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_tombstones_dog_id ON tombstones(dog_id);
+CREATE INDEX idx_tombstones_user_id ON tombstones(user_id);
+CREATE INDEX idx_tombstones_deleted_at ON tombstones(deleted_at);
@@ -0,0 +1,10 @@
+-- Domain events awaiting dispatch (EVENTS_OUTBOX=true)
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    -- Events are claimed by one relay at a time, see OutboxRepository.Claim
+    locked_by VARCHAR(100),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
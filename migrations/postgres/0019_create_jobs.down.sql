@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name = 'JOBS_MANAGE';
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,23 @@
+-- Background job queue
+CREATE TABLE jobs (
+    id SERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_by VARCHAR(100),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_type ON jobs(type);
+CREATE INDEX idx_jobs_status_run_at ON jobs(status, run_at);
+
+INSERT INTO permissions (name, description) VALUES
+('JOBS_MANAGE', 'Inspect and retry background jobs')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_invites_status_expires_at;
+DROP TABLE IF EXISTS scheduled_tasks;
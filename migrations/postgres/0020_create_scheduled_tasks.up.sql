@@ -0,0 +1,13 @@
+-- Scheduled maintenance tasks: one row per task, used as a lock so that only
+-- one replica runs each scheduled run
+CREATE TABLE scheduled_tasks (
+    name VARCHAR(100) PRIMARY KEY,
+    locked_by VARCHAR(100),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_finished_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT
+);
+
+-- Expiring pending invites
+CREATE INDEX idx_invites_status_expires_at ON invites(status, expires_at);
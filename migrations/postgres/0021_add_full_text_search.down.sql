@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_consultant_notes_search_vector;
+ALTER TABLE consultant_notes DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_events_search_vector;
+ALTER TABLE events DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,13 @@
+-- Full-text search. The "russian" configuration stems Russian words and English
+-- (ASCII) ones. Generated columns keep the vectors in sync on write.
+ALTER TABLE events ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', COALESCE(note, ''))) STORED;
+CREATE INDEX idx_events_search_vector ON events USING GIN (search_vector);
+
+-- Title matches rank above content matches
+ALTER TABLE consultant_notes ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('russian', COALESCE(content, '')), 'B')
+    ) STORED;
+CREATE INDEX idx_consultant_notes_search_vector ON consultant_notes USING GIN (search_vector);
@@ -0,0 +1,27 @@
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES event_comments(id) ON DELETE CASCADE,
+    location VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_event_id ON attachments(event_id);
+CREATE INDEX idx_attachments_comment_id ON attachments(comment_id);
+
+-- Existing uploads become attachments, served through the access-checked route
+INSERT INTO attachments (event_id, location, created_at)
+SELECT id, attachment_url, created_at FROM events
+WHERE attachment_url IS NOT NULL AND attachment_url <> '';
+
+INSERT INTO attachments (comment_id, location, created_at)
+SELECT id, attachment_url, created_at FROM event_comments
+WHERE attachment_url IS NOT NULL AND attachment_url <> '';
+
+UPDATE events SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.event_id = events.id
+) WHERE id IN (SELECT event_id FROM attachments WHERE event_id IS NOT NULL);
+
+UPDATE event_comments SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.comment_id = event_comments.id
+) WHERE id IN (SELECT comment_id FROM attachments WHERE comment_id IS NOT NULL);
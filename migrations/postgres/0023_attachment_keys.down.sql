@@ -0,0 +1,22 @@
+ALTER TABLE events ADD COLUMN attachment_url VARCHAR(500);
+ALTER TABLE event_comments ADD COLUMN attachment_url VARCHAR(500);
+
+UPDATE events SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.event_id = events.id
+) WHERE id IN (SELECT event_id FROM attachments WHERE event_id IS NOT NULL);
+
+UPDATE event_comments SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.comment_id = event_comments.id
+) WHERE id IN (SELECT comment_id FROM attachments WHERE comment_id IS NOT NULL);
+
+-- The base URL is not known here, keys are kept as relative locations
+ALTER TABLE attachments ADD COLUMN location VARCHAR(500);
+UPDATE attachments SET location = CASE WHEN backend = 'local' THEN '/files/' || key ELSE '/' || key END;
+ALTER TABLE attachments ALTER COLUMN location SET NOT NULL;
+
+ALTER TABLE attachments DROP COLUMN sha256;
+ALTER TABLE attachments DROP COLUMN size;
+ALTER TABLE attachments DROP COLUMN content_type;
+ALTER TABLE attachments DROP COLUMN filename;
+ALTER TABLE attachments DROP COLUMN backend;
+ALTER TABLE attachments DROP COLUMN key;
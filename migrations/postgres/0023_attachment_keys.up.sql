@@ -0,0 +1,25 @@
+-- Attachments keep the storage key and metadata instead of absolute URLs
+ALTER TABLE attachments ADD COLUMN key VARCHAR(500);
+ALTER TABLE attachments ADD COLUMN backend VARCHAR(20);
+ALTER TABLE attachments ADD COLUMN filename VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE attachments ADD COLUMN content_type VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE attachments ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE attachments ADD COLUMN sha256 VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Both backends stored uploads under events/, local URLs went through /uploads or /files.
+-- Size, SHA-256 and content type of existing files are unknown.
+UPDATE attachments SET
+    key = substr(location, strpos(location, '/events/') + 1),
+    backend = CASE
+        WHEN location LIKE '%/uploads/events/%' OR location LIKE '%/files/events/%' THEN 'local'
+        ELSE 's3'
+    END;
+UPDATE attachments SET filename = substr(key, length('events/') + 1) WHERE key LIKE 'events/%';
+
+ALTER TABLE attachments ALTER COLUMN key SET NOT NULL;
+ALTER TABLE attachments ALTER COLUMN backend SET NOT NULL;
+ALTER TABLE attachments DROP COLUMN location;
+
+-- Download paths are resolved from attachments when loading
+ALTER TABLE events DROP COLUMN attachment_url;
+ALTER TABLE event_comments DROP COLUMN attachment_url;
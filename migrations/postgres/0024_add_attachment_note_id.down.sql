@@ -0,0 +1,4 @@
+-- Note attachments have no other owner
+DELETE FROM attachments WHERE note_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_attachments_note_id;
+ALTER TABLE attachments DROP COLUMN note_id;
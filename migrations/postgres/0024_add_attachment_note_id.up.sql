@@ -0,0 +1,3 @@
+-- Consultant notes can carry attachments too
+ALTER TABLE attachments ADD COLUMN note_id INTEGER REFERENCES consultant_notes(id) ON DELETE CASCADE;
+CREATE INDEX idx_attachments_note_id ON attachments(note_id);
@@ -0,0 +1,2 @@
+ALTER TABLE uploads DROP COLUMN size;
+DROP TABLE IF EXISTS thumbnails;
@@ -0,0 +1,18 @@
+-- Thumbnails of image attachments, and of completed uploads until they are attached
+CREATE TABLE thumbnails (
+    id SERIAL PRIMARY KEY,
+    attachment_id INTEGER REFERENCES attachments(id) ON DELETE CASCADE,
+    upload_id VARCHAR(36) REFERENCES uploads(id) ON DELETE CASCADE,
+    size VARCHAR(20) NOT NULL,
+    key VARCHAR(500) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL
+);
+
+CREATE INDEX idx_thumbnails_attachment_id ON thumbnails(attachment_id);
+CREATE INDEX idx_thumbnails_upload_id ON thumbnails(upload_id);
+
+-- Size of the stored file of a completed upload; re-encoded images differ from upload_length
+ALTER TABLE uploads ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
+UPDATE uploads SET size = upload_length WHERE completed_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE attachments DROP COLUMN scanned_at;
+ALTER TABLE attachments DROP COLUMN scan_threat;
+ALTER TABLE attachments DROP COLUMN scan_status;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS attachments_refs ON attachments;
+DROP TRIGGER IF EXISTS thumbnails_refs ON thumbnails;
+DROP TRIGGER IF EXISTS uploads_refs ON uploads;
+DROP TRIGGER IF EXISTS upload_chunks_refs ON upload_chunks;
+DROP FUNCTION IF EXISTS stored_object_refs();
+DROP TABLE IF EXISTS stored_objects;
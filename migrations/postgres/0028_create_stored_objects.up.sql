@@ -0,0 +1,57 @@
+-- Files in the storage backends with the number of rows referring to them.
+-- Uploads of identical content share one file; it is deleted once the count
+-- drops to zero. Triggers keep the count, so rows removed by cascades count too.
+CREATE TABLE stored_objects (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(500) NOT NULL,
+    backend VARCHAR(20) NOT NULL,
+    sha256 VARCHAR(64),
+    size BIGINT NOT NULL DEFAULT 0,
+    ref_count INTEGER NOT NULL DEFAULT 0,
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_stored_objects_key ON stored_objects(key);
+CREATE INDEX idx_stored_objects_content ON stored_objects(backend, sha256, size);
+CREATE INDEX idx_stored_objects_ref_count ON stored_objects(backend, ref_count);
+
+-- Files stored before the table existed; legacy attachments have no hash
+INSERT INTO stored_objects (key, backend, sha256, size, ref_count)
+SELECT key, MIN(backend), MAX(sha256), MAX(size), COUNT(*)
+FROM (
+    SELECT key, backend, NULLIF(sha256, '') AS sha256, size FROM attachments
+    UNION ALL
+    SELECT t.key, COALESCE(a.backend, u.backend, ''), NULL, 0
+    FROM thumbnails t
+    LEFT JOIN attachments a ON a.id = t.attachment_id
+    LEFT JOIN uploads u ON u.id = t.upload_id
+    UNION ALL
+    SELECT key, backend, NULLIF(sha256, ''), size FROM uploads WHERE key IS NOT NULL AND key <> ''
+    UNION ALL
+    SELECT c.key, u.backend, NULL, c.size FROM upload_chunks c JOIN uploads u ON u.id = c.upload_id
+) refs
+GROUP BY key;
+
+-- Counts the key of a row inserted, deleted or re-keyed in a referring table.
+-- The key of an upload is empty until it is complete.
+CREATE FUNCTION stored_object_refs() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_refs AFTER INSERT OR DELETE OR UPDATE OF key ON attachments
+    FOR EACH ROW EXECUTE FUNCTION stored_object_refs();
+CREATE TRIGGER thumbnails_refs AFTER INSERT OR DELETE OR UPDATE OF key ON thumbnails
+    FOR EACH ROW EXECUTE FUNCTION stored_object_refs();
+CREATE TRIGGER uploads_refs AFTER INSERT OR DELETE OR UPDATE OF key ON uploads
+    FOR EACH ROW EXECUTE FUNCTION stored_object_refs();
+CREATE TRIGGER upload_chunks_refs AFTER INSERT OR DELETE OR UPDATE OF key ON upload_chunks
+    FOR EACH ROW EXECUTE FUNCTION stored_object_refs();
@@ -0,0 +1,15 @@
+-- SQLite cannot drop a foreign key column, so the table is rebuilt
+CREATE TABLE dogs_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    breed VARCHAR(255),
+    birth_date TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO dogs_old (id, name, breed, birth_date, created_at, updated_at)
+SELECT id, name, breed, birth_date, created_at, updated_at FROM dogs;
+
+DROP TABLE dogs;
+ALTER TABLE dogs_old RENAME TO dogs;
@@ -0,0 +1,19 @@
+-- SQLite cannot add a column with a foreign key and a non-NULL default, so the
+-- table is rebuilt. Nothing references dogs yet.
+CREATE TABLE dogs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    breed VARCHAR(255),
+    birth_date TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    owner_id INTEGER NOT NULL DEFAULT 1 REFERENCES users(id)
+);
+
+INSERT INTO dogs_new (id, name, breed, birth_date, created_at, updated_at)
+SELECT id, name, breed, birth_date, created_at, updated_at FROM dogs;
+
+DROP TABLE dogs;
+ALTER TABLE dogs_new RENAME TO dogs;
+
+CREATE INDEX idx_dogs_owner_id ON dogs(owner_id);
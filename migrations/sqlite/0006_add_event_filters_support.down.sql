@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS consultant_access;
+DROP INDEX IF EXISTS idx_events_dog_id;
+
+-- SQLite cannot drop a foreign key column, so the table is rebuilt
+CREATE TABLE events_old (
+  id         INTEGER PRIMARY KEY,
+  type       TEXT NOT NULL,
+  note       TEXT,
+  at         TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL
+);
+
+INSERT INTO events_old (id, type, note, at, created_at, updated_at)
+SELECT id, type, note, at, created_at, updated_at FROM events;
+
+DROP TABLE events;
+ALTER TABLE events_old RENAME TO events;
+
+CREATE INDEX idx_events_type ON events(type);
+CREATE INDEX idx_events_at   ON events(at);
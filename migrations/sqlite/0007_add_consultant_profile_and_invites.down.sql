@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invites;
+DROP TABLE IF EXISTS consultant_profiles;
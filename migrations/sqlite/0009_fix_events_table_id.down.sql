@@ -0,0 +1,17 @@
+-- Rollback: This would require restoring data, so just drop it
+DROP TABLE IF EXISTS events;
+
+-- Recreate with the schema after 0006
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY,
+    type TEXT NOT NULL,
+    note TEXT,
+    at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NULL,
+    dog_id INTEGER REFERENCES dogs(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_type ON events(type);
+CREATE INDEX IF NOT EXISTS idx_events_at ON events(at);
+CREATE INDEX IF NOT EXISTS idx_events_dog_id ON events(dog_id);
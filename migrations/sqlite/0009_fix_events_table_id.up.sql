@@ -0,0 +1,17 @@
+-- Recreate events with an autoincrementing ID that is never reused, matching
+-- the Postgres schema
+DROP TABLE IF EXISTS events;
+
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    dog_id INTEGER REFERENCES dogs(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    note VARCHAR(255),
+    at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_events_type ON events(type);
+CREATE INDEX idx_events_at ON events(at);
+CREATE INDEX idx_events_dog_id ON events(dog_id);
@@ -0,0 +1,12 @@
+CREATE TABLE event_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    content TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_comments_event_id ON event_comments(event_id);
+CREATE INDEX idx_event_comments_user_id ON event_comments(user_id);
+CREATE INDEX idx_event_comments_created_at ON event_comments(created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE events 
+DROP COLUMN attachment_url;
+
+ALTER TABLE event_comments 
+DROP COLUMN attachment_url;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_events_dog_id_at;
@@ -0,0 +1,3 @@
+-- Structured measurements used by per-dog statistics
+ALTER TABLE events ADD COLUMN duration_minutes INTEGER;
+ALTER TABLE events ADD COLUMN amount_grams INTEGER;
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS tombstones;
+
+DROP INDEX IF EXISTS idx_consultant_notes_updated_at;
+DROP INDEX IF EXISTS idx_event_comments_updated_at;
+DROP INDEX IF EXISTS idx_events_updated_at;
+DROP INDEX IF EXISTS idx_dogs_updated_at;
+
+DROP INDEX IF EXISTS idx_consultant_notes_client_id;
+DROP INDEX IF EXISTS idx_event_comments_client_id;
+DROP INDEX IF EXISTS idx_events_client_id;
+
+ALTER TABLE consultant_notes DROP COLUMN client_id;
+ALTER TABLE event_comments DROP COLUMN client_id;
+ALTER TABLE events DROP COLUMN client_id;
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name IN ('WEBHOOKS_MANAGE_OWN', 'WEBHOOKS_MANAGE_ALL');
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,40 @@
+-- Outbound webhooks
+CREATE TABLE webhook_endpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url VARCHAR(2000) NOT NULL,
+    description VARCHAR(255),
+    secret VARCHAR(64) NOT NULL,
+    event_types VARCHAR(500) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_endpoints_user_id ON webhook_endpoints(user_id);
+
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    endpoint_id INTEGER NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    redelivery_of INTEGER,
+    next_attempt_at DATETIME,
+    last_status_code INTEGER,
+    last_error TEXT,
+    delivered_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries(next_attempt_at);
+
+-- Existing users receive these on the next start (MigrateExistingUsers)
+INSERT INTO permissions (name, description) VALUES
+('WEBHOOKS_MANAGE_OWN', 'Manage own webhook endpoints'),
+('WEBHOOKS_MANAGE_ALL', 'Manage any webhook endpoint')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Domain events awaiting dispatch (EVENTS_OUTBOX=true)
+CREATE TABLE outbox_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    -- Events are claimed by one relay at a time, see OutboxRepository.Claim
+    locked_by VARCHAR(100),
+    locked_until DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- FTS5 tables and triggers are managed by internal/search
+DROP TRIGGER IF EXISTS events_fts_ai;
+DROP TRIGGER IF EXISTS events_fts_ad;
+DROP TRIGGER IF EXISTS events_fts_au;
+DROP TRIGGER IF EXISTS consultant_notes_fts_ai;
+DROP TRIGGER IF EXISTS consultant_notes_fts_ad;
+DROP TRIGGER IF EXISTS consultant_notes_fts_au;
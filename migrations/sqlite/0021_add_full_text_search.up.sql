@@ -0,0 +1,4 @@
+-- Full-text search on SQLite uses FTS5 tables, which exist only in builds with the
+-- sqlite_fts5 tag, so internal/search creates them at startup. This migration
+-- keeps the version numbers of both dialects in step.
+SELECT 1;
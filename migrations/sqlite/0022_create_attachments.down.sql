@@ -0,0 +1,9 @@
+UPDATE events SET attachment_url = (
+    SELECT a.location FROM attachments a WHERE a.event_id = events.id
+) WHERE id IN (SELECT event_id FROM attachments WHERE event_id IS NOT NULL);
+
+UPDATE event_comments SET attachment_url = (
+    SELECT a.location FROM attachments a WHERE a.comment_id = event_comments.id
+) WHERE id IN (SELECT comment_id FROM attachments WHERE comment_id IS NOT NULL);
+
+DROP TABLE attachments;
@@ -0,0 +1,29 @@
+ALTER TABLE events ADD COLUMN attachment_url VARCHAR(500);
+ALTER TABLE event_comments ADD COLUMN attachment_url VARCHAR(500);
+
+UPDATE events SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.event_id = events.id
+) WHERE id IN (SELECT event_id FROM attachments WHERE event_id IS NOT NULL);
+
+UPDATE event_comments SET attachment_url = (
+    SELECT '/api/v1/attachments/' || a.id || '/content' FROM attachments a WHERE a.comment_id = event_comments.id
+) WHERE id IN (SELECT comment_id FROM attachments WHERE comment_id IS NOT NULL);
+
+CREATE TABLE attachments_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES event_comments(id) ON DELETE CASCADE,
+    location VARCHAR(500) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The base URL is not known here, keys are kept as relative locations
+INSERT INTO attachments_old (id, event_id, comment_id, location, created_at)
+SELECT id, event_id, comment_id, CASE WHEN backend = 'local' THEN '/files/' || key ELSE '/' || key END, created_at
+FROM attachments;
+
+DROP TABLE attachments;
+ALTER TABLE attachments_old RENAME TO attachments;
+
+CREATE INDEX idx_attachments_event_id ON attachments(event_id);
+CREATE INDEX idx_attachments_comment_id ON attachments(comment_id);
@@ -0,0 +1,37 @@
+-- Attachments keep the storage key and metadata instead of absolute URLs. The table
+-- is rebuilt so that key and backend are NOT NULL without a default.
+CREATE TABLE attachments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES event_comments(id) ON DELETE CASCADE,
+    key VARCHAR(500) NOT NULL,
+    backend VARCHAR(20) NOT NULL,
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Both backends stored uploads under events/, local URLs went through /uploads or /files.
+-- Size, SHA-256 and content type of existing files are unknown.
+INSERT INTO attachments_new (id, event_id, comment_id, key, backend, created_at)
+SELECT id, event_id, comment_id,
+    substr(location, instr(location, '/events/') + 1),
+    CASE
+        WHEN location LIKE '%/uploads/events/%' OR location LIKE '%/files/events/%' THEN 'local'
+        ELSE 's3'
+    END,
+    created_at
+FROM attachments;
+UPDATE attachments_new SET filename = substr(key, length('events/') + 1) WHERE key LIKE 'events/%';
+
+DROP TABLE attachments;
+ALTER TABLE attachments_new RENAME TO attachments;
+
+CREATE INDEX idx_attachments_event_id ON attachments(event_id);
+CREATE INDEX idx_attachments_comment_id ON attachments(comment_id);
+
+-- Download paths are resolved from attachments when loading
+ALTER TABLE events DROP COLUMN attachment_url;
+ALTER TABLE event_comments DROP COLUMN attachment_url;
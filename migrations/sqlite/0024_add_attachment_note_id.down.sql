@@ -0,0 +1,26 @@
+-- Note attachments have no other owner. SQLite cannot drop a foreign key column,
+-- so the table is rebuilt.
+DELETE FROM attachments WHERE note_id IS NOT NULL;
+
+CREATE TABLE attachments_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES event_comments(id) ON DELETE CASCADE,
+    key VARCHAR(500) NOT NULL,
+    backend VARCHAR(20) NOT NULL,
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO attachments_new (id, event_id, comment_id, key, backend, filename, content_type, size, sha256, created_at)
+SELECT id, event_id, comment_id, key, backend, filename, content_type, size, sha256, created_at
+FROM attachments;
+
+DROP TABLE attachments;
+ALTER TABLE attachments_new RENAME TO attachments;
+
+CREATE INDEX idx_attachments_event_id ON attachments(event_id);
+CREATE INDEX idx_attachments_comment_id ON attachments(comment_id);
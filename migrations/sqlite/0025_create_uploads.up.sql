@@ -0,0 +1,30 @@
+-- Resumable (tus) uploads and the chunks received for them
+CREATE TABLE uploads (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    backend VARCHAR(20) NOT NULL,
+    key VARCHAR(500),
+    sha256 VARCHAR(64),
+    completed_at DATETIME,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_uploads_user_id ON uploads(user_id);
+CREATE INDEX idx_uploads_expires_at ON uploads(expires_at);
+
+CREATE TABLE upload_chunks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    upload_id VARCHAR(36) NOT NULL REFERENCES uploads(id) ON DELETE CASCADE,
+    chunk_offset BIGINT NOT NULL,
+    size BIGINT NOT NULL,
+    key VARCHAR(500) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_upload_chunks_upload_id ON upload_chunks(upload_id);
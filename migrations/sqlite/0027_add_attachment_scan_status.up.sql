@@ -0,0 +1,5 @@
+-- Malware scan state of attachments. Files uploaded before scanning was added are
+-- quarantined too; the attachments.requeue task queues their scans.
+ALTER TABLE attachments ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'quarantine';
+ALTER TABLE attachments ADD COLUMN scan_threat VARCHAR(255);
+ALTER TABLE attachments ADD COLUMN scanned_at DATETIME;
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS attachments_refs_ai;
+DROP TRIGGER IF EXISTS attachments_refs_ad;
+DROP TRIGGER IF EXISTS attachments_refs_au;
+DROP TRIGGER IF EXISTS thumbnails_refs_ai;
+DROP TRIGGER IF EXISTS thumbnails_refs_ad;
+DROP TRIGGER IF EXISTS thumbnails_refs_au;
+DROP TRIGGER IF EXISTS uploads_refs_ai;
+DROP TRIGGER IF EXISTS uploads_refs_ad;
+DROP TRIGGER IF EXISTS uploads_refs_au;
+DROP TRIGGER IF EXISTS upload_chunks_refs_ai;
+DROP TRIGGER IF EXISTS upload_chunks_refs_ad;
+DROP TRIGGER IF EXISTS upload_chunks_refs_au;
+DROP TABLE IF EXISTS stored_objects;
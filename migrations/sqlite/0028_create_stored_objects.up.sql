@@ -0,0 +1,79 @@
+-- Files in the storage backends with the number of rows referring to them.
+-- Uploads of identical content share one file; it is deleted once the count
+-- drops to zero. Triggers keep the count, so rows removed by cascades count too.
+CREATE TABLE stored_objects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key VARCHAR(500) NOT NULL,
+    backend VARCHAR(20) NOT NULL,
+    sha256 VARCHAR(64),
+    size BIGINT NOT NULL DEFAULT 0,
+    ref_count INTEGER NOT NULL DEFAULT 0,
+    claimed_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_stored_objects_key ON stored_objects(key);
+CREATE INDEX idx_stored_objects_content ON stored_objects(backend, sha256, size);
+CREATE INDEX idx_stored_objects_ref_count ON stored_objects(backend, ref_count);
+
+-- Files stored before the table existed; legacy attachments have no hash
+INSERT INTO stored_objects (key, backend, sha256, size, ref_count)
+SELECT key, MIN(backend), MAX(sha256), MAX(size), COUNT(*)
+FROM (
+    SELECT key, backend, NULLIF(sha256, '') AS sha256, size FROM attachments
+    UNION ALL
+    SELECT t.key, COALESCE(a.backend, u.backend, ''), NULL, 0
+    FROM thumbnails t
+    LEFT JOIN attachments a ON a.id = t.attachment_id
+    LEFT JOIN uploads u ON u.id = t.upload_id
+    UNION ALL
+    SELECT key, backend, NULLIF(sha256, ''), size FROM uploads WHERE key IS NOT NULL AND key <> ''
+    UNION ALL
+    SELECT c.key, u.backend, NULL, c.size FROM upload_chunks c JOIN uploads u ON u.id = c.upload_id
+) refs
+GROUP BY key;
+
+CREATE TRIGGER attachments_refs_ai AFTER INSERT ON attachments BEGIN
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+CREATE TRIGGER attachments_refs_ad AFTER DELETE ON attachments BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+END;
+CREATE TRIGGER attachments_refs_au AFTER UPDATE OF key ON attachments WHEN OLD.key IS NOT NEW.key BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+
+CREATE TRIGGER thumbnails_refs_ai AFTER INSERT ON thumbnails BEGIN
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+CREATE TRIGGER thumbnails_refs_ad AFTER DELETE ON thumbnails BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+END;
+CREATE TRIGGER thumbnails_refs_au AFTER UPDATE OF key ON thumbnails WHEN OLD.key IS NOT NEW.key BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+
+-- The key of an upload is empty until it is complete
+CREATE TRIGGER uploads_refs_ai AFTER INSERT ON uploads BEGIN
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+CREATE TRIGGER uploads_refs_ad AFTER DELETE ON uploads BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+END;
+CREATE TRIGGER uploads_refs_au AFTER UPDATE OF key ON uploads WHEN OLD.key IS NOT NEW.key BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+
+CREATE TRIGGER upload_chunks_refs_ai AFTER INSERT ON upload_chunks BEGIN
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
+CREATE TRIGGER upload_chunks_refs_ad AFTER DELETE ON upload_chunks BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+END;
+CREATE TRIGGER upload_chunks_refs_au AFTER UPDATE OF key ON upload_chunks WHEN OLD.key IS NOT NEW.key BEGIN
+    UPDATE stored_objects SET ref_count = ref_count - 1 WHERE key = OLD.key;
+    UPDATE stored_objects SET ref_count = ref_count + 1 WHERE key = NEW.key;
+END;
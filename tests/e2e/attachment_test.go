@@ -0,0 +1,414 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG is a 1x1 transparent PNG
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89\x00\x00\x00\x12IDATx\x9c\x00\x05\x00\xfa\xff\x02\x00\x00\x00\x00\x03\x00\x00\x0f\x00\x03B\xa7\xf5\x0e\x00\x00\x00\x00IEND\xaeB`\x82")
+
+// testPDF is a minimal PDF document
+var testPDF = []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n2 0 obj << /Type /Pages /Kids [] /Count 0 >> endobj\nxref\n0 3\n0000000000 65535 f \n0000000009 00000 n \n0000000058 00000 n \ntrailer << /Size 3 /Root 1 0 R >>\nstartxref\n110\n%%EOF\n")
+
+// testFile is a file part of a multipart request
+type testFile struct {
+	Name    string
+	Content []byte
+}
+
+// postMultipart sends the data form field as JSON, unless data is nil, along with
+// repeated "file" parts typed by their extension
+func postMultipart(t *testing.T, token, path string, data interface{}, files []testFile, result interface{}) int {
+	resp, err := http.DefaultClient.Do(newMultipartRequest(t, token, path, data, files))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if result != nil && len(respBody) > 0 {
+		require.NoError(t, json.Unmarshal(respBody, result), "Failed to unmarshal response: %s", string(respBody))
+	}
+	return resp.StatusCode
+}
+
+// newMultipartRequest builds the request sent by postMultipart
+func newMultipartRequest(t *testing.T, token, path string, data interface{}, files []testFile) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if data != nil {
+		dataJSON, err := json.Marshal(data)
+		require.NoError(t, err)
+		require.NoError(t, form.WriteField("data", string(dataJSON)))
+	}
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, file.Name))
+		header.Set("Content-Type", mime.TypeByExtension(filepath.Ext(file.Name)))
+		part, err := form.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write(file.Content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, form.Close())
+
+	req, err := http.NewRequest("POST", BaseURL+path, &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// noRedirects is a client returning redirects instead of following them
+var noRedirects = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// getAttachment requests an attachment path, relative to the server root, without following redirects
+func getAttachment(t *testing.T, token, path string) *http.Response {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(BaseURL, "/api/v1")+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := noRedirects.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// download fetches a URL and returns the status and body
+func download(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestAttachments(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerEmail := fmt.Sprintf("owner_attach_%d@example.com", time.Now().UnixNano())
+	ownerToken, err := client.RegisterAndLogin("Owner Attach", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("AttachDog", "Beagle", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	otherEmail := fmt.Sprintf("other_attach_%d@example.com", time.Now().UnixNano())
+	otherToken, err := client.RegisterAndLogin("Other Attach", otherEmail, "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	content := string(testPNG)
+	var event map[string]interface{}
+	status := postMultipart(t, ownerToken, "/events", map[string]interface{}{
+		"dog_id": dogID,
+		"type":   "vet",
+		"note":   "vaccination",
+	}, []testFile{{"vet.png", testPNG}, {"invoice.pdf", testPDF}}, &event)
+	require.Equal(t, http.StatusCreated, status)
+	eventID := uint(event["id"].(float64))
+
+	attachmentURL, ok := event["attachment_url"].(string)
+	require.True(t, ok, "event should have an attachment_url")
+	require.Regexp(t, `^/api/v1/attachments/\d+/content$`, attachmentURL)
+
+	attachments, ok := event["attachments"].([]interface{})
+	require.True(t, ok, "event should list its attachments")
+	require.Len(t, attachments, 2)
+
+	t.Run("Attachment Metadata", func(t *testing.T) {
+		attachment := attachments[0].(map[string]interface{})
+		require.Equal(t, "vet.png", attachment["filename"])
+		require.Equal(t, "image/png", attachment["content_type"])
+		require.Equal(t, float64(len(testPNG)), attachment["size"])
+		sum := sha256.Sum256(testPNG)
+		require.Equal(t, hex.EncodeToString(sum[:]), attachment["sha256"])
+		require.NotContains(t, attachment, "key")
+		require.Equal(t, attachmentURL, fmt.Sprintf("/api/v1/attachments/%.0f/content", attachment["id"]))
+		require.Equal(t, attachmentURL, attachment["url"])
+
+		invoice := attachments[1].(map[string]interface{})
+		require.Equal(t, "invoice.pdf", invoice["filename"])
+		require.Equal(t, "application/pdf", invoice["content_type"])
+	})
+
+	t.Run("Event Lists Download Path", func(t *testing.T) {
+		var fetched map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events/%d", eventID), &fetched)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, attachmentURL, fetched["attachment_url"])
+		require.Equal(t, event["attachments"], fetched["attachments"])
+	})
+
+	t.Run("Owner Downloads Through Signed URL", func(t *testing.T) {
+		resp := getAttachment(t, ownerToken, attachmentURL)
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location := resp.Header.Get("Location")
+		require.Contains(t, location, "/files/")
+		require.Contains(t, location, "signature=")
+		require.Contains(t, location, "expires=")
+
+		status, body := download(t, location)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, content, body)
+	})
+
+	t.Run("Token In Query Rejected", func(t *testing.T) {
+		resp := getAttachment(t, "", attachmentURL+"?access_token="+ownerToken)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Signed Link", func(t *testing.T) {
+		linkPath := strings.TrimPrefix(strings.TrimSuffix(attachmentURL, "/content"), "/api/v1") + "/link"
+		var link map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(linkPath, &link))
+		require.Contains(t, link["url"], "signature=")
+		expiresAt, err := time.Parse(time.RFC3339Nano, link["expires_at"].(string))
+		require.NoError(t, err)
+		require.True(t, expiresAt.After(time.Now()))
+
+		// The link works without a token
+		status, body := download(t, link["url"].(string))
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, content, body)
+
+		require.Equal(t, http.StatusNotFound, getAttachment(t, otherToken, "/api/v1"+linkPath).StatusCode)
+		require.Equal(t, http.StatusUnauthorized, getAttachment(t, "", "/api/v1"+linkPath).StatusCode)
+	})
+
+	t.Run("Anonymous Rejected", func(t *testing.T) {
+		resp := getAttachment(t, "", attachmentURL)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Other Owner Gets 404", func(t *testing.T) {
+		resp := getAttachment(t, otherToken, attachmentURL)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Tampered Or Unsigned URL Rejected", func(t *testing.T) {
+		resp := getAttachment(t, ownerToken, attachmentURL)
+		location := resp.Header.Get("Location")
+
+		status, _ := download(t, strings.Split(location, "?")[0])
+		require.Equal(t, http.StatusForbidden, status)
+
+		i := strings.Index(location, "signature=") + len("signature=")
+		tampered := location[:i] + strings.Repeat("0", len(location)-i)
+		status, _ = download(t, tampered)
+		require.Equal(t, http.StatusForbidden, status)
+
+		expired := strings.Replace(location, "expires=", "expires=1", 1)
+		status, _ = download(t, expired)
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Uploads Not Public", func(t *testing.T) {
+		resp := getAttachment(t, "", "/uploads/events/vet.png")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Comment Attachment", func(t *testing.T) {
+		var comment map[string]interface{}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/comments", eventID), map[string]interface{}{
+			"content": "certificate attached",
+		}, []testFile{{"certificate.png", testPNG}}, &comment)
+		require.Equal(t, http.StatusCreated, status)
+
+		commentURL, ok := comment["attachment_url"].(string)
+		require.True(t, ok, "comment should have an attachment_url")
+		require.NotEqual(t, attachmentURL, commentURL)
+
+		var fetched map[string]interface{}
+		status = client.Get(fmt.Sprintf("/event-comments/%d", uint(comment["id"].(float64))), &fetched)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, commentURL, fetched["attachment_url"])
+
+		resp := getAttachment(t, ownerToken, commentURL)
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		status, body := download(t, resp.Header.Get("Location"))
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, content, body)
+
+		resp = getAttachment(t, otherToken, commentURL)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Add And Remove Event Files", func(t *testing.T) {
+		var added []map[string]interface{}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/attachments", eventID), nil,
+			[]testFile{{"lab.pdf", testPDF}, {"xray.png", testPNG}}, &added)
+		require.Equal(t, http.StatusCreated, status)
+		require.Len(t, added, 2)
+		require.Equal(t, "lab.pdf", added[0]["filename"])
+
+		var fetched map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &fetched))
+		require.Len(t, fetched["attachments"], 4)
+
+		labURL := added[0]["url"].(string)
+		other := NewTestClient(BaseURL)
+		other.SetT(t)
+		other.SetToken(otherToken)
+		require.Equal(t, http.StatusNotFound, other.Delete(fmt.Sprintf("/attachments/%.0f", added[0]["id"])))
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/attachments/%.0f", added[0]["id"])))
+		require.Equal(t, http.StatusNotFound, getAttachment(t, ownerToken, labURL).StatusCode)
+		require.Equal(t, http.StatusNotFound, client.Delete(fmt.Sprintf("/attachments/%.0f", added[0]["id"])))
+
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &fetched))
+		require.Len(t, fetched["attachments"], 3)
+		require.Equal(t, attachmentURL, fetched["attachment_url"], "the first file stays the deprecated attachment_url")
+	})
+
+	t.Run("Add Requires Access", func(t *testing.T) {
+		status := postMultipart(t, otherToken, fmt.Sprintf("/events/%d/attachments", eventID), nil, []testFile{{"x.png", testPNG}}, nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		status = postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/attachments", eventID), nil, nil, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Too Many Files", func(t *testing.T) {
+		files := make([]testFile, 11)
+		for i := range files {
+			files[i] = testFile{fmt.Sprintf("photo%d.png", i), testPNG}
+		}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/attachments", eventID), nil, files, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Streaming Limits", func(t *testing.T) {
+		path := fmt.Sprintf("/events/%d/attachments", eventID)
+
+		// Rejected while copying, after the first 25 MB
+		huge := append(append([]byte{}, testPNG...), make([]byte, 25*1024*1024)...)
+		var resp map[string]interface{}
+		status := postMultipart(t, ownerToken, path, nil, []testFile{{"huge.png", huge}}, &resp)
+		require.Equal(t, http.StatusRequestEntityTooLarge, status)
+		require.Contains(t, resp["error"], "huge.png")
+
+		// The type is sniffed from the content, not the name
+		status = postMultipart(t, ownerToken, path, nil, []testFile{{"photo.png", []byte("MZ\x90\x00\x03\x00\x00\x00 not an image")}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = postMultipart(t, ownerToken, path, nil, []testFile{{"empty.png", nil}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		var fetched map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &fetched))
+		require.Len(t, fetched["attachments"], 3, "rejected uploads are not attached")
+	})
+
+	t.Run("Large Upload With Idempotency Key", func(t *testing.T) {
+		// Bodies over 1 MB are spooled to disk for the fingerprint and still reach the handler
+		large := append(append([]byte{}, testMP4...), make([]byte, 3*1024*1024)...)
+		key := fmt.Sprintf("attach-%d", time.Now().UnixNano())
+		first := newMultipartRequest(t, ownerToken, fmt.Sprintf("/events/%d/attachments", eventID), nil, []testFile{{"walk.mp4", large}})
+		body, err := io.ReadAll(first.Body)
+		require.NoError(t, err)
+		send := func() (*http.Response, []map[string]interface{}) {
+			req, err := http.NewRequest("POST", first.URL.String(), bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header = first.Header.Clone()
+			req.Header.Set("Idempotency-Key", key)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var added []map[string]interface{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&added))
+			return resp, added
+		}
+
+		resp, added := send()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Len(t, added, 1)
+		require.Equal(t, float64(len(large)), added[0]["size"])
+
+		resp, replayed := send()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+		require.Equal(t, added, replayed)
+
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/attachments/%.0f", added[0]["id"])))
+	})
+
+	t.Run("Comment And Note Files", func(t *testing.T) {
+		consultant := NewTestClient(BaseURL)
+		consultant.SetT(t)
+		consultantToken, err := consultant.RegisterAndLogin("Consultant Attach", fmt.Sprintf("consultant_attach_%d@example.com", time.Now().UnixNano()), "password", "consultant")
+		require.NoError(t, err)
+		var profile map[string]interface{}
+		require.Equal(t, http.StatusOK, consultant.Put("/consultants/profile", map[string]interface{}{"description": "Vet"}, &profile))
+		var invite map[string]interface{}
+		require.Equal(t, http.StatusCreated, client.Post(fmt.Sprintf("/consultants/%.0f/invite", profile["user_id"].(float64)), map[string]interface{}{"dog_id": dogID}, &invite))
+		require.Equal(t, http.StatusOK, consultant.Post(fmt.Sprintf("/invites/accept?token=%s", invite["token"].(string)), nil, nil))
+
+		// An assigned consultant may add files to the owner's event, but not remove them
+		var eventFiles []map[string]interface{}
+		require.Equal(t, http.StatusCreated, postMultipart(t, consultantToken, fmt.Sprintf("/events/%d/attachments", eventID), nil,
+			[]testFile{{"xray.png", testPNG}}, &eventFiles))
+		require.Len(t, eventFiles, 1)
+		eventFilePath := fmt.Sprintf("/attachments/%.0f", eventFiles[0]["id"])
+		require.Equal(t, http.StatusForbidden, consultant.Delete(eventFilePath))
+		require.Equal(t, http.StatusFound, getAttachment(t, ownerToken, eventFiles[0]["url"].(string)).StatusCode)
+		require.Equal(t, http.StatusNoContent, client.Delete(eventFilePath))
+
+		// Only the comment author adds files to a comment
+		var comment map[string]interface{}
+		require.Equal(t, http.StatusCreated, consultant.Post(fmt.Sprintf("/events/%d/comments", eventID), map[string]interface{}{"event_id": eventID, "content": "Results"}, &comment))
+		commentPath := fmt.Sprintf("/event-comments/%.0f/attachments", comment["id"])
+		require.Equal(t, http.StatusCreated, postMultipart(t, consultantToken, commentPath, nil, []testFile{{"results.pdf", testPDF}}, nil))
+		require.Equal(t, http.StatusForbidden, postMultipart(t, ownerToken, commentPath, nil, []testFile{{"x.png", testPNG}}, nil))
+
+		var fetched map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/event-comments/%.0f", comment["id"]), &fetched))
+		require.Len(t, fetched["attachments"], 1)
+		commentFile := fetched["attachments"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, http.StatusFound, getAttachment(t, ownerToken, commentFile["url"].(string)).StatusCode)
+		require.Equal(t, http.StatusForbidden, client.Delete(fmt.Sprintf("/attachments/%.0f", commentFile["id"])))
+
+		// Notes and their files stay private to the consultant
+		var note map[string]interface{}
+		require.Equal(t, http.StatusCreated, consultant.Post("/consultant-notes", map[string]interface{}{"dog_id": dogID, "title": "Plan", "content": "Diet"}, &note))
+		var noteFiles []map[string]interface{}
+		require.Equal(t, http.StatusCreated, postMultipart(t, consultantToken, fmt.Sprintf("/consultant-notes/%.0f/attachments", note["id"]), nil,
+			[]testFile{{"diet.pdf", testPDF}, {"chart.png", testPNG}}, &noteFiles))
+		require.Len(t, noteFiles, 2)
+
+		require.Equal(t, http.StatusOK, consultant.Get(fmt.Sprintf("/consultant-notes/%.0f", note["id"]), &fetched))
+		require.Len(t, fetched["attachments"], 2)
+
+		noteURL := noteFiles[0]["url"].(string)
+		require.Equal(t, http.StatusFound, getAttachment(t, consultantToken, noteURL).StatusCode)
+		require.Equal(t, http.StatusNotFound, getAttachment(t, ownerToken, noteURL).StatusCode)
+		require.Equal(t, http.StatusNoContent, consultant.Delete(fmt.Sprintf("/attachments/%.0f", noteFiles[0]["id"])))
+		require.Equal(t, http.StatusNotFound, getAttachment(t, consultantToken, noteURL).StatusCode)
+	})
+
+	t.Run("Unknown Attachment", func(t *testing.T) {
+		resp := getAttachment(t, ownerToken, "/api/v1/attachments/999999/content")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
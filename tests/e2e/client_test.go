@@ -47,6 +47,12 @@ func (c *TestClient) SetToken(token string) {
 }
 
 func (c *TestClient) doRequest(method, path string, body interface{}, response interface{}) int {
+	status, _ := c.doRequestWithHeaders(method, path, nil, body, response)
+	return status
+}
+
+// doRequestWithHeaders sends extra request headers and returns the response headers too
+func (c *TestClient) doRequestWithHeaders(method, path string, headers map[string]string, body interface{}, response interface{}) (int, http.Header) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -61,6 +67,9 @@ func (c *TestClient) doRequest(method, path string, body interface{}, response i
 	if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 
 	resp, err := c.Client.Do(req)
 	require.NoError(c.t, err)
@@ -75,13 +84,17 @@ func (c *TestClient) doRequest(method, path string, body interface{}, response i
 		}
 	}
 
-	return resp.StatusCode
+	return resp.StatusCode, resp.Header
 }
 
 func (c *TestClient) Post(path string, body interface{}, result interface{}) int {
 	return c.doRequest("POST", path, body, result)
 }
 
+func (c *TestClient) PostWithHeaders(path string, headers map[string]string, body interface{}, result interface{}) (int, http.Header) {
+	return c.doRequestWithHeaders("POST", path, headers, body, result)
+}
+
 func (c *TestClient) Put(path string, body interface{}, result interface{}) int {
 	return c.doRequest("PUT", path, body, result)
 }
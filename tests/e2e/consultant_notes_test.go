@@ -117,6 +117,7 @@ func TestConsultantNotes(t *testing.T) {
 		
 		notes := resp["notes"].([]interface{})
 		require.NotEmpty(t, notes)
+		require.Contains(t, notes[0].(map[string]interface{})["highlight"], "<mark>")
 	})
 
 	t.Run("Sort by Updated Date", func(t *testing.T) {
@@ -53,6 +53,11 @@ func TestConsultantFlow(t *testing.T) {
 	data := searchResp["data"].([]interface{})
 	require.NotEmpty(t, data, "Search should find the consultant")
 
+	// Matching is case-insensitive on every database
+	status = client.Get("/consultants?query=smith&services=training", &searchResp)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, searchResp["data"], "Search should ignore case")
+
 	// 5. Owner invites consultant
 	inviteReq := map[string]interface{}{
 		"dog_id": dogID,
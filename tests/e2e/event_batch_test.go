@@ -0,0 +1,128 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsBatch(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	// Setup: a stranger's dog the owner must not be able to write to
+	strangerEmail := fmt.Sprintf("stranger_batch_%d@example.com", time.Now().UnixNano())
+	_, err := client.RegisterAndLogin("Stranger Batch", strangerEmail, "password", "owner")
+	require.NoError(t, err)
+	strangerDogID, err := client.CreateDog("StrangerDog", "Pug", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	ownerEmail := fmt.Sprintf("owner_batch_%d@example.com", time.Now().UnixNano())
+	_, err = client.RegisterAndLogin("Owner Batch", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+	dogID, err := client.CreateDog("BatchDog", "Beagle", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	countEvents := func(t *testing.T) int {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+		return int(resp["total_count"].(float64))
+	}
+
+	t.Run("Atomic batch creates all events", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Post("/events/batch", map[string]interface{}{
+			"events": []map[string]interface{}{
+				{"dog_id": dogID, "type": "feed", "amount_grams": 120, "at": "2025-03-01T07:00:00Z"},
+				{"dog_id": dogID, "type": "walk", "duration_minutes": 30, "at": "2025-03-01T08:00:00Z"},
+				{"dog_id": dogID, "type": "feed", "amount_grams": 150, "at": "2025-03-01T18:00:00Z"},
+			},
+		}, &resp)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "atomic", resp["mode"])
+		require.Equal(t, float64(3), resp["created"])
+		require.Equal(t, float64(0), resp["failed"])
+
+		results := resp["results"].([]interface{})
+		require.Len(t, results, 3)
+		for i, raw := range results {
+			result := raw.(map[string]interface{})
+			require.Equal(t, float64(i), result["index"])
+			require.Equal(t, float64(http.StatusCreated), result["status"])
+			require.NotNil(t, result["event"])
+		}
+		require.Equal(t, 3, countEvents(t))
+	})
+
+	t.Run("Atomic batch with an invalid item creates nothing", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Post("/events/batch", map[string]interface{}{
+			"mode": "atomic",
+			"events": []map[string]interface{}{
+				{"dog_id": dogID, "type": "walk", "at": "2025-03-02T08:00:00Z"},
+				{"dog_id": dogID, "at": "2025-03-02T09:00:00Z"}, // missing type
+			},
+		}, &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		require.Equal(t, float64(0), resp["created"])
+
+		results := resp["results"].([]interface{})
+		require.Equal(t, float64(http.StatusFailedDependency), results[0].(map[string]interface{})["status"])
+		require.Equal(t, float64(http.StatusBadRequest), results[1].(map[string]interface{})["status"])
+		require.Equal(t, 3, countEvents(t))
+	})
+
+	t.Run("Atomic batch with a foreign dog creates nothing", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Post("/events/batch", map[string]interface{}{
+			"events": []map[string]interface{}{
+				{"dog_id": dogID, "type": "walk", "at": "2025-03-03T08:00:00Z"},
+				{"dog_id": strangerDogID, "type": "walk", "at": "2025-03-03T08:00:00Z"},
+			},
+		}, &resp)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		results := resp["results"].([]interface{})
+		require.Equal(t, float64(http.StatusNotFound), results[1].(map[string]interface{})["status"])
+		require.Equal(t, 3, countEvents(t))
+	})
+
+	t.Run("Partial batch reports failures per item", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Post("/events/batch", map[string]interface{}{
+			"mode": "partial",
+			"events": []map[string]interface{}{
+				{"dog_id": dogID, "type": "walk", "at": "2025-03-04T08:00:00Z"},
+				{"dog_id": strangerDogID, "type": "walk", "at": "2025-03-04T08:00:00Z"},
+				{"dog_id": dogID, "type": "feed", "amount_grams": -5},
+				{"dog_id": dogID, "type": "feed", "at": "2025-03-04T18:00:00Z"},
+			},
+		}, &resp)
+		require.Equal(t, http.StatusMultiStatus, status)
+		require.Equal(t, float64(2), resp["created"])
+		require.Equal(t, float64(2), resp["failed"])
+
+		expected := []int{http.StatusCreated, http.StatusNotFound, http.StatusBadRequest, http.StatusCreated}
+		results := resp["results"].([]interface{})
+		require.Len(t, results, len(expected))
+		for i, raw := range results {
+			require.Equal(t, float64(expected[i]), raw.(map[string]interface{})["status"], "item %d", i)
+		}
+		require.Equal(t, 5, countEvents(t))
+	})
+
+	t.Run("Batch size is limited", func(t *testing.T) {
+		events := make([]map[string]interface{}, 101)
+		for i := range events {
+			events[i] = map[string]interface{}{"dog_id": dogID, "type": "walk"}
+		}
+		status := client.Post("/events/batch", map[string]interface{}{"events": events}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = client.Post("/events/batch", map[string]interface{}{"events": []interface{}{}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -71,4 +71,45 @@ func TestEvents(t *testing.T) {
 		require.Len(t, events, 1)
 		require.Equal(t, "feed", events[0].(map[string]interface{})["type"])
 	})
+
+	t.Run("Sort Events by Occurrence Time", func(t *testing.T) {
+		// Back-filled event: recorded last, but happened first
+		backfill := map[string]interface{}{"dog_id": dogID, "type": "meds", "note": "Backfilled pill", "at": "2025-01-01T06:00:00Z"}
+		status := client.Post("/events", backfill, nil)
+		require.Equal(t, 201, status)
+
+		var resp map[string]interface{}
+		status = client.Get("/events?sort_by=at&sort_order=asc", &resp)
+		require.Equal(t, 200, status)
+
+		events := resp["events"].([]interface{})
+		require.Len(t, events, 3)
+		require.Equal(t, "meds", events[0].(map[string]interface{})["type"])
+		require.Equal(t, "feed", events[2].(map[string]interface{})["type"])
+	})
+
+	t.Run("Filter Events by Datetime Range", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get("/events?from=2025-01-01T07:00:00Z&to=2025-01-01T12:00:00Z", &resp)
+		require.Equal(t, 200, status)
+
+		events := resp["events"].([]interface{})
+		require.Len(t, events, 1)
+		require.Equal(t, "walk", events[0].(map[string]interface{})["type"])
+	})
+
+	t.Run("Filter Events by Dog", func(t *testing.T) {
+		otherDogID, err := client.CreateDog("Other", "Pug", "2021-01-01T00:00:00Z")
+		require.NoError(t, err)
+		status := client.Post("/events", map[string]interface{}{"dog_id": otherDogID, "type": "walk", "at": "2025-01-02T08:00:00Z"}, nil)
+		require.Equal(t, 201, status)
+
+		var resp map[string]interface{}
+		status = client.Get(fmt.Sprintf("/events?dog_id=%d", otherDogID), &resp)
+		require.Equal(t, 200, status)
+
+		events := resp["events"].([]interface{})
+		require.Len(t, events, 1)
+		require.Equal(t, float64(otherDogID), events[0].(map[string]interface{})["dog_id"])
+	})
 }
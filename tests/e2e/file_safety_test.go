@@ -0,0 +1,197 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSVG is an SVG drawing that runs scripts when opened as a page
+const testSVG = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100" viewBox="0 0 200 100" onload="alert(document.cookie)">
+  <script>fetch('/api/v1/users/me')</script>
+  <rect x="10" y="10" width="80" height="80" fill="#d33"/>
+  <foreignObject width="100" height="100"><body xmlns="http://www.w3.org/1999/xhtml"><iframe src="javascript:alert(1)"></iframe></body></foreignObject>
+</svg>`
+
+// testDOCX builds a DOCX archive of the given entries, adding the content
+// types part of a Word document
+func testDOCX(t *testing.T, entries map[string]string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`,
+	}
+	for name, content := range entries {
+		files[name] = content
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+// fetch follows an attachment path to its file and returns the response and body
+func fetch(t *testing.T, token, path string) (*http.Response, string) {
+	resp := getAttachment(t, token, path)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	file, err := http.Get(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	defer file.Body.Close()
+	var body bytes.Buffer
+	_, err = body.ReadFrom(file.Body)
+	require.NoError(t, err)
+	return file, body.String()
+}
+
+func TestFileSafety(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerToken, err := client.RegisterAndLogin("Owner Files", fmt.Sprintf("owner_files_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("FilesDog", "Husky", "2019-01-01T00:00:00Z")
+	require.NoError(t, err)
+	var event map[string]interface{}
+	require.Equal(t, http.StatusCreated, client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "vet"}, &event))
+	path := fmt.Sprintf("/events/%.0f/attachments", event["id"])
+
+	// attach adds a file to the event and returns the attachment, or the error response
+	attach := func(t *testing.T, file testFile) (int, map[string]interface{}) {
+		var resp interface{}
+		status := postMultipart(t, ownerToken, path, nil, []testFile{file}, &resp)
+		if added, ok := resp.([]interface{}); ok {
+			require.Len(t, added, 1)
+			return status, added[0].(map[string]interface{})
+		}
+		return status, resp.(map[string]interface{})
+	}
+
+	t.Run("SVG Rendered To PNG", func(t *testing.T) {
+		status, attachment := attach(t, testFile{"chart.svg", []byte(testSVG)})
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "chart.png", attachment["filename"])
+		require.Equal(t, "image/png", attachment["content_type"])
+		require.Len(t, attachment["thumbnails"], 3)
+
+		resp, body := fetch(t, ownerToken, attachment["url"].(string))
+		require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		require.NotContains(t, body, "script")
+		config, format, err := image.DecodeConfig(strings.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, "png", format)
+		require.Equal(t, 2048, config.Width)
+		require.Equal(t, 1024, config.Height)
+	})
+
+	t.Run("Not An SVG Rejected", func(t *testing.T) {
+		status, resp := attach(t, testFile{"page.svg", []byte("<html><body><script>alert(1)</script></body></html>")})
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Contains(t, resp["error"], "page.svg")
+	})
+
+	t.Run("Documents Downloaded As Attachments", func(t *testing.T) {
+		status, attachment := attach(t, testFile{"lab results.pdf", testPDF})
+		require.Equal(t, http.StatusCreated, status)
+
+		resp, body := fetch(t, ownerToken, attachment["url"].(string))
+		require.Equal(t, string(testPDF), body)
+		require.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+		require.Equal(t, `attachment; filename="lab results.pdf"`, resp.Header.Get("Content-Disposition"))
+		require.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+		require.Contains(t, resp.Header.Get("Content-Security-Policy"), "sandbox")
+
+		// The type and disposition are signed with the URL
+		location := getAttachment(t, ownerToken, attachment["url"].(string)).Header.Get("Location")
+		forged := strings.Replace(location, "response-content-type=application%2Fpdf", "response-content-type=text%2Fhtml", 1)
+		require.NotEqual(t, location, forged)
+		status, _ = download(t, forged)
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Images Shown Inline", func(t *testing.T) {
+		status, attachment := attach(t, testFile{"paw.png", testPNG})
+		require.Equal(t, http.StatusCreated, status)
+		resp, _ := fetch(t, ownerToken, attachment["url"].(string))
+		require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		require.Equal(t, "inline; filename=paw.png", resp.Header.Get("Content-Disposition"))
+
+		thumbnail := attachment["thumbnails"].([]interface{})[0].(map[string]interface{})
+		resp, _ = fetch(t, ownerToken, thumbnail["url"].(string))
+		require.Equal(t, "inline", resp.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("Type Detected From Content", func(t *testing.T) {
+		status, attachment := attach(t, testFile{"notes.txt", []byte("Feeding: 200 g twice a day")})
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "text/plain; charset=utf-8", attachment["content_type"])
+		resp, _ := fetch(t, ownerToken, attachment["url"].(string))
+		require.Equal(t, "attachment; filename=notes.txt", resp.Header.Get("Content-Disposition"))
+		require.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+
+		// A page named as text is not stored as one
+		status, _ = attach(t, testFile{"notes.txt", []byte("<html><script>alert(1)</script></html>")})
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Document Structure Checked", func(t *testing.T) {
+		document := map[string]string{"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"/>`}
+		status, attachment := attach(t, testFile{"diet.docx", testDOCX(t, document)})
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", attachment["content_type"])
+
+		macros := map[string]string{"word/document.xml": document["word/document.xml"], "word/vbaProject.bin": "macro"}
+		invalid := []testFile{
+			{"truncated.pdf", testPDF[:100]},
+			{"noxref.pdf", []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")},
+			{"report.docx", testDOCX(t, map[string]string{"xl/workbook.xml": "<workbook/>"})},
+			{"macros.docx", testDOCX(t, macros)},
+			{"archive.docx", []byte("PK\x03\x04 not really a zip archive")},
+			{"old.doc", []byte("plain text renamed to .doc")},
+		}
+		for _, file := range invalid {
+			status, resp := attach(t, file)
+			require.Equal(t, http.StatusBadRequest, status, file.Name)
+			require.Contains(t, resp["error"], "document is corrupt", file.Name)
+		}
+	})
+
+	t.Run("Resumable Upload Checked On Completion", func(t *testing.T) {
+		broken := append(append([]byte{}, testPDF[:100]...), bytes.Repeat([]byte(" "), 100)...)
+		location := createUpload(t, ownerToken, "scan.pdf", len(broken))
+		status, _ := tusRequest(t, ownerToken, "PATCH", location, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": "0",
+		}, broken)
+		require.Equal(t, http.StatusBadRequest, status)
+		status, _ = tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		location = createUpload(t, ownerToken, "chart.svg", len(testSVG))
+		status, headers := tusRequest(t, ownerToken, "PATCH", location, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": "0",
+		}, []byte(testSVG))
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, strconv.Itoa(len(testSVG)), headers.Get("Upload-Offset"))
+
+		var added []map[string]interface{}
+		require.Equal(t, http.StatusCreated, attachUploads(t, ownerToken, uint(event["id"].(float64)), []string{uploadID(location)}, &added))
+		require.Equal(t, "chart.png", added[0]["filename"])
+		require.Equal(t, "image/png", added[0]["content_type"])
+	})
+}
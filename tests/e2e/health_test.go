@@ -0,0 +1,36 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	u, err := url.Parse(BaseURL)
+	require.NoError(t, err)
+
+	resp, err := http.Get(fmt.Sprintf("%s://%s/health", u.Scheme, u.Host))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var health struct {
+		DB     string `json:"db"`
+		Schema struct {
+			Version uint `json:"version"`
+			Dirty   bool `json:"dirty"`
+			Latest  uint `json:"latest"`
+		} `json:"schema"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+
+	require.Equal(t, "up", health.DB)
+	require.False(t, health.Schema.Dirty)
+	require.NotZero(t, health.Schema.Latest)
+	require.Equal(t, health.Schema.Latest, health.Schema.Version, "server runs with pending migrations")
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	email := fmt.Sprintf("owner_idempotency_%d@example.com", time.Now().UnixNano())
+	_, err := client.RegisterAndLogin("Owner Idempotency", email, "password", "owner")
+	require.NoError(t, err)
+	dogID, err := client.CreateDog("RetryDog", "Corgi", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	countEvents := func(t *testing.T) int {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+		return int(resp["total_count"].(float64))
+	}
+
+	key := fmt.Sprintf("walk-%d", time.Now().UnixNano())
+	body := map[string]interface{}{"dog_id": dogID, "type": "walk", "at": "2025-04-01T08:00:00Z"}
+
+	var first map[string]interface{}
+
+	t.Run("First request is executed", func(t *testing.T) {
+		status, headers := client.PostWithHeaders("/events", map[string]string{"Idempotency-Key": key}, body, &first)
+		require.Equal(t, http.StatusCreated, status)
+		require.Empty(t, headers.Get("Idempotent-Replayed"))
+		require.Equal(t, 1, countEvents(t))
+	})
+
+	t.Run("Retry replays the original response", func(t *testing.T) {
+		var replayed map[string]interface{}
+		status, headers := client.PostWithHeaders("/events", map[string]string{"Idempotency-Key": key}, body, &replayed)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "true", headers.Get("Idempotent-Replayed"))
+		require.Equal(t, first["id"], replayed["id"])
+		require.Equal(t, 1, countEvents(t))
+	})
+
+	t.Run("Reused key with a different body is rejected", func(t *testing.T) {
+		other := map[string]interface{}{"dog_id": dogID, "type": "feed", "at": "2025-04-01T09:00:00Z"}
+		status, _ := client.PostWithHeaders("/events", map[string]string{"Idempotency-Key": key}, other, nil)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		require.Equal(t, 1, countEvents(t))
+	})
+
+	t.Run("Keys are scoped per user", func(t *testing.T) {
+		otherClient := NewTestClient(BaseURL)
+		otherClient.SetT(t)
+		_, err := otherClient.RegisterAndLogin("Other Idempotency", fmt.Sprintf("other_idempotency_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+		otherDogID, err := otherClient.CreateDog("OtherRetryDog", "Pug", "2020-01-01T00:00:00Z")
+		require.NoError(t, err)
+
+		status, headers := otherClient.PostWithHeaders("/events", map[string]string{"Idempotency-Key": key}, map[string]interface{}{"dog_id": otherDogID, "type": "walk"}, nil)
+		require.Equal(t, http.StatusCreated, status)
+		require.Empty(t, headers.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Forbidden requests do not use up the key", func(t *testing.T) {
+		// Owners cannot create consultant notes
+		forbiddenKey := fmt.Sprintf("forbidden-%d", time.Now().UnixNano())
+		note := map[string]interface{}{"dog_id": dogID, "title": "Nope", "content": "Nope"}
+		for i := 0; i < 2; i++ {
+			status, headers := client.PostWithHeaders("/consultant-notes", map[string]string{"Idempotency-Key": forbiddenKey}, note, nil)
+			require.Equal(t, http.StatusForbidden, status)
+			require.Empty(t, headers.Get("Idempotent-Replayed"))
+		}
+	})
+
+	t.Run("Requests without a key are not deduplicated", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, client.Post("/events", body, nil))
+		require.Equal(t, http.StatusCreated, client.Post("/events", body, nil))
+		require.Equal(t, 3, countEvents(t))
+	})
+
+	t.Run("Notes are idempotent too", func(t *testing.T) {
+		noteKey := fmt.Sprintf("note-%d", time.Now().UnixNano())
+		consultant := NewTestClient(BaseURL)
+		consultant.SetT(t)
+		_, err := consultant.RegisterAndLogin("Consultant Idempotency", fmt.Sprintf("consultant_idempotency_%d@example.com", time.Now().UnixNano()), "password", "consultant")
+		require.NoError(t, err)
+
+		var profile map[string]interface{}
+		require.Equal(t, http.StatusOK, consultant.Put("/consultants/profile", map[string]interface{}{"description": "Retry tester"}, &profile))
+		consultantID := uint(profile["user_id"].(float64))
+
+		var invite map[string]interface{}
+		status, _ := client.PostWithHeaders(fmt.Sprintf("/consultants/%d/invite", consultantID), map[string]string{"Idempotency-Key": noteKey}, map[string]interface{}{"dog_id": dogID}, &invite)
+		require.Equal(t, http.StatusCreated, status)
+		var inviteRetry map[string]interface{}
+		status, headers := client.PostWithHeaders(fmt.Sprintf("/consultants/%d/invite", consultantID), map[string]string{"Idempotency-Key": noteKey}, map[string]interface{}{"dog_id": dogID}, &inviteRetry)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "true", headers.Get("Idempotent-Replayed"))
+		require.Equal(t, invite["token"], inviteRetry["token"])
+
+		require.Equal(t, http.StatusOK, consultant.Post(fmt.Sprintf("/invites/accept?token=%s", invite["token"].(string)), nil, nil))
+
+		noteBody := map[string]interface{}{"dog_id": dogID, "title": "Retry", "content": "Only once"}
+		var note, noteRetry map[string]interface{}
+		status, _ = consultant.PostWithHeaders("/consultant-notes", map[string]string{"Idempotency-Key": noteKey}, noteBody, &note)
+		require.Equal(t, http.StatusCreated, status)
+		status, headers = consultant.PostWithHeaders("/consultant-notes", map[string]string{"Idempotency-Key": noteKey}, noteBody, &noteRetry)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "true", headers.Get("Idempotent-Replayed"))
+		require.Equal(t, note["id"], noteRetry["id"])
+	})
+}
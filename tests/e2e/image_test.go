@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	_ "golang.org/x/image/webp"
+)
+
+// testGPS is a marker in the EXIF of testPhoto, standing in for coordinates
+const testGPS = "GPS 55.7558N 37.6173E"
+
+// testWebP is a 1x1 WebP carrying an EXIF chunk with testGPS
+var testWebP = []byte("RIFF\x52\x00\x00\x00WEBPVP8X\n\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00VP8L\r\x00\x00\x00/\x00\x00\x00\x10\a\x10\x11\x11\x88\x88\xfe\a\x00EXIF\x1d\x00\x00\x00MM\x00*\x00\x00\x00\x08" + testGPS + "\x00")
+
+// testPhoto is a width x height JPEG taken with the camera turned, as phones
+// store it: EXIF orientation 6 (rotate 90 clockwise) and an EXIF comment with testGPS
+func testPhoto(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	// TIFF header and one IFD: Orientation and ImageDescription
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00*\x00\x00\x00\x08")
+	binary.Write(&tiff, binary.BigEndian, uint16(2))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3, 0, 1, 6, 0})
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x010E, 2, 0, uint16(len(testGPS) + 1), 0, 38})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(testGPS + "\x00")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := encoded.Bytes()
+	photo := append([]byte{}, data[:2]...)
+	photo = append(append(photo, app1...), segment...)
+	return append(photo, data[2:]...)
+}
+
+// fetchImage follows an attachment or thumbnail path to its file and decodes it
+func fetchImage(t *testing.T, token, path string) (string, image.Config) {
+	resp := getAttachment(t, token, path)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	status, body := download(t, resp.Header.Get("Location"))
+	require.Equal(t, http.StatusOK, status)
+	config, _, err := image.DecodeConfig(strings.NewReader(body))
+	require.NoError(t, err)
+	return body, config
+}
+
+// thumbnailsBySize indexes the thumbnails of an attachment
+func thumbnailsBySize(t *testing.T, attachment map[string]interface{}) map[string]map[string]interface{} {
+	list, ok := attachment["thumbnails"].([]interface{})
+	require.True(t, ok, "image attachment should list thumbnails")
+	thumbnails := make(map[string]map[string]interface{})
+	for _, item := range list {
+		thumbnail := item.(map[string]interface{})
+		thumbnails[thumbnail["size"].(string)] = thumbnail
+	}
+	return thumbnails
+}
+
+func TestImages(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerToken, err := client.RegisterAndLogin("Owner Images", fmt.Sprintf("owner_images_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	otherToken, err := client.RegisterAndLogin("Other Images", fmt.Sprintf("other_images_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("PhotoDog", "Corgi", "2021-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	photo := testPhoto(t, 800, 400)
+	var event map[string]interface{}
+	status := postMultipart(t, ownerToken, "/events", map[string]interface{}{
+		"dog_id": dogID,
+		"type":   "walk",
+	}, []testFile{{"walk.jpg", photo}, {"invoice.pdf", testPDF}}, &event)
+	require.Equal(t, http.StatusCreated, status)
+	eventID := uint(event["id"].(float64))
+	attachments := event["attachments"].([]interface{})
+	require.Len(t, attachments, 2)
+	attachment := attachments[0].(map[string]interface{})
+
+	t.Run("Metadata Stripped", func(t *testing.T) {
+		require.Equal(t, "image/jpeg", attachment["content_type"])
+		require.NotEqual(t, float64(len(photo)), attachment["size"])
+
+		body, config := fetchImage(t, ownerToken, attachment["url"].(string))
+		require.Equal(t, float64(len(body)), attachment["size"])
+		require.NotContains(t, body, "Exif")
+		require.NotContains(t, body, testGPS)
+		// Turned upright, as the orientation is gone with the EXIF
+		require.Equal(t, 400, config.Width)
+		require.Equal(t, 800, config.Height)
+	})
+
+	t.Run("Thumbnails", func(t *testing.T) {
+		thumbnails := thumbnailsBySize(t, attachment)
+		require.Len(t, thumbnails, 3)
+		expected := map[string][2]int{"small": {80, 160}, "medium": {240, 480}, "large": {400, 800}} // Never scaled up
+		for size, dimensions := range expected {
+			thumbnail := thumbnails[size]
+			require.Equal(t, float64(dimensions[0]), thumbnail["width"], size)
+			require.Equal(t, float64(dimensions[1]), thumbnail["height"], size)
+			require.Equal(t, "image/jpeg", thumbnail["content_type"])
+			require.Equal(t, fmt.Sprintf("/api/v1/attachments/%.0f/thumbnails/%s", attachment["id"], size), thumbnail["url"])
+			require.NotContains(t, thumbnail, "key")
+
+			body, config := fetchImage(t, ownerToken, thumbnail["url"].(string))
+			require.NotContains(t, body, testGPS)
+			require.Equal(t, dimensions[0], config.Width)
+			require.Equal(t, dimensions[1], config.Height)
+		}
+
+		invoice := attachments[1].(map[string]interface{})
+		require.NotContains(t, invoice, "thumbnails")
+
+		var fetched map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &fetched))
+		require.Equal(t, event["attachments"], fetched["attachments"])
+	})
+
+	t.Run("Thumbnail Access", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/attachments/%.0f/thumbnails/small", attachment["id"])
+		require.Equal(t, http.StatusNotFound, getAttachment(t, otherToken, path).StatusCode)
+		require.Equal(t, http.StatusUnauthorized, getAttachment(t, "", path).StatusCode)
+		require.Equal(t, http.StatusUnauthorized, getAttachment(t, "", path+"?access_token="+ownerToken).StatusCode)
+
+		var link map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(strings.TrimPrefix(path, "/api/v1")+"/link", &link))
+		require.Contains(t, link["url"], "signature=")
+
+		path = fmt.Sprintf("/api/v1/attachments/%.0f/thumbnails/huge", attachment["id"])
+		require.Equal(t, http.StatusNotFound, getAttachment(t, ownerToken, path).StatusCode)
+		path = fmt.Sprintf("/api/v1/attachments/%.0f/thumbnails/small", attachments[1].(map[string]interface{})["id"])
+		require.Equal(t, http.StatusNotFound, getAttachment(t, ownerToken, path).StatusCode)
+	})
+
+	t.Run("Comment With WebP", func(t *testing.T) {
+		var comment map[string]interface{}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/comments", eventID), map[string]interface{}{
+			"content": "sticker",
+		}, []testFile{{"sticker.webp", testWebP}}, &comment)
+		require.Equal(t, http.StatusCreated, status)
+
+		attachment := comment["attachments"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "image/webp", attachment["content_type"])
+		body, config := fetchImage(t, ownerToken, attachment["url"].(string))
+		require.NotContains(t, body, testGPS)
+		require.Equal(t, 1, config.Width)
+		require.Equal(t, float64(len(body)), attachment["size"])
+
+		thumbnails := thumbnailsBySize(t, attachment)
+		require.Len(t, thumbnails, 3)
+		// Transparent images get PNG thumbnails
+		require.Equal(t, "image/png", thumbnails["small"]["content_type"])
+
+		var fetched map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/event-comments/%.0f", comment["id"]), &fetched))
+		require.Equal(t, comment["attachments"], fetched["attachments"])
+	})
+
+	t.Run("Corrupt Image Rejected", func(t *testing.T) {
+		corrupt := append(append([]byte{}, photo[:200]...), bytes.Repeat([]byte{0}, 200)...)
+		var resp map[string]interface{}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/attachments", eventID), nil, []testFile{{"broken.jpg", corrupt}}, &resp)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Contains(t, resp["error"], "broken.jpg")
+	})
+
+	t.Run("Resumable Upload", func(t *testing.T) {
+		location := createUpload(t, ownerToken, "walk.jpg", len(photo))
+		status, _ := tusRequest(t, ownerToken, "PATCH", location, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": "0",
+		}, photo)
+		require.Equal(t, http.StatusNoContent, status)
+
+		// The length of the upload is what was sent, the attachment keeps the stored file
+		status, headers := tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, strconv.Itoa(len(photo)), headers.Get("Upload-Offset"))
+
+		var added []map[string]interface{}
+		require.Equal(t, http.StatusCreated, attachUploads(t, ownerToken, eventID, []string{uploadID(location)}, &added))
+		require.Len(t, added, 1)
+		body, config := fetchImage(t, ownerToken, added[0]["url"].(string))
+		require.NotContains(t, body, testGPS)
+		require.Equal(t, 400, config.Width)
+		require.Equal(t, float64(len(body)), added[0]["size"])
+
+		thumbnails := thumbnailsBySize(t, added[0])
+		require.Len(t, thumbnails, 3)
+		_, config = fetchImage(t, ownerToken, thumbnails["medium"]["url"].(string))
+		require.Equal(t, 240, config.Width)
+	})
+}
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobs(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerEmail := fmt.Sprintf("owner_jobs_%d@example.com", time.Now().UnixNano())
+	_, err := client.RegisterAndLogin("Owner Jobs", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+
+	t.Run("Non-admins are forbidden", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, client.Get("/admin/jobs", nil))
+		require.Equal(t, http.StatusForbidden, client.Get("/admin/jobs/1", nil))
+		require.Equal(t, http.StatusForbidden, client.Post("/admin/jobs/1/retry", nil, nil))
+	})
+
+	// Admins cannot register through the API, so the rest needs an existing account
+	adminEmail, adminPassword := os.Getenv("E2E_ADMIN_EMAIL"), os.Getenv("E2E_ADMIN_PASSWORD")
+	if adminEmail == "" {
+		t.Skip("E2E_ADMIN_EMAIL and E2E_ADMIN_PASSWORD not set")
+	}
+
+	admin := NewTestClient(BaseURL)
+	admin.SetT(t)
+	var login map[string]interface{}
+	require.Equal(t, http.StatusOK, admin.Post("/auth/login", map[string]string{"email": adminEmail, "password": adminPassword}, &login))
+	admin.SetToken(login["token"].(string))
+
+	// A failing webhook delivery leaves a pending job with the error
+	dogID, err := client.CreateDog("JobDog", "Beagle", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+	receiver := newWebhookReceiver(t)
+	receiver.status.Store(http.StatusServiceUnavailable)
+
+	var webhook map[string]interface{}
+	status := client.Post("/webhooks", map[string]interface{}{"url": receiver.URL, "event_types": []string{"event.created"}}, &webhook)
+	require.Equal(t, http.StatusCreated, status)
+	defer client.Delete(fmt.Sprintf("/webhooks/%.0f", webhook["id"]))
+
+	status = client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk"}, nil)
+	require.Equal(t, http.StatusCreated, status)
+	receiver.next(t)
+
+	var deliveries map[string]interface{}
+	require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/webhooks/%.0f/deliveries", webhook["id"]), &deliveries))
+	require.Len(t, deliveries["deliveries"], 1)
+	deliveryID := deliveries["deliveries"].([]interface{})[0].(map[string]interface{})["id"]
+
+	var job map[string]interface{}
+	t.Run("Failed job is rescheduled", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			var resp map[string]interface{}
+			status := admin.Get("/admin/jobs?type=webhook.deliver&status=pending&page_size=100", &resp)
+			require.Equal(t, http.StatusOK, status)
+			for _, raw := range resp["jobs"].([]interface{}) {
+				j := raw.(map[string]interface{})
+				if j["payload"].(map[string]interface{})["delivery_id"] == deliveryID && j["attempts"] == float64(1) {
+					job = j
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 100*time.Millisecond)
+
+		require.Contains(t, job["last_error"], "503")
+		runAt, err := time.Parse(time.RFC3339Nano, job["run_at"].(string))
+		require.NoError(t, err)
+		require.True(t, runAt.After(time.Now()), "retry must be scheduled with backoff")
+	})
+
+	t.Run("Get and retry", func(t *testing.T) {
+		require.NotNil(t, job)
+		jobPath := fmt.Sprintf("/admin/jobs/%.0f", job["id"])
+
+		var got map[string]interface{}
+		require.Equal(t, http.StatusOK, admin.Get(jobPath, &got))
+		require.Equal(t, job["id"], got["id"])
+		require.Equal(t, "webhook.deliver", got["type"])
+
+		// Only dead jobs can be retried
+		require.Equal(t, http.StatusConflict, admin.Post(jobPath+"/retry", nil, nil))
+		require.Equal(t, http.StatusNotFound, admin.Post("/admin/jobs/999999999/retry", nil, nil))
+		require.Equal(t, http.StatusNotFound, admin.Get("/admin/jobs/999999999", nil))
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, admin.Get("/admin/jobs?status=exploded", nil))
+	})
+}
@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEICAR is the EICAR anti-virus test file, reported by every scanner
+const testEICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// scanStatus waits for an attachment to leave quarantine and returns its status.
+// Downloads are refused while it is scanned.
+func scanStatus(t *testing.T, client *TestClient, token string, eventID uint, attachmentID float64) string {
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var event map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &event))
+		for _, item := range event["attachments"].([]interface{}) {
+			attachment := item.(map[string]interface{})
+			if attachment["id"] != attachmentID {
+				continue
+			}
+			if attachment["scan_status"] != "quarantine" {
+				return attachment["scan_status"].(string)
+			}
+			resp := getAttachment(t, token, attachment["url"].(string))
+			require.Equal(t, http.StatusLocked, resp.StatusCode)
+			require.NotEmpty(t, resp.Header.Get("Retry-After"))
+		}
+		require.True(t, time.Now().Before(deadline), "attachment %.0f is still quarantined", attachmentID)
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func TestMalwareScan(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerToken, err := client.RegisterAndLogin("Owner Scan", fmt.Sprintf("owner_scan_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("ScanDog", "Poodle", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	var event map[string]interface{}
+	status := postMultipart(t, ownerToken, "/events", map[string]interface{}{
+		"dog_id": dogID,
+		"type":   "vet",
+	}, []testFile{{"invoice.pdf", testPDF}, {"eicar.txt", []byte(testEICAR)}}, &event)
+	require.Equal(t, http.StatusCreated, status)
+	eventID := uint(event["id"].(float64))
+	attachments := event["attachments"].([]interface{})
+	require.Len(t, attachments, 2)
+
+	t.Run("Clean File Downloadable", func(t *testing.T) {
+		invoice := attachments[0].(map[string]interface{})
+		require.Contains(t, []interface{}{"clean", "quarantine"}, invoice["scan_status"])
+		require.Equal(t, "clean", scanStatus(t, client, ownerToken, eventID, invoice["id"].(float64)))
+
+		resp, body := fetch(t, ownerToken, invoice["url"].(string))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, string(testPDF), body)
+	})
+
+	t.Run("Infected File Blocked", func(t *testing.T) {
+		eicar := attachments[1].(map[string]interface{})
+		switch scanStatus(t, client, ownerToken, eventID, eicar["id"].(float64)) {
+		case "clean":
+			t.Skip("the server runs without a malware scanner")
+		case "infected":
+			resp := getAttachment(t, ownerToken, eicar["url"].(string))
+			require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+			var fetched map[string]interface{}
+			require.Equal(t, http.StatusOK, client.Get(fmt.Sprintf("/events/%d", eventID), &fetched))
+			infected := fetched["attachments"].([]interface{})[1].(map[string]interface{})
+			require.NotEmpty(t, infected["scan_threat"])
+			require.NotEmpty(t, infected["scanned_at"])
+		default:
+			t.Fatalf("unexpected scan status %v", eicar["scan_status"])
+		}
+	})
+}
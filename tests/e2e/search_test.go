@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	email := fmt.Sprintf("search_owner_%d@example.com", time.Now().UnixNano())
+	_, err := client.RegisterAndLogin("Search Owner", email, "password", "owner")
+	require.NoError(t, err)
+
+	dogID, err := client.CreateDog("Seeker", "Beagle", "2021-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	events := []map[string]interface{}{
+		{"dog_id": dogID, "type": "walk", "note": "Long walk in the park", "at": "2025-01-01T08:00:00Z"},
+		{"dog_id": dogID, "type": "walk", "note": "Short walk around the block", "at": "2025-01-01T12:00:00Z"},
+		{"dog_id": dogID, "type": "feed", "note": "Tried <b>new</b> food", "at": "2025-01-01T18:00:00Z"},
+	}
+	for _, e := range events {
+		status := client.Post("/events", e, nil)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	t.Run("All Words Must Match", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d&search=park+walk", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		found := resp["events"].([]interface{})
+		require.Len(t, found, 1)
+		require.Equal(t, "Long walk in the park", found[0].(map[string]interface{})["note"])
+	})
+
+	t.Run("Matches Are Highlighted", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d&search=walk", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		found := resp["events"].([]interface{})
+		require.Len(t, found, 2)
+		for _, e := range found {
+			require.Contains(t, e.(map[string]interface{})["highlight"], "<mark>walk</mark>")
+		}
+	})
+
+	t.Run("Highlight Escapes HTML", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d&search=food", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		found := resp["events"].([]interface{})
+		require.Len(t, found, 1)
+		highlight := found[0].(map[string]interface{})["highlight"].(string)
+		require.Contains(t, highlight, "&lt;b&gt;")
+		require.Contains(t, highlight, "<mark>food</mark>")
+	})
+
+	t.Run("Matches Dog Name", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d&search=seeker", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, resp["events"].([]interface{}), 3)
+
+		// Note matches still apply alongside the name
+		status = client.Get(fmt.Sprintf("/events?dog_id=%d&search=park", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, resp["events"].([]interface{}), 1)
+	})
+
+	t.Run("No Highlight Without Search", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/events?dog_id=%d", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		for _, e := range resp["events"].([]interface{}) {
+			require.NotContains(t, e.(map[string]interface{}), "highlight")
+		}
+	})
+}
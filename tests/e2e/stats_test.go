@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDogStats(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	email := fmt.Sprintf("stats_owner_%d@example.com", time.Now().UnixNano())
+	_, err := client.RegisterAndLogin("Stats Owner", email, "password", "owner")
+	require.NoError(t, err)
+
+	dogID, err := client.CreateDog("StatsDog", "Beagle", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	// Walks on Mar 3, 4, 5 and 7 (longest streak: 3 days), two feedings on Mar 4
+	events := []map[string]interface{}{
+		{"dog_id": dogID, "type": "walk", "at": "2025-03-03T08:00:00Z", "duration_minutes": 30},
+		{"dog_id": dogID, "type": "walk", "at": "2025-03-04T08:00:00Z", "duration_minutes": 45},
+		{"dog_id": dogID, "type": "walk", "at": "2025-03-05T08:00:00Z", "duration_minutes": 20},
+		{"dog_id": dogID, "type": "walk", "at": "2025-03-07T08:00:00Z", "duration_minutes": 60},
+		{"dog_id": dogID, "type": "feed", "at": "2025-03-04T09:00:00Z", "amount_grams": 150},
+		{"dog_id": dogID, "type": "feed", "at": "2025-03-04T19:00:00Z", "amount_grams": 200},
+	}
+	for _, e := range events {
+		status := client.Post("/events", e, nil)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	rangeQuery := "from=2025-03-01T00:00:00Z&to=2025-04-01T00:00:00Z"
+
+	t.Run("Group by day", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/dogs/%d/stats?group_by=day&%s", dogID, rangeQuery), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		buckets := resp["buckets"].([]interface{})
+		require.Len(t, buckets, 4)
+
+		march4 := buckets[1].(map[string]interface{})
+		require.Equal(t, "2025-03-04", march4["period"])
+		require.Equal(t, float64(3), march4["total"])
+		counts := march4["counts"].(map[string]interface{})
+		require.Equal(t, float64(1), counts["walk"])
+		require.Equal(t, float64(2), counts["feed"])
+	})
+
+	t.Run("Group by week", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/dogs/%d/stats?group_by=week&%s", dogID, rangeQuery), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		// Mar 3, 2025 is a Monday, so all events fall into one week
+		buckets := resp["buckets"].([]interface{})
+		require.Len(t, buckets, 1)
+		require.Equal(t, "2025-03-03", buckets[0].(map[string]interface{})["period"])
+		require.Equal(t, float64(6), buckets[0].(map[string]interface{})["total"])
+	})
+
+	t.Run("Totals and streaks", func(t *testing.T) {
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/dogs/%d/stats?group_by=month&%s", dogID, rangeQuery), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		totals := map[string]map[string]interface{}{}
+		for _, item := range resp["totals"].([]interface{}) {
+			row := item.(map[string]interface{})
+			totals[row["type"].(string)] = row
+		}
+		require.Equal(t, float64(155), totals["walk"]["duration_minutes"])
+		require.Equal(t, float64(350), totals["feed"]["amount_grams"])
+
+		streaks := map[string]map[string]interface{}{}
+		for _, item := range resp["streaks"].([]interface{}) {
+			row := item.(map[string]interface{})
+			streaks[row["type"].(string)] = row
+		}
+		require.Equal(t, float64(3), streaks["walk"]["days"])
+		require.Equal(t, "2025-03-03", streaks["walk"]["start"])
+		require.Equal(t, "2025-03-05", streaks["walk"]["end"])
+		require.Equal(t, float64(1), streaks["feed"]["days"])
+	})
+
+	t.Run("Other owner cannot see stats", func(t *testing.T) {
+		other := NewTestClient(BaseURL)
+		other.SetT(t)
+		_, err := other.RegisterAndLogin("Other Owner", fmt.Sprintf("stats_other_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+
+		status := other.Get(fmt.Sprintf("/dogs/%d/stats", dogID), nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
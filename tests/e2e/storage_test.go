@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// storedPath returns the path of the stored file an attachment redirects to,
+// without the signature
+func storedPath(t *testing.T, token, attachmentURL string) string {
+	resp := getAttachment(t, token, attachmentURL)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	return strings.Split(resp.Header.Get("Location"), "?")[0]
+}
+
+func TestStorageDeduplication(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerToken, err := client.RegisterAndLogin("Owner Storage", fmt.Sprintf("owner_storage_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("StorageDog", "Dachshund", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	// Content no other test uploads, so that the first copy is stored anew
+	content := append(append([]byte{}, testPDF...), fmt.Sprintf("%% %d\n", time.Now().UnixNano())...)
+	createEvent := func(filename string, content []byte) (uint, map[string]interface{}) {
+		var event map[string]interface{}
+		status := postMultipart(t, ownerToken, "/events", map[string]interface{}{
+			"dog_id": dogID,
+			"type":   "vet",
+		}, []testFile{{filename, content}}, &event)
+		require.Equal(t, http.StatusCreated, status)
+		return uint(event["id"].(float64)), event["attachments"].([]interface{})[0].(map[string]interface{})
+	}
+
+	firstEventID, first := createEvent("invoice.pdf", content)
+	secondEventID, second := createEvent("invoice copy.pdf", content)
+	_, other := createEvent("other.pdf", append(append([]byte{}, content...), "%\n"...))
+
+	t.Run("Identical Content Shares One File", func(t *testing.T) {
+		require.Equal(t, first["sha256"], second["sha256"])
+		require.NotEqual(t, first["id"], second["id"])
+		require.Equal(t, storedPath(t, ownerToken, first["url"].(string)), storedPath(t, ownerToken, second["url"].(string)))
+		require.NotEqual(t, storedPath(t, ownerToken, first["url"].(string)), storedPath(t, ownerToken, other["url"].(string)))
+
+		// Each attachment keeps its own name
+		resp, body := fetch(t, ownerToken, second["url"].(string))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, string(content), body)
+		require.Contains(t, resp.Header.Get("Content-Disposition"), "invoice copy.pdf")
+	})
+
+	t.Run("Shared File Outlives Deleted Event", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/events/%d", firstEventID)))
+
+		resp, body := fetch(t, ownerToken, second["url"].(string))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, string(content), body)
+	})
+
+	t.Run("Shared File Outlives Deleted Attachment", func(t *testing.T) {
+		var added []map[string]interface{}
+		status := postMultipart(t, ownerToken, fmt.Sprintf("/events/%d/attachments", secondEventID), nil,
+			[]testFile{{"duplicate.pdf", content}}, &added)
+		require.Equal(t, http.StatusCreated, status)
+		require.Len(t, added, 1)
+		require.Equal(t, storedPath(t, ownerToken, second["url"].(string)), storedPath(t, ownerToken, added[0]["url"].(string)))
+
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/attachments/%.0f", added[0]["id"])))
+		resp, body := fetch(t, ownerToken, second["url"].(string))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, string(content), body)
+	})
+
+	t.Run("Unshared File Deleted With Attachment", func(t *testing.T) {
+		resp := getAttachment(t, ownerToken, other["url"].(string))
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location := resp.Header.Get("Location")
+
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/attachments/%.0f", other["id"])))
+		status, _ := download(t, location)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,199 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// sseMessage is one parsed Server-Sent Event
+type sseMessage struct {
+	ID    uint64
+	Event string
+	Data  map[string]interface{}
+}
+
+// openSSE connects to a stream and returns a channel of parsed messages
+func openSSE(t *testing.T, path string, headers map[string]string) (<-chan sseMessage, func()) {
+	req, err := http.NewRequest("GET", BaseURL+path, nil)
+	require.NoError(t, err)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	messages := make(chan sseMessage, 16)
+	go func() {
+		defer close(messages)
+		scanner := bufio.NewScanner(resp.Body)
+		var msg sseMessage
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if msg.Event != "" {
+					messages <- msg
+				}
+				msg = sseMessage{}
+			case strings.HasPrefix(line, "id: "):
+				msg.ID, _ = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+			case strings.HasPrefix(line, "event: "):
+				msg.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg.Data)
+			}
+		}
+	}()
+
+	return messages, func() { resp.Body.Close() }
+}
+
+func nextSSE(t *testing.T, messages <-chan sseMessage) sseMessage {
+	select {
+	case msg, ok := <-messages:
+		require.True(t, ok, "stream closed")
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stream message")
+		return sseMessage{}
+	}
+}
+
+func TestDogStream(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerToken, err := client.RegisterAndLogin("Owner Stream", fmt.Sprintf("owner_stream_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	dogID, err := client.CreateDog("StreamDog", "Dalmatian", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	streamPath := fmt.Sprintf("/dogs/%d/stream", dogID)
+	auth := map[string]string{"Authorization": "Bearer " + ownerToken}
+
+	var firstID uint64
+	var eventID float64
+
+	t.Run("SSE delivers created events and comments", func(t *testing.T) {
+		messages, stop := openSSE(t, streamPath, auth)
+		defer stop()
+
+		var event map[string]interface{}
+		require.Equal(t, http.StatusCreated, client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk"}, &event))
+		eventID = event["id"].(float64)
+
+		msg := nextSSE(t, messages)
+		require.Equal(t, "event.created", msg.Event)
+		require.Equal(t, float64(dogID), msg.Data["dog_id"])
+		require.Equal(t, eventID, msg.Data["data"].(map[string]interface{})["id"])
+		firstID = msg.ID
+
+		require.Equal(t, http.StatusCreated, client.Post(fmt.Sprintf("/events/%.0f/comments", eventID), map[string]interface{}{"event_id": eventID, "content": "Live"}, nil))
+		msg = nextSSE(t, messages)
+		require.Equal(t, "comment.created", msg.Event)
+		require.Greater(t, msg.ID, firstID)
+	})
+
+	t.Run("Resume with Last-Event-ID replays missed messages", func(t *testing.T) {
+		messages, stop := openSSE(t, streamPath, map[string]string{
+			"Authorization": "Bearer " + ownerToken,
+			"Last-Event-ID": strconv.FormatUint(firstID, 10),
+		})
+		defer stop()
+
+		msg := nextSSE(t, messages)
+		require.Equal(t, "comment.created", msg.Event)
+	})
+
+	t.Run("Token in query string", func(t *testing.T) {
+		messages, stop := openSSE(t, streamPath+"?access_token="+url.QueryEscape(ownerToken), nil)
+		defer stop()
+
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/events/%.0f", eventID)))
+		msg := nextSSE(t, messages)
+		require.Equal(t, "event.deleted", msg.Event)
+	})
+
+	t.Run("WebSocket delivers the same messages", func(t *testing.T) {
+		wsURL := strings.Replace(BaseURL, "http", "ws", 1) + fmt.Sprintf("/dogs/%d/ws?access_token=%s", dogID, url.QueryEscape(ownerToken))
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		defer conn.Close()
+
+		require.Equal(t, http.StatusCreated, client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "feed"}, nil))
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, "event.created", msg["type"])
+	})
+
+	t.Run("Notes reach only their author", func(t *testing.T) {
+		consultant := NewTestClient(BaseURL)
+		consultant.SetT(t)
+		consultantToken, err := consultant.RegisterAndLogin("Consultant Stream", fmt.Sprintf("consultant_stream_%d@example.com", time.Now().UnixNano()), "password", "consultant")
+		require.NoError(t, err)
+		var profile map[string]interface{}
+		require.Equal(t, http.StatusOK, consultant.Put("/consultants/profile", map[string]interface{}{"description": "Stream"}, &profile))
+		var invite map[string]interface{}
+		require.Equal(t, http.StatusCreated, client.Post(fmt.Sprintf("/consultants/%.0f/invite", profile["user_id"].(float64)), map[string]interface{}{"dog_id": dogID}, &invite))
+		require.Equal(t, http.StatusOK, consultant.Post(fmt.Sprintf("/invites/accept?token=%s", invite["token"].(string)), nil, nil))
+
+		ownerMessages, stopOwner := openSSE(t, streamPath, auth)
+		defer stopOwner()
+		consultantMessages, stopConsultant := openSSE(t, streamPath, map[string]string{"Authorization": "Bearer " + consultantToken})
+		defer stopConsultant()
+
+		require.Equal(t, http.StatusCreated, consultant.Post("/consultant-notes", map[string]interface{}{"dog_id": dogID, "title": "Live", "content": "Private"}, nil))
+		require.Equal(t, "note.created", nextSSE(t, consultantMessages).Event)
+
+		// The owner's next message is the following event, the note was skipped
+		require.Equal(t, http.StatusCreated, client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk"}, nil))
+		require.Equal(t, "event.created", nextSSE(t, ownerMessages).Event)
+	})
+
+	t.Run("Stream ends once access is lost", func(t *testing.T) {
+		// Needs a short STREAM_ACCESS_CHECK_SECONDS, see docker-compose.yml
+		goneDogID, err := client.CreateDog("GoneDog", "Dalmatian", "2020-01-01T00:00:00Z")
+		require.NoError(t, err)
+		messages, stop := openSSE(t, fmt.Sprintf("/dogs/%d/stream", goneDogID), auth)
+		defer stop()
+
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/dogs/%d", goneDogID)))
+		select {
+		case _, open := <-messages:
+			require.False(t, open, "expected the stream to end")
+		case <-time.After(10 * time.Second):
+			t.Fatal("stream stayed open after the dog was deleted")
+		}
+	})
+
+	t.Run("Stranger cannot subscribe", func(t *testing.T) {
+		stranger := NewTestClient(BaseURL)
+		stranger.SetT(t)
+		strangerToken, err := stranger.RegisterAndLogin("Stranger Stream", fmt.Sprintf("stranger_stream_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", BaseURL+streamPath, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+strangerToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,203 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	owner := NewTestClient(BaseURL)
+	owner.SetT(t)
+	_, err := owner.RegisterAndLogin("Owner Sync", fmt.Sprintf("owner_sync_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	dogID, err := owner.CreateDog("SyncDog", "Collie", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	pull := func(t *testing.T, client *TestClient, token string) map[string]interface{} {
+		path := "/sync"
+		if token != "" {
+			path += "?since=" + url.QueryEscape(token)
+		}
+		var resp map[string]interface{}
+		require.Equal(t, http.StatusOK, client.Get(path, &resp))
+		return resp
+	}
+	ids := func(resp map[string]interface{}, kind string) map[float64]bool {
+		out := map[float64]bool{}
+		for _, raw := range resp[kind].([]interface{}) {
+			item := raw.(map[string]interface{})
+			if id, ok := item["id"]; ok {
+				out[id.(float64)] = true
+			} else {
+				out[item["entity_id"].(float64)] = true
+			}
+		}
+		return out
+	}
+
+	var token string
+	eventClientID := uuid.NewString()
+	var eventID float64
+
+	t.Run("Full snapshot", func(t *testing.T) {
+		resp := pull(t, owner, "")
+		require.Equal(t, true, resp["full"])
+		require.True(t, ids(resp, "dogs")[float64(dogID)])
+		require.NotEmpty(t, resp["token"])
+		token = resp["token"].(string)
+	})
+
+	t.Run("Push offline event and comment", func(t *testing.T) {
+		commentClientID := uuid.NewString()
+		body := map[string]interface{}{
+			"events": []map[string]interface{}{
+				{"client_id": eventClientID, "dog_id": dogID, "type": "walk", "at": "2025-05-01T08:00:00Z"},
+				{"client_id": uuid.NewString(), "dog_id": dogID}, // missing type
+			},
+			"comments": []map[string]interface{}{
+				{"client_id": commentClientID, "event_client_id": eventClientID, "content": "Written offline"},
+			},
+		}
+
+		var resp map[string]interface{}
+		require.Equal(t, http.StatusOK, owner.Post("/sync", body, &resp))
+
+		events := resp["events"].([]interface{})
+		require.Len(t, events, 2)
+		created := events[0].(map[string]interface{})
+		require.Equal(t, "created", created["status"])
+		require.Equal(t, eventClientID, created["client_id"])
+		eventID = created["id"].(float64)
+		require.Equal(t, "rejected", events[1].(map[string]interface{})["status"])
+
+		comments := resp["comments"].([]interface{})
+		require.Len(t, comments, 1)
+		require.Equal(t, "created", comments[0].(map[string]interface{})["status"])
+
+		// Retrying the same push does not duplicate anything
+		var retry map[string]interface{}
+		require.Equal(t, http.StatusOK, owner.Post("/sync", body, &retry))
+		again := retry["events"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "exists", again["status"])
+		require.Equal(t, eventID, again["id"])
+		require.Equal(t, "exists", retry["comments"].([]interface{})[0].(map[string]interface{})["status"])
+	})
+
+	t.Run("Delta contains new records", func(t *testing.T) {
+		resp := pull(t, owner, token)
+		require.Equal(t, false, resp["full"])
+		require.True(t, ids(resp, "events")[eventID])
+		require.Len(t, resp["comments"].([]interface{}), 1)
+		token = resp["token"].(string)
+	})
+
+	t.Run("Deletes produce tombstones", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, owner.Delete(fmt.Sprintf("/events/%.0f", eventID)))
+
+		resp := pull(t, owner, token)
+		tombstones := resp["tombstones"].([]interface{})
+		require.Len(t, tombstones, 1)
+		tombstone := tombstones[0].(map[string]interface{})
+		require.Equal(t, "event", tombstone["entity_type"])
+		require.Equal(t, eventID, tombstone["entity_id"])
+		require.Equal(t, eventClientID, tombstone["client_id"])
+	})
+
+	t.Run("Comment on a deleted event is a conflict", func(t *testing.T) {
+		var resp map[string]interface{}
+		require.Equal(t, http.StatusOK, owner.Post("/sync", map[string]interface{}{
+			"comments": []map[string]interface{}{
+				{"client_id": uuid.NewString(), "event_client_id": eventClientID, "content": "Too late"},
+				{"client_id": uuid.NewString(), "event_id": eventID, "content": "Too late by id"},
+			},
+		}, &resp))
+		for _, raw := range resp["comments"].([]interface{}) {
+			result := raw.(map[string]interface{})
+			require.Equal(t, "conflict", result["status"])
+			require.Equal(t, "event was deleted", result["reason"])
+		}
+	})
+
+	t.Run("Consultant gets full data of newly shared dog", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, owner.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "feed", "at": "2025-05-02T08:00:00Z"}, nil))
+
+		consultant := NewTestClient(BaseURL)
+		consultant.SetT(t)
+		_, err := consultant.RegisterAndLogin("Consultant Sync", fmt.Sprintf("consultant_sync_%d@example.com", time.Now().UnixNano()), "password", "consultant")
+		require.NoError(t, err)
+		var profile map[string]interface{}
+		require.Equal(t, http.StatusOK, consultant.Put("/consultants/profile", map[string]interface{}{"description": "Sync"}, &profile))
+		share := func(dog uint) {
+			var invite map[string]interface{}
+			require.Equal(t, http.StatusCreated, owner.Post(fmt.Sprintf("/consultants/%.0f/invite", profile["user_id"].(float64)), map[string]interface{}{"dog_id": dog}, &invite))
+			require.Equal(t, http.StatusOK, consultant.Post(fmt.Sprintf("/invites/accept?token=%s", invite["token"].(string)), nil, nil))
+		}
+
+		// The consultant already syncs another dog of the owner
+		otherDogID, err := owner.CreateDog("OtherSyncDog", "Collie", "2020-01-01T00:00:00Z")
+		require.NoError(t, err)
+		share(otherDogID)
+		consultantToken := pull(t, consultant, "")["token"].(string)
+
+		share(dogID)
+		resp := pull(t, consultant, consultantToken)
+		require.True(t, ids(resp, "dogs")[float64(dogID)])
+		require.Len(t, resp["events"].([]interface{}), 1)
+	})
+
+	t.Run("Stranger sees nothing and cannot push", func(t *testing.T) {
+		stranger := NewTestClient(BaseURL)
+		stranger.SetT(t)
+		_, err := stranger.RegisterAndLogin("Stranger Sync", fmt.Sprintf("stranger_sync_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+
+		resp := pull(t, stranger, "")
+		require.False(t, ids(resp, "dogs")[float64(dogID)])
+
+		var push map[string]interface{}
+		require.Equal(t, http.StatusOK, stranger.Post("/sync", map[string]interface{}{
+			"events": []map[string]interface{}{{"client_id": uuid.NewString(), "dog_id": dogID, "type": "walk"}},
+		}, &push))
+		require.Equal(t, "rejected", push["events"].([]interface{})[0].(map[string]interface{})["status"])
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, owner.Get("/sync?since=garbage", nil))
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		paged := NewTestClient(BaseURL)
+		paged.SetT(t)
+		_, err := paged.RegisterAndLogin("Owner Sync Pages", fmt.Sprintf("owner_sync_pages_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+		pagedDogID, err := paged.CreateDog("PagedDog", "Collie", "2020-01-01T00:00:00Z")
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			require.Equal(t, http.StatusCreated, paged.Post("/events", map[string]interface{}{"dog_id": pagedDogID, "type": "walk"}, nil))
+		}
+
+		var resp map[string]interface{}
+		require.Equal(t, http.StatusOK, paged.Get("/sync?limit=3", &resp))
+		require.Equal(t, true, resp["has_more"])
+		require.Len(t, resp["dogs"], 1)
+		require.Len(t, resp["events"], 2)
+		events := ids(resp, "events")
+
+		require.Equal(t, http.StatusOK, paged.Get("/sync?limit=3&since="+url.QueryEscape(resp["next_since"].(string)), &resp))
+		require.Equal(t, false, resp["has_more"])
+		require.Equal(t, true, resp["full"])
+		require.Len(t, resp["dogs"], 0)
+		require.Len(t, resp["events"], 1)
+		for id := range ids(resp, "events") {
+			require.False(t, events[id], "event %v sent twice", id)
+		}
+
+		require.Equal(t, http.StatusBadRequest, paged.Get("/sync?limit=1001", nil))
+	})
+}
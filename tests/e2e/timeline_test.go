@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDogTimeline(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	// Setup: owner with dog and two events
+	ownerEmail := fmt.Sprintf("owner_timeline_%d@example.com", time.Now().UnixNano())
+	ownerToken, err := client.RegisterAndLogin("Owner Timeline", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+
+	dogID, err := client.CreateDog("TimelineDog", "Husky", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	var eventResp map[string]interface{}
+	status := client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk", "note": "First walk", "at": "2025-02-01T08:00:00Z"}, &eventResp)
+	require.Equal(t, http.StatusCreated, status)
+	eventID := uint(eventResp["id"].(float64))
+
+	status = client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "feed", "note": "Breakfast", "at": "2025-02-01T09:00:00Z"}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	// Setup: consultant with access
+	consultantEmail := fmt.Sprintf("consultant_timeline_%d@example.com", time.Now().UnixNano())
+	consultantToken, err := client.RegisterAndLogin("Consultant Timeline", consultantEmail, "password", "consultant")
+	require.NoError(t, err)
+
+	var profileResp map[string]interface{}
+	status = client.Put("/consultants/profile", map[string]interface{}{"description": "Timeline tester"}, &profileResp)
+	require.Equal(t, http.StatusOK, status)
+	consultantID := uint(profileResp["user_id"].(float64))
+
+	client.SetToken(ownerToken)
+	var inviteResp map[string]interface{}
+	status = client.Post(fmt.Sprintf("/consultants/%d/invite", consultantID), map[string]interface{}{"dog_id": dogID}, &inviteResp)
+	require.Equal(t, http.StatusCreated, status)
+
+	client.SetToken(consultantToken)
+	status = client.Post(fmt.Sprintf("/invites/accept?token=%s", inviteResp["token"].(string)), nil, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// Consultant comments on the first event and writes a note
+	status = client.Post(fmt.Sprintf("/events/%d/comments", eventID), map[string]interface{}{"event_id": eventID, "content": "Good pace"}, nil)
+	require.Equal(t, http.StatusCreated, status)
+	status = client.Post("/consultant-notes", map[string]interface{}{"dog_id": dogID, "title": "Assessment", "content": "Calm dog"}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	t.Run("Consultant sees events, comments and own notes", func(t *testing.T) {
+		client.SetToken(consultantToken)
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/dogs/%d/timeline", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		items := resp["items"].([]interface{})
+		require.Len(t, items, 4)
+
+		types := map[string]int{}
+		for _, raw := range items {
+			item := raw.(map[string]interface{})
+			types[item["type"].(string)]++
+			require.NotNil(t, item[item["type"].(string)], "item payload must match its type")
+		}
+		require.Equal(t, 2, types["event"])
+		require.Equal(t, 1, types["comment"])
+		require.Equal(t, 1, types["note"])
+	})
+
+	t.Run("Owner does not see consultant notes", func(t *testing.T) {
+		client.SetToken(ownerToken)
+		var resp map[string]interface{}
+		status := client.Get(fmt.Sprintf("/dogs/%d/timeline", dogID), &resp)
+		require.Equal(t, http.StatusOK, status)
+
+		items := resp["items"].([]interface{})
+		require.Len(t, items, 3)
+		for _, raw := range items {
+			require.NotEqual(t, "note", raw.(map[string]interface{})["type"])
+		}
+	})
+
+	t.Run("Cursor pagination walks the whole feed in order", func(t *testing.T) {
+		client.SetToken(ownerToken)
+
+		var seen []string
+		var lastAt time.Time
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			path := fmt.Sprintf("/dogs/%d/timeline?limit=2", dogID)
+			if cursor != "" {
+				path += "&cursor=" + url.QueryEscape(cursor)
+			}
+
+			var resp map[string]interface{}
+			status := client.Get(path, &resp)
+			require.Equal(t, http.StatusOK, status)
+
+			for _, raw := range resp["items"].([]interface{}) {
+				item := raw.(map[string]interface{})
+				at, err := time.Parse(time.RFC3339Nano, item["at"].(string))
+				require.NoError(t, err)
+				if !lastAt.IsZero() {
+					require.False(t, at.After(lastAt), "timeline must be newest first")
+				}
+				lastAt = at
+				seen = append(seen, fmt.Sprintf("%s:%.0f", item["type"], item["id"]))
+			}
+
+			if resp["has_more"] != true {
+				break
+			}
+			cursor = resp["next_cursor"].(string)
+		}
+
+		require.Len(t, seen, 3)
+		require.Equal(t, fmt.Sprintf("event:%d", eventID), seen[2])
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		client.SetToken(ownerToken)
+		status := client.Get(fmt.Sprintf("/dogs/%d/timeline?cursor=garbage", dogID), nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Stranger cannot see timeline", func(t *testing.T) {
+		stranger := NewTestClient(BaseURL)
+		stranger.SetT(t)
+		_, err := stranger.RegisterAndLogin("Stranger", fmt.Sprintf("stranger_timeline_%d@example.com", time.Now().UnixNano()), "password", "owner")
+		require.NoError(t, err)
+
+		status := stranger.Get(fmt.Sprintf("/dogs/%d/timeline", dogID), nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,255 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testMP4 is the ftyp box of an MP4 video followed by filler
+var testMP4 = append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), bytes.Repeat([]byte("video-frame-"), 30000)...)
+
+// tusRequest sends a tus 1.0 request and returns the status and response headers.
+// path is relative to BaseURL unless it is the absolute path of a Location header.
+func tusRequest(t *testing.T, token, method, path string, headers map[string]string, body []byte) (int, http.Header) {
+	if strings.HasPrefix(path, "/api/") {
+		path = strings.TrimSuffix(BaseURL, "/api/v1") + path
+	} else {
+		path = BaseURL + path
+	}
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		if value == "" {
+			req.Header.Del(name)
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, resp.Header
+}
+
+// createUpload starts an upload of length bytes and returns its Location
+func createUpload(t *testing.T, token, filename string, length int) string {
+	status, headers := tusRequest(t, token, "POST", "/uploads", map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte(filename)),
+	}, nil)
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "1.0.0", headers.Get("Tus-Resumable"))
+	require.NotEmpty(t, headers.Get("Upload-Expires"))
+	location := headers.Get("Location")
+	require.Regexp(t, `^/api/v1/uploads/[0-9a-f-]{36}$`, location)
+	return location
+}
+
+// uploadID returns the upload ID of a Location
+func uploadID(location string) string {
+	return location[strings.LastIndex(location, "/")+1:]
+}
+
+// attachUploads attaches completed uploads to an event
+func attachUploads(t *testing.T, token string, eventID uint, ids []string, result interface{}) int {
+	body, err := json.Marshal(map[string]interface{}{"upload_ids": ids})
+	require.NoError(t, err)
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/events/%d/attachments", BaseURL, eventID), bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if result != nil && len(respBody) > 0 {
+		require.NoError(t, json.Unmarshal(respBody, result), "Failed to unmarshal response: %s", string(respBody))
+	}
+	return resp.StatusCode
+}
+
+func TestResumableUploads(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerEmail := fmt.Sprintf("owner_tus_%d@example.com", time.Now().UnixNano())
+	ownerToken, err := client.RegisterAndLogin("Owner Tus", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+
+	otherEmail := fmt.Sprintf("other_tus_%d@example.com", time.Now().UnixNano())
+	otherToken, err := client.RegisterAndLogin("Other Tus", otherEmail, "password", "owner")
+	require.NoError(t, err)
+	client.SetToken(ownerToken)
+
+	dogID, err := client.CreateDog("TusDog", "Husky", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	var event map[string]interface{}
+	status := postMultipart(t, ownerToken, "/events", map[string]interface{}{
+		"dog_id": dogID,
+		"type":   "walk",
+		"note":   "first swim",
+	}, nil, &event)
+	require.Equal(t, http.StatusCreated, status)
+	eventID := uint(event["id"].(float64))
+
+	t.Run("Discovery", func(t *testing.T) {
+		status, headers := tusRequest(t, "", "OPTIONS", "/uploads", map[string]string{"Tus-Resumable": ""}, nil)
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, "1.0.0", headers.Get("Tus-Version"))
+		require.Contains(t, headers.Get("Tus-Extension"), "creation")
+		require.Contains(t, headers.Get("Tus-Extension"), "termination")
+		require.NotEmpty(t, headers.Get("Tus-Max-Size"))
+	})
+
+	t.Run("Protocol Version Required", func(t *testing.T) {
+		status, headers := tusRequest(t, ownerToken, "POST", "/uploads", map[string]string{
+			"Tus-Resumable": "0.2.2",
+			"Upload-Length": "10",
+		}, nil)
+		require.Equal(t, http.StatusPreconditionFailed, status)
+		require.Equal(t, "1.0.0", headers.Get("Tus-Version"))
+	})
+
+	t.Run("Upload In Chunks And Attach", func(t *testing.T) {
+		location := createUpload(t, ownerToken, "swim.mp4", len(testMP4))
+		third := len(testMP4) / 3
+
+		status, headers := tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": "0"}, testMP4[:third])
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, strconv.Itoa(third), headers.Get("Upload-Offset"))
+
+		// Resume from the offset the server reports
+		status, headers = tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, strconv.Itoa(third), headers.Get("Upload-Offset"))
+		require.Equal(t, strconv.Itoa(len(testMP4)), headers.Get("Upload-Length"))
+		require.Equal(t, "no-store", headers.Get("Cache-Control"))
+
+		status, headers = tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": "0"}, testMP4[:third])
+		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, strconv.Itoa(third), headers.Get("Upload-Offset"))
+
+		status, _ = tusRequest(t, ownerToken, "PATCH", location, map[string]string{
+			"Upload-Offset": strconv.Itoa(third),
+			"Content-Type":  "application/octet-stream",
+		}, testMP4[third:])
+		require.Equal(t, http.StatusUnsupportedMediaType, status)
+
+		status, _ = tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": strconv.Itoa(third)}, append(testMP4[third:], 'x'))
+		require.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+		status, _ = tusRequest(t, otherToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, _ = tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": strconv.Itoa(third)}, testMP4[third:2*third])
+		require.Equal(t, http.StatusNoContent, status)
+		status, headers = tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": strconv.Itoa(2 * third)}, testMP4[2*third:])
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, strconv.Itoa(len(testMP4)), headers.Get("Upload-Offset"))
+
+		id := uploadID(location)
+		require.Equal(t, http.StatusNotFound, attachUploads(t, otherToken, eventID, []string{id}, nil))
+
+		var added []map[string]interface{}
+		require.Equal(t, http.StatusCreated, attachUploads(t, ownerToken, eventID, []string{id}, &added))
+		require.Len(t, added, 1)
+		require.Equal(t, "swim.mp4", added[0]["filename"])
+		require.Equal(t, "video/mp4", added[0]["content_type"])
+		require.Equal(t, float64(len(testMP4)), added[0]["size"])
+
+		resp := getAttachment(t, ownerToken, added[0]["url"].(string))
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		status, body := download(t, resp.Header.Get("Location"))
+		require.Equal(t, http.StatusOK, status)
+		require.True(t, body == string(testMP4), "downloaded file differs from the upload")
+
+		// An upload is attached once
+		require.Equal(t, http.StatusNotFound, attachUploads(t, ownerToken, eventID, []string{id}, nil))
+		status, _ = tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Creation With Upload", func(t *testing.T) {
+		status, headers := tusRequest(t, ownerToken, "POST", "/uploads", map[string]string{
+			"Upload-Length":   strconv.Itoa(len(testPDF)),
+			"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("lab.pdf")) + ",filetype " + base64.StdEncoding.EncodeToString([]byte("application/pdf")),
+		}, testPDF)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, strconv.Itoa(len(testPDF)), headers.Get("Upload-Offset"))
+
+		var added []map[string]interface{}
+		require.Equal(t, http.StatusCreated, attachUploads(t, ownerToken, eventID, []string{uploadID(headers.Get("Location"))}, &added))
+		require.Equal(t, "application/pdf", added[0]["content_type"])
+	})
+
+	t.Run("Incomplete Upload Cannot Be Attached", func(t *testing.T) {
+		location := createUpload(t, ownerToken, "walk.mp4", len(testMP4))
+		status, _ := tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": "0"}, testMP4[:100])
+		require.Equal(t, http.StatusNoContent, status)
+		require.Equal(t, http.StatusNotFound, attachUploads(t, ownerToken, eventID, []string{uploadID(location)}, nil))
+	})
+
+	t.Run("Content Checked On Completion", func(t *testing.T) {
+		fake := []byte("MZ\x90\x00\x03\x00\x00\x00 not a video")
+		location := createUpload(t, ownerToken, "clip.mp4", len(fake))
+		status, _ := tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": "0"}, fake)
+		require.Equal(t, http.StatusBadRequest, status)
+		status, _ = tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status, "a rejected upload is removed")
+	})
+
+	t.Run("Rejected On Creation", func(t *testing.T) {
+		status, _ := tusRequest(t, ownerToken, "POST", "/uploads", map[string]string{
+			"Upload-Length":   "100",
+			"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("setup.exe")),
+		}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = tusRequest(t, ownerToken, "POST", "/uploads", map[string]string{
+			"Upload-Length":   strconv.FormatInt(1<<40, 10),
+			"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("long.mp4")),
+		}, nil)
+		require.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+		status, _ = tusRequest(t, ownerToken, "POST", "/uploads", nil, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Termination", func(t *testing.T) {
+		location := createUpload(t, ownerToken, "cancel.mp4", len(testMP4))
+		status, _ := tusRequest(t, ownerToken, "PATCH", location, map[string]string{"Upload-Offset": "0"}, testMP4[:1000])
+		require.Equal(t, http.StatusNoContent, status)
+
+		status, _ = tusRequest(t, otherToken, "DELETE", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+		status, _ = tusRequest(t, ownerToken, "DELETE", location, nil, nil)
+		require.Equal(t, http.StatusNoContent, status)
+		status, _ = tusRequest(t, ownerToken, "HEAD", location, nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Anonymous Rejected", func(t *testing.T) {
+		status, _ := tusRequest(t, "", "POST", "/uploads", map[string]string{"Upload-Length": "10"}, nil)
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
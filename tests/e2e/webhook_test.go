@@ -0,0 +1,296 @@
+package e2e
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// webhookRequest is a delivery received by a webhookReceiver
+type webhookRequest struct {
+	Header http.Header
+	Body   []byte
+}
+
+// webhookReceiver is an HTTP server the pawtrack server posts webhooks to
+type webhookReceiver struct {
+	URL      string
+	Requests chan webhookRequest
+	status   atomic.Int32
+}
+
+// newWebhookReceiver starts a receiver reachable by the server under test.
+// E2E_WEBHOOK_HOST overrides the advertised host, e.g. host.docker.internal
+// when the server runs in a container. The server must run with
+// WEBHOOK_ALLOW_PRIVATE_NETWORKS=true to deliver to it.
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+
+	host := os.Getenv("E2E_WEBHOOK_HOST")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+
+	r := &webhookReceiver{
+		URL:      fmt.Sprintf("http://%s:%d/hook", host, listener.Addr().(*net.TCPAddr).Port),
+		Requests: make(chan webhookRequest, 16),
+	}
+	r.status.Store(http.StatusOK)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.Requests <- webhookRequest{Header: req.Header.Clone(), Body: body}
+		w.WriteHeader(int(r.status.Load()))
+		fmt.Fprint(w, "ok")
+	})}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	return r
+}
+
+// next waits for the next delivery
+func (r *webhookReceiver) next(t *testing.T) webhookRequest {
+	select {
+	case req := <-r.Requests:
+		return req
+	case <-time.After(15 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+		return webhookRequest{}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	client := NewTestClient(BaseURL)
+	client.SetT(t)
+
+	ownerEmail := fmt.Sprintf("owner_webhooks_%d@example.com", time.Now().UnixNano())
+	ownerToken, err := client.RegisterAndLogin("Owner Webhooks", ownerEmail, "password", "owner")
+	require.NoError(t, err)
+
+	dogID, err := client.CreateDog("HookDog", "Collie", "2020-01-01T00:00:00Z")
+	require.NoError(t, err)
+
+	receiver := newWebhookReceiver(t)
+
+	t.Run("Validation", func(t *testing.T) {
+		status := client.Post("/webhooks", map[string]interface{}{"url": receiver.URL, "event_types": []string{"dog.exploded"}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = client.Post("/webhooks", map[string]interface{}{"url": "ftp://example.com/hook", "event_types": []string{"event.created"}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status = client.Post("/webhooks", map[string]interface{}{"url": receiver.URL, "event_types": []string{}}, nil)
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	var webhook map[string]interface{}
+	status := client.Post("/webhooks", map[string]interface{}{
+		"url":         receiver.URL,
+		"description": "e2e",
+		"event_types": []string{"event.created", "invite.accepted"},
+	}, &webhook)
+	require.Equal(t, http.StatusCreated, status)
+	webhookID := uint(webhook["id"].(float64))
+	secret, _ := webhook["secret"].(string)
+	require.NotEmpty(t, secret)
+
+	t.Run("Secret is only returned on creation", func(t *testing.T) {
+		var list []map[string]interface{}
+		status := client.Get("/webhooks", &list)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, list, 1)
+		require.Nil(t, list[0]["secret"])
+		require.ElementsMatch(t, []interface{}{"event.created", "invite.accepted"}, list[0]["event_types"])
+	})
+
+	// A second user with their own endpoint must not receive the owner's activity
+	stranger := NewTestClient(BaseURL)
+	stranger.SetT(t)
+	_, err = stranger.RegisterAndLogin("Stranger Webhooks", fmt.Sprintf("stranger_webhooks_%d@example.com", time.Now().UnixNano()), "password", "owner")
+	require.NoError(t, err)
+	strangerReceiver := newWebhookReceiver(t)
+	status = stranger.Post("/webhooks", map[string]interface{}{"url": strangerReceiver.URL, "event_types": []string{"event.created"}}, nil)
+	require.Equal(t, http.StatusCreated, status)
+
+	t.Run("Stranger cannot see the endpoint", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, stranger.Get(fmt.Sprintf("/webhooks/%d", webhookID), nil))
+		require.Equal(t, http.StatusNotFound, stranger.Get(fmt.Sprintf("/webhooks/%d/deliveries", webhookID), nil))
+		require.Equal(t, http.StatusNotFound, stranger.Delete(fmt.Sprintf("/webhooks/%d", webhookID)))
+	})
+
+	t.Run("Event creation is delivered signed", func(t *testing.T) {
+		client.SetToken(ownerToken)
+		var event map[string]interface{}
+		status := client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk", "note": "Hooked walk"}, &event)
+		require.Equal(t, http.StatusCreated, status)
+
+		req := receiver.next(t)
+		require.Equal(t, "event.created", req.Header.Get("X-Pawtrack-Event"))
+		require.NotEmpty(t, req.Header.Get("X-Pawtrack-Delivery"))
+
+		timestamp := req.Header.Get("X-Pawtrack-Timestamp")
+		require.NotEmpty(t, timestamp)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(req.Body)
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Pawtrack-Signature"))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(req.Body, &payload))
+		require.Equal(t, "event.created", payload["type"])
+		require.Equal(t, float64(dogID), payload["dog_id"])
+		require.NotEmpty(t, payload["id"])
+		require.Equal(t, event["id"], payload["data"].(map[string]interface{})["id"])
+	})
+
+	t.Run("Invite acceptance is delivered", func(t *testing.T) {
+		consultant := NewTestClient(BaseURL)
+		consultant.SetT(t)
+		_, err := consultant.RegisterAndLogin("Consultant Webhooks", fmt.Sprintf("consultant_webhooks_%d@example.com", time.Now().UnixNano()), "password", "consultant")
+		require.NoError(t, err)
+
+		var profile map[string]interface{}
+		status := consultant.Put("/consultants/profile", map[string]interface{}{"description": "Webhook tester"}, &profile)
+		require.Equal(t, http.StatusOK, status)
+
+		client.SetToken(ownerToken)
+		var invite map[string]interface{}
+		status = client.Post(fmt.Sprintf("/consultants/%.0f/invite", profile["user_id"]), map[string]interface{}{"dog_id": dogID}, &invite)
+		require.Equal(t, http.StatusCreated, status)
+
+		status = consultant.Post(fmt.Sprintf("/invites/accept?token=%s", invite["token"]), nil, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		req := receiver.next(t)
+		require.Equal(t, "invite.accepted", req.Header.Get("X-Pawtrack-Event"))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(req.Body, &payload))
+		data := payload["data"].(map[string]interface{})
+		require.Equal(t, invite["id"], data["id"])
+		require.Equal(t, "accepted", data["status"])
+		require.Nil(t, data["token"])
+	})
+
+	var failedDeliveryID uint
+	var failedPayloadID interface{}
+
+	t.Run("Failed delivery is logged and scheduled for retry", func(t *testing.T) {
+		receiver.status.Store(http.StatusInternalServerError)
+		defer receiver.status.Store(http.StatusOK)
+
+		client.SetToken(ownerToken)
+		status := client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "feed"}, nil)
+		require.Equal(t, http.StatusCreated, status)
+		req := receiver.next(t)
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(req.Body, &payload))
+		failedPayloadID = payload["id"]
+
+		// The attempt is saved right after the response
+		var delivery map[string]interface{}
+		require.Eventually(t, func() bool {
+			var resp map[string]interface{}
+			status := client.Get(fmt.Sprintf("/webhooks/%d/deliveries?status=pending", webhookID), &resp)
+			require.Equal(t, http.StatusOK, status)
+			for _, raw := range resp["deliveries"].([]interface{}) {
+				d := raw.(map[string]interface{})
+				if d["attempts"] == float64(1) {
+					delivery = d
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 100*time.Millisecond)
+
+		require.Equal(t, float64(500), delivery["last_status_code"])
+		require.NotContains(t, delivery, "last_response", "response bodies are not stored")
+		require.NotEmpty(t, delivery["last_error"])
+		require.Equal(t, failedPayloadID, delivery["payload"].(map[string]interface{})["id"])
+
+		nextAttempt, err := time.Parse(time.RFC3339Nano, delivery["next_attempt_at"].(string))
+		require.NoError(t, err)
+		require.True(t, nextAttempt.After(time.Now()), "retry must be scheduled with backoff")
+
+		failedDeliveryID = uint(delivery["id"].(float64))
+	})
+
+	t.Run("Redeliver", func(t *testing.T) {
+		require.NotZero(t, failedDeliveryID)
+		client.SetToken(ownerToken)
+
+		var redelivery map[string]interface{}
+		status := client.Post(fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", webhookID, failedDeliveryID), nil, &redelivery)
+		require.Equal(t, http.StatusAccepted, status)
+		require.Equal(t, float64(failedDeliveryID), redelivery["redelivery_of"])
+
+		req := receiver.next(t)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(req.Body, &payload))
+		require.Equal(t, failedPayloadID, payload["id"], "redelivery keeps the payload ID for deduplication")
+
+		require.Eventually(t, func() bool {
+			var resp map[string]interface{}
+			client.Get(fmt.Sprintf("/webhooks/%d/deliveries?status=succeeded", webhookID), &resp)
+			for _, raw := range resp["deliveries"].([]interface{}) {
+				if raw.(map[string]interface{})["id"] == redelivery["id"] {
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 100*time.Millisecond)
+
+		// Deliveries of other endpoints cannot be redelivered through this one
+		status = stranger.Post(fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", webhookID, failedDeliveryID), nil, nil)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Paused endpoint receives nothing", func(t *testing.T) {
+		client.SetToken(ownerToken)
+		var updated map[string]interface{}
+		status := client.Put(fmt.Sprintf("/webhooks/%d", webhookID), map[string]interface{}{"active": false}, &updated)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, false, updated["active"])
+
+		var before map[string]interface{}
+		client.Get(fmt.Sprintf("/webhooks/%d/deliveries", webhookID), &before)
+
+		status = client.Post("/events", map[string]interface{}{"dog_id": dogID, "type": "walk"}, nil)
+		require.Equal(t, http.StatusCreated, status)
+
+		var after map[string]interface{}
+		client.Get(fmt.Sprintf("/webhooks/%d/deliveries", webhookID), &after)
+		require.Equal(t, before["total_count"], after["total_count"])
+
+		status = client.Post(fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", webhookID, failedDeliveryID), nil, nil)
+		require.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("Stranger received nothing", func(t *testing.T) {
+		select {
+		case req := <-strangerReceiver.Requests:
+			t.Fatalf("stranger's endpoint received %s", req.Header.Get("X-Pawtrack-Event"))
+		case <-time.After(500 * time.Millisecond):
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		client.SetToken(ownerToken)
+		require.Equal(t, http.StatusNoContent, client.Delete(fmt.Sprintf("/webhooks/%d", webhookID)))
+		require.Equal(t, http.StatusNotFound, client.Get(fmt.Sprintf("/webhooks/%d", webhookID), nil))
+	})
+}